@@ -9,6 +9,7 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/swaggo/http-swagger v1.3.4
 	go.uber.org/mock v0.5.0
+	golang.org/x/time v0.5.0
 	gotest.tools v2.2.0+incompatible
 )
 
@@ -18,6 +19,7 @@ require (
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rogpeppe/go-internal v1.13.1 // indirect
+	golang.org/x/text v0.21.0 // indirect
 )
 
 require (
@@ -32,8 +34,8 @@ require (
 	github.com/stretchr/testify v1.10.0
 	github.com/swaggo/files v1.0.1 // indirect
 	github.com/swaggo/swag v1.16.2
-	golang.org/x/net v0.34.0 // indirect
-	golang.org/x/sync v0.7.0
+	golang.org/x/net v0.34.0
+	golang.org/x/sync v0.10.0
 	golang.org/x/tools v0.22.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
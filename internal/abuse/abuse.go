@@ -0,0 +1,296 @@
+package abuse
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/forwarded"
+)
+
+/*
+The guard spots two kinds of probing and blocks the client behind them for a while.  Payment id enumeration shows up as a run of lookups for payments that do not exist, card testing as small payments or verifications tried with many different card numbers.  Either within one window blocks the client from the guarded endpoints until the block runs out, every block is logged and listed by Blocks.
+
+Like the rate limiter, clients are told apart by IP until the gateway has API keys.  A request with no IP, as over a Unix socket without a trusted proxy forwarding the client's, is never tracked or blocked: it would share its key with every other such request, and blocking it would block them all for BlockFor.  Card numbers are only held as a hash, and only for as long as the window.
+*/
+
+const (
+	ReasonLookupMisses     = "payment_lookup_misses"
+	ReasonCardTesting      = "card_testing"
+	ReasonFailedChallenges = "failed_challenges"
+)
+
+// maxBodySize bounds how much of a payment request is read to find its card number and amount.
+const maxBodySize = 1 << 20
+
+type Config struct {
+	// Window is how long misses and cards are counted for before the count starts again
+	Window time.Duration
+	// MaxLookupMisses is how many lookups of missing payments a client may make in a window
+	MaxLookupMisses int
+	// MaxCards is how many different cards a client may try small payments with in a window
+	MaxCards int
+	// SmallAmount is the largest amount, in minor units, counted as a small payment
+	SmallAmount int
+	// BlockFor is how long a client stays blocked
+	BlockFor time.Duration
+	// ChallengeAfter is how many payment link payments a client may make in a window before it is challenged
+	ChallengeAfter int
+	// MaxChallengeFailures is how many challenges a client may fail in a window
+	MaxChallengeFailures int
+}
+
+var DefaultConfig = Config{
+	Window:               10 * time.Minute,
+	MaxLookupMisses:      20,
+	MaxCards:             5,
+	SmallAmount:          500,
+	BlockFor:             time.Hour,
+	ChallengeAfter:       3,
+	MaxChallengeFailures: 5,
+}
+
+// Block is a client that is currently blocked.
+type Block struct {
+	Client string    `json:"client"`
+	Reason string    `json:"reason"`
+	Until  time.Time `json:"until"`
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+type activity struct {
+	windowStart       time.Time
+	misses            int
+	cards             map[[sha256.Size]byte]struct{}
+	linkPayments      int
+	challengeFailures int
+	challengePassed   bool
+	blockedUntil      time.Time
+	reason            string
+}
+
+type Guard struct {
+	mu         sync.Mutex
+	config     Config
+	challenger Challenger
+	clients    map[string]*activity
+	lastSweep  time.Time
+	now        func() time.Time
+}
+
+func NewGuard(config Config) *Guard {
+	return &Guard{
+		config:    config,
+		clients:   map[string]*activity{},
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Blocked reports whether the client is blocked, and if so for how much longer.
+func (g *Guard) Blocked(client string) (bool, time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	a, ok := g.clients[client]
+	if !ok {
+		return false, 0
+	}
+	if remaining := a.blockedUntil.Sub(g.now()); remaining > 0 {
+		return true, remaining
+	}
+	return false, 0
+}
+
+// RecordMiss counts a lookup of a payment that does not exist.
+func (g *Guard) RecordMiss(client string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	a := g.activity(client)
+	a.misses++
+	if a.misses > g.config.MaxLookupMisses {
+		g.block(client, a, ReasonLookupMisses)
+	}
+}
+
+// RecordAuthorization counts a card tried for amount, only small amounts count towards card testing.
+func (g *Guard) RecordAuthorization(client string, cardNumber int, amount int) {
+	if cardNumber == 0 || amount > g.config.SmallAmount {
+		return
+	}
+	fingerprint := sha256.Sum256([]byte(strconv.Itoa(cardNumber)))
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	a := g.activity(client)
+	if a.cards == nil {
+		a.cards = map[[sha256.Size]byte]struct{}{}
+	}
+	a.cards[fingerprint] = struct{}{}
+	if len(a.cards) > g.config.MaxCards {
+		g.block(client, a, ReasonCardTesting)
+	}
+}
+
+// Blocks returns the clients that are currently blocked, soonest to be lifted first.
+func (g *Guard) Blocks() []Block {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	blocks := []Block{}
+	for client, a := range g.clients {
+		if a.blockedUntil.After(now) {
+			blocks = append(blocks, Block{Client: client, Reason: a.reason, Until: a.blockedUntil})
+		}
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Until.Before(blocks[j].Until) })
+	return blocks
+}
+
+// activity returns the client's activity in the current window, starting a new window if the last one is over.
+func (g *Guard) activity(client string) *activity {
+	now := g.now()
+	g.evictIdle(now)
+
+	a, ok := g.clients[client]
+	if !ok {
+		a = &activity{windowStart: now}
+		g.clients[client] = a
+	}
+	if now.Sub(a.windowStart) >= g.config.Window {
+		a.windowStart = now
+		a.misses = 0
+		a.cards = nil
+		a.linkPayments = 0
+		a.challengeFailures = 0
+		a.challengePassed = false
+	}
+	return a
+}
+
+func (g *Guard) block(client string, a *activity, reason string) {
+	if a.blockedUntil.After(g.now()) {
+		return
+	}
+	a.blockedUntil = g.now().Add(g.config.BlockFor)
+	a.reason = reason
+	log.Printf("blocked %s until %s: %s", client, a.blockedUntil.Format(time.RFC3339), reason)
+}
+
+// evictIdle drops clients whose window is over and who are not blocked, at most once per window.
+func (g *Guard) evictIdle(now time.Time) {
+	if now.Sub(g.lastSweep) < g.config.Window {
+		return
+	}
+	g.lastSweep = now
+
+	for client, a := range g.clients {
+		if now.Sub(a.windowStart) >= g.config.Window && !a.blockedUntil.After(now) {
+			delete(g.clients, client)
+		}
+	}
+}
+
+// LookupMiddleware turns blocked clients away from payment lookups and counts the lookups that find nothing.
+func (g *Guard) LookupMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := forwarded.ClientIP(r)
+		if client == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if blocked, retryAfter := g.Blocked(client); blocked {
+			writeBlocked(w, retryAfter)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == http.StatusNotFound {
+			g.RecordMiss(client)
+		}
+	})
+}
+
+// AuthorizationMiddleware turns blocked clients away from payments and verifications and counts the cards they try.
+func (g *Guard) AuthorizationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := forwarded.ClientIP(r)
+		if client == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if blocked, retryAfter := g.Blocked(client); blocked {
+			writeBlocked(w, retryAfter)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// cutting the body short would hand the handler broken JSON
+		if len(body) > maxBodySize {
+			writeError(w, http.StatusRequestEntityTooLarge, "The request body must be at most 1MB.")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// anything that does not decode is left for the handler to reject
+		var attempt struct {
+			CardNumber    int `json:"card_number"`
+			Amount        int `json:"amount"`
+			PaymentMethod *struct {
+				Card *struct {
+					Number int `json:"number"`
+				} `json:"card"`
+			} `json:"payment_method"`
+		}
+		if json.Unmarshal(body, &attempt) == nil {
+			cardNumber := attempt.CardNumber
+			if attempt.PaymentMethod != nil && attempt.PaymentMethod.Card != nil {
+				cardNumber = attempt.PaymentMethod.Card.Number
+			}
+			g.RecordAuthorization(client, cardNumber, attempt.Amount)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func writeBlocked(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+	if err := json.NewEncoder(w).Encode(errorResponse{
+		Message: fmt.Sprintf("Blocked after suspicious activity. Please try again in %s.", retryAfter.Round(time.Second)),
+	}); err != nil {
+		log.Printf("Failed to encode error response: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package abuse_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/abuse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = abuse.Config{
+	Window:          time.Minute,
+	MaxLookupMisses: 2,
+	MaxCards:        2,
+	SmallAmount:     100,
+	BlockFor:        time.Hour,
+}
+
+func TestGuard_LookupMisses(t *testing.T) {
+	guard := abuse.NewGuard(testConfig)
+	handler := guard.LookupMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/payments/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	lookup := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/payments/"+id, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// found payments do not count
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, lookup("found").Code)
+	}
+	assert.Equal(t, http.StatusNotFound, lookup("missing").Code)
+	assert.Equal(t, http.StatusNotFound, lookup("missing").Code)
+	assert.Equal(t, http.StatusNotFound, lookup("missing").Code)
+
+	// the third miss blocked the client, even from payments that exist
+	w := lookup("found")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "3600", w.Header().Get("Retry-After"))
+
+	blocks := guard.Blocks()
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "10.0.0.1", blocks[0].Client)
+	assert.Equal(t, abuse.ReasonLookupMisses, blocks[0].Reason)
+
+	// other clients are unaffected
+	blocked, _ := guard.Blocked("10.0.0.2")
+	assert.False(t, blocked)
+}
+
+func TestGuard_CardTesting(t *testing.T) {
+	guard := abuse.NewGuard(testConfig)
+	var served int
+	handler := guard.AuthorizationMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the handler still gets the whole body
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `"amount"`)
+		served++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	pay := func(body string) int {
+		req := httptest.NewRequest("POST", "/api/payments", strings.NewReader(body))
+		req.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// large payments and the same card again do not count
+	assert.Equal(t, http.StatusOK, pay(`{"card_number": 2222405343248871, "amount": 5000}`))
+	assert.Equal(t, http.StatusOK, pay(`{"card_number": 2222405343248872, "amount": 5000}`))
+	assert.Equal(t, http.StatusOK, pay(`{"card_number": 2222405343248873, "amount": 0}`))
+	assert.Equal(t, http.StatusOK, pay(`{"card_number": 2222405343248873, "amount": 1}`))
+	assert.Equal(t, http.StatusOK, pay(`{"payment_method": {"type": "card", "card": {"number": 2222405343248874}}, "amount": 1}`))
+
+	// a third small card is one too many
+	assert.Equal(t, http.StatusOK, pay(`{"card_number": 2222405343248875, "amount": 1}`))
+	assert.Equal(t, http.StatusTooManyRequests, pay(`{"card_number": 2222405343248876, "amount": 5000}`))
+	assert.Equal(t, 6, served)
+
+	blocks := guard.Blocks()
+	require.Len(t, blocks, 1)
+	assert.Equal(t, abuse.ReasonCardTesting, blocks[0].Reason)
+}
+
+func TestGuard_WithoutAddressNotBlocked(t *testing.T) {
+	guard := abuse.NewGuard(testConfig)
+	handler := guard.LookupMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	// requests over a Unix socket have no address, blocking one would block every client
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest("GET", "/api/payments/missing", nil)
+		req.RemoteAddr = ""
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	}
+	assert.Empty(t, guard.Blocks())
+}
+
+func TestGuard_BodyTooLarge(t *testing.T) {
+	guard := abuse.NewGuard(testConfig)
+	handler := guard.AuthorizationMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("POST", "/api/payments", strings.NewReader(strings.Repeat(" ", 1<<20+1)))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
@@ -0,0 +1,125 @@
+package abuse
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/forwarded"
+)
+
+/*
+A payment link can be paid by anyone who has its id, with no merchant checkout in front of it, so it is where bots land.  Once a Challenger is set, a client that looks automated has to pass a challenge (a CAPTCHA, a proof of work, whatever the challenger checks) before its payment is let through.  A client looks automated when it has paid links more than ChallengeAfter times in a window, or is halfway to being blocked for enumeration or card testing.
+
+The answer travels in the ChallengeHeader header.  Passing lets the client pay for the rest of the window without another challenge, failing counts against it like a lookup miss does and too many failures block it.  Without a Challenger nobody is challenged.
+*/
+
+// ChallengeHeader carries the client's answer to its challenge.
+const ChallengeHeader = "X-Challenge-Response"
+
+// Challenger checks a client's answer to a challenge, e.g. a CAPTCHA token with the provider that issued it.
+type Challenger interface {
+	Verify(ctx context.Context, client, response string) (bool, error)
+}
+
+// SetChallenger makes the guard challenge clients that look automated before they pay a payment link.
+func (g *Guard) SetChallenger(challenger Challenger) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.challenger = challenger
+}
+
+// challengeRequired counts a payment link payment and reports whether the client must pass a challenge first.
+func (g *Guard) challengeRequired(client string) (Challenger, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.challenger == nil {
+		return nil, false
+	}
+
+	a := g.activity(client)
+	a.linkPayments++
+	suspicious := a.linkPayments > g.config.ChallengeAfter ||
+		a.misses > g.config.MaxLookupMisses/2 ||
+		len(a.cards) > g.config.MaxCards/2
+	return g.challenger, suspicious && !a.challengePassed
+}
+
+// recordChallenge feeds the outcome of a challenge back into the client's activity.
+func (g *Guard) recordChallenge(client string, passed bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	a := g.activity(client)
+	if passed {
+		a.challengePassed = true
+		return
+	}
+	a.challengeFailures++
+	if a.challengeFailures > g.config.MaxChallengeFailures {
+		g.block(client, a, ReasonFailedChallenges)
+	}
+}
+
+// ChallengeMiddleware turns blocked clients away from payment link payments and challenges those that look automated.
+func (g *Guard) ChallengeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := forwarded.ClientIP(r)
+		if client == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if blocked, retryAfter := g.Blocked(client); blocked {
+			writeBlocked(w, retryAfter)
+			return
+		}
+
+		challenger, required := g.challengeRequired(client)
+		if !required {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		response := r.Header.Get(ChallengeHeader)
+		if response == "" {
+			writeError(w, http.StatusForbidden, "A challenge must be passed before paying. Send the response in the "+ChallengeHeader+" header.")
+			return
+		}
+
+		passed, err := challenger.Verify(r.Context(), client, response)
+		if err != nil {
+			log.Printf("Failed to verify challenge from %s: %v", client, err)
+			writeError(w, http.StatusServiceUnavailable, "The challenge could not be checked. Please try again later.")
+			return
+		}
+		g.recordChallenge(client, passed)
+		if !passed {
+			writeError(w, http.StatusForbidden, "The challenge was not passed.")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(errorResponse{Message: message}); err != nil {
+		log.Printf("Failed to encode error response: %v", err)
+	}
+}
+
+// FakeChallenger passes the answer "pass" and fails anything else, it stands in for a real provider in dev mode.
+type FakeChallenger struct{}
+
+func NewFakeChallenger() *FakeChallenger {
+	return &FakeChallenger{}
+}
+
+func (c *FakeChallenger) Verify(ctx context.Context, client, response string) (bool, error) {
+	return response == "pass", nil
+}
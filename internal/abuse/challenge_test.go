@@ -0,0 +1,92 @@
+package abuse_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/abuse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingChallenger struct{}
+
+func (failingChallenger) Verify(ctx context.Context, client, response string) (bool, error) {
+	return false, errors.New("provider unavailable")
+}
+
+func payLink(handler http.Handler, client, response string) int {
+	req := httptest.NewRequest("POST", "/api/payment-links/abc/pay", nil)
+	req.RemoteAddr = client + ":1234"
+	if response != "" {
+		req.Header.Set(abuse.ChallengeHeader, response)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestGuard_Challenge(t *testing.T) {
+	config := testConfig
+	config.ChallengeAfter = 2
+	config.MaxChallengeFailures = 1
+	guard := abuse.NewGuard(config)
+	handler := guard.ChallengeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// nobody is challenged until there is a challenger
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, payLink(handler, "10.0.0.1", ""))
+	}
+
+	guard.SetChallenger(abuse.NewFakeChallenger())
+	assert.Equal(t, http.StatusOK, payLink(handler, "10.0.0.2", ""))
+	assert.Equal(t, http.StatusOK, payLink(handler, "10.0.0.2", ""))
+	assert.Equal(t, http.StatusForbidden, payLink(handler, "10.0.0.2", ""))
+
+	// passing lets the client through for the rest of the window
+	assert.Equal(t, http.StatusOK, payLink(handler, "10.0.0.2", "pass"))
+	assert.Equal(t, http.StatusOK, payLink(handler, "10.0.0.2", ""))
+
+	// failing too often blocks the client
+	assert.Equal(t, http.StatusOK, payLink(handler, "10.0.0.3", ""))
+	assert.Equal(t, http.StatusOK, payLink(handler, "10.0.0.3", ""))
+	assert.Equal(t, http.StatusForbidden, payLink(handler, "10.0.0.3", "wrong"))
+	assert.Equal(t, http.StatusForbidden, payLink(handler, "10.0.0.3", "wrong"))
+	assert.Equal(t, http.StatusTooManyRequests, payLink(handler, "10.0.0.3", "pass"))
+
+	blocks := guard.Blocks()
+	require.Len(t, blocks, 1)
+	assert.Equal(t, abuse.ReasonFailedChallenges, blocks[0].Reason)
+}
+
+func TestGuard_ChallengeSuspiciousClient(t *testing.T) {
+	guard := abuse.NewGuard(testConfig)
+	guard.SetChallenger(abuse.NewFakeChallenger())
+	handler := guard.ChallengeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// a client halfway to being blocked for card testing is challenged on its first payment
+	guard.RecordAuthorization("10.0.0.1", 2222405343248871, 1)
+	guard.RecordAuthorization("10.0.0.1", 2222405343248872, 1)
+	assert.Equal(t, http.StatusForbidden, payLink(handler, "10.0.0.1", ""))
+	assert.Equal(t, http.StatusOK, payLink(handler, "10.0.0.1", "pass"))
+}
+
+func TestGuard_ChallengerUnavailable(t *testing.T) {
+	config := testConfig
+	config.ChallengeAfter = 0
+	guard := abuse.NewGuard(config)
+	guard.SetChallenger(failingChallenger{})
+	handler := guard.ChallengeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	assert.Equal(t, http.StatusServiceUnavailable, payLink(handler, "10.0.0.1", "pass"))
+	assert.Empty(t, guard.Blocks())
+}
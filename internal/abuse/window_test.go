@@ -0,0 +1,37 @@
+package abuse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuard_WindowResetsAndEvicts(t *testing.T) {
+	now := time.Now()
+	guard := NewGuard(Config{Window: time.Minute, MaxLookupMisses: 2, MaxCards: 2, SmallAmount: 100, BlockFor: time.Hour})
+	guard.now = func() time.Time { return now }
+
+	guard.RecordAuthorization("10.0.0.1", 2222405343248871, 1)
+	guard.RecordAuthorization("10.0.0.1", 2222405343248872, 1)
+	guard.RecordMiss("10.0.0.2")
+	guard.RecordMiss("10.0.0.2")
+	guard.RecordMiss("10.0.0.2")
+
+	// the first client's cards are from an earlier window and no longer count
+	now = now.Add(time.Minute)
+	guard.RecordAuthorization("10.0.0.1", 2222405343248873, 1)
+	blocked, _ := guard.Blocked("10.0.0.1")
+	assert.False(t, blocked)
+
+	// the quiet client goes, the blocked one stays until its block is over
+	now = now.Add(time.Minute)
+	guard.RecordMiss("10.0.0.3")
+	assert.NotContains(t, guard.clients, "10.0.0.1")
+	assert.Contains(t, guard.clients, "10.0.0.2")
+
+	now = now.Add(time.Hour)
+	guard.RecordMiss("10.0.0.3")
+	assert.NotContains(t, guard.clients, "10.0.0.2")
+	assert.Empty(t, guard.Blocks())
+}
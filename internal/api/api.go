@@ -2,77 +2,195 @@ package api
 
 import (
 	"context"
-	"fmt"
-	"net"
 	"net/http"
 	"time"
 
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/abuse"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/forwarded"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/idempotency"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/ratelimit"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/seed"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/wallet"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/chi/v5"
-	"golang.org/x/sync/errgroup"
-)
-
-const (
-	bankURL = "http://localhost:8080"
 )
 
 type Api struct {
 	router             *chi.Mux
 	paymentsRepo       *repository.PaymentsRepository
 	domain             *domain.Domain
+	bus                events.Bus
+	limiter            *ratelimit.Limiter
+	guard              *abuse.Guard
+	idempotency        *idempotency.Store
+	proxies            *forwarded.Proxies
 	PostPaymentService *domain.PaymentServiceImpl
 }
 
-func New() *Api {
+func New(bankClient client.Client) *Api {
 	a := &Api{}
 	repo := repository.NewPaymentsRepository()
 	a.paymentsRepo = repo
-	client := client.NewClient(bankURL, 5*time.Second)
-	postPaymentService := domain.NewPaymentServiceImpl(repo, client)
+	a.bus = events.NewInMemoryBus()
+	a.bus.Subscribe(events.PaymentAuthorized, events.Log)
+	a.bus.Subscribe(events.PaymentDeclined, events.Log)
+	a.bus.Subscribe(events.PaymentVerified, events.Log)
+	a.bus.Subscribe(events.PaymentCaptured, events.Log)
+	a.bus.Subscribe(events.PaymentVoided, events.Log)
+	a.bus.Subscribe(events.PaymentRefunded, events.Log)
+	a.bus.Subscribe(events.PaymentExpired, events.Log)
+	a.bus.Subscribe(events.PaymentDisputed, events.Log)
+	a.bus.Subscribe(events.PaymentReversed, events.Log)
+	a.bus.Subscribe(events.PaymentFailed, events.Log)
+	a.bus.Subscribe(events.PaymentScheduled, events.Log)
+	a.bus.Subscribe(events.PaymentCancelled, events.Log)
+	a.limiter = ratelimit.NewLimiter(ratelimit.DefaultBudgets)
+	a.guard = abuse.NewGuard(abuse.DefaultConfig)
+	a.idempotency = idempotency.NewStore(idempotency.DefaultTTL)
+	postPaymentService := domain.NewPaymentServiceImpl(repo, bankClient, a.bus)
+	a.PostPaymentService = postPaymentService
 	a.domain = domain.NewDomain(postPaymentService)
 	a.setupRouter()
 
 	return a
 }
 
-func (a *Api) Run(ctx context.Context, addr string) error {
-	httpServer := &http.Server{
-		Addr:        addr,
-		Handler:     a.router,
-		BaseContext: func(_ net.Listener) context.Context { return ctx },
-	}
+// Subscribe registers handler for payment lifecycle events of the given type.
+func (a *Api) Subscribe(eventType events.Type, handler events.Handler) {
+	a.bus.Subscribe(eventType, handler)
+}
 
-	g, ctx := errgroup.WithContext(ctx)
+// SetAuthorizationValidity sets how long authorizations may go uncaptured before they expire, zero keeps each scheme's own window.  It must be called before the gateway starts serving.
+func (a *Api) SetAuthorizationValidity(validity time.Duration) {
+	a.PostPaymentService.SetAuthorizationValidity(validity)
+}
 
-	g.Go(func() error {
-		<-ctx.Done()
-		fmt.Printf("shutting down HTTP server\n")
-		return httpServer.Shutdown(ctx)
-	})
+// SetAVSDeclineResults makes the gateway decline payments whose address verification result is one of results.  It must be called before the gateway starts serving.
+func (a *Api) SetAVSDeclineResults(results []string) {
+	a.PostPaymentService.SetAVSDeclineResults(results)
+}
+
+// SetWalletDecrypter makes the gateway accept payments from the wallet type, e.g. wallet.ApplePay, unwrapping them with
+// decrypter.  It must be called before the gateway starts serving.
+func (a *Api) SetWalletDecrypter(walletType string, decrypter wallet.Decrypter) {
+	a.PostPaymentService.SetWalletDecrypter(walletType, decrypter)
+}
 
-	g.Go(func() error {
-		fmt.Printf("starting HTTP server on %s\n", addr)
-		err := httpServer.ListenAndServe()
-		if err != nil && err != http.ErrServerClosed {
-			return err
-		}
+// SetChallenger makes the gateway challenge clients that look automated before they pay a payment link, checking
+// their answers with challenger.  It must be called before the gateway starts serving.
+func (a *Api) SetChallenger(challenger abuse.Challenger) {
+	a.guard.SetChallenger(challenger)
+}
 
-		return nil
-	})
+// SetTrustedProxies makes the gateway take the client's address from X-Forwarded-For on requests from proxies, so
+// rate limits and abuse blocks apply to the client rather than the proxy.  It must be called before the gateway starts
+// serving.
+func (a *Api) SetTrustedProxies(proxies *forwarded.Proxies) {
+	a.proxies = proxies
+}
+
+// StartPaymentWorkers starts the workers that send async payments to the bank, they stop when ctx is done.  Zero
+// workers turns async mode off.  It must be called before the gateway starts serving.
+func (a *Api) StartPaymentWorkers(ctx context.Context, workers int) {
+	a.PostPaymentService.SetAsyncWorkers(workers)
+	a.PostPaymentService.RunWorkers(ctx)
+}
+
+// RunScheduler sends scheduled payments to the bank as they fall due, checking every interval until ctx is done.
+func (a *Api) RunScheduler(ctx context.Context, interval time.Duration) {
+	a.PostPaymentService.RunScheduler(ctx, interval)
+}
 
-	return g.Wait()
+// RunExpiry voids authorizations that were never captured, checking every interval until ctx is done.
+func (a *Api) RunExpiry(ctx context.Context, interval time.Duration) {
+	a.PostPaymentService.RunExpiry(ctx, interval)
+}
+
+// Seed loads demo data into the gateway's storage before it starts serving.
+func (a *Api) Seed(ctx context.Context, data *seed.Data) error {
+	return data.Apply(ctx, a.paymentsRepo)
 }
 
 func (a *Api) setupRouter() {
 	a.router = chi.NewRouter()
+	a.router.Use(a.forwardedFor)
 	a.router.Use(middleware.Logger)
+	a.router.Use(timeoutMiddleware(requestTimeout))
 
 	a.router.Get("/ping", a.PingHandler())
-	a.router.Get("/swagger/*", a.SwaggerHandler())
+	a.router.Get(openAPIPath, a.OpenAPIHandler())
+	a.router.Handle("/docs", http.RedirectHandler("/docs/index.html", http.StatusMovedPermanently))
+	a.router.Get("/docs/*", a.DocsHandler())
+	// the Swagger UI used to live here
+	a.router.Handle("/swagger/*", http.RedirectHandler("/docs/index.html", http.StatusMovedPermanently))
+
+	for version, routes := range a.versions() {
+		a.router.Route("/api/"+version, routes)
+	}
+	// the unversioned paths predate versioning, they stay v1 so existing integrations keep working
+	a.router.Route("/api", a.v1Routes)
+}
+
+// forwardedFor puts the address of the client a trusted proxy forwarded a request for in its RemoteAddr.
+func (a *Api) forwardedFor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.proxies.Rewrite(r)
+		next.ServeHTTP(w, r)
+	})
+}
 
-	a.router.Get("/api/payments/{id}", a.GetPaymentHandler())
-	a.router.Post("/api/payments", a.PostPaymentHandler())
+// versions gives the function registering each API version's routes, they are mounted under /api/<version>.  A
+// version that changes response shapes gets an entry of its own, registering new handlers where the shape changes and
+// the previous version's everywhere else, so the older versions are never touched.
+func (a *Api) versions() map[string]func(chi.Router) {
+	return map[string]func(chi.Router){
+		"v1": a.v1Routes,
+	}
+}
+
+func (a *Api) v1Routes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(a.limiter.Middleware(ratelimit.Read))
+		r.Get("/changelog", a.ChangelogHandler())
+		r.Get("/payments", a.ListPaymentsHandler())
+		r.With(a.guard.LookupMiddleware).Get("/payments/{id}", a.GetPaymentHandler())
+		r.Get("/payments/{id}/refunds", a.GetRefundsHandler())
+		r.Get("/payments/{id}/disputes", a.GetDisputesHandler())
+		r.Get("/disputes/{id}/evidence-bundle", a.DisputeEvidenceBundleHandler())
+		r.Get("/payment-intents/{id}", a.GetPaymentIntentHandler())
+		r.Get("/payment-links/{id}", a.GetPaymentLinkHandler())
+		r.Get("/installment-plans/{id}", a.GetInstallmentPlanHandler())
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(a.limiter.Middleware(ratelimit.Write))
+		r.Use(a.idempotency.Middleware)
+		r.With(a.guard.AuthorizationMiddleware).Post("/payments", a.PostPaymentHandler())
+		r.Post("/payments/{id}/capture", a.CapturePaymentHandler())
+		r.Post("/payments/{id}/void", a.VoidPaymentHandler())
+		r.Post("/payments/{id}/refund", a.RefundPaymentHandler())
+		r.Post("/payments/{id}/retry", a.RetryPaymentHandler())
+		r.Post("/payments/{id}/reverse", a.ReversePaymentHandler())
+		r.Delete("/payments/{id}", a.CancelPaymentHandler())
+		r.Post("/customers/{id}/cards", a.StoreCardHandler())
+		r.Post("/payment-intents", a.CreatePaymentIntentHandler())
+		r.Post("/payment-intents/{id}/confirm", a.ConfirmPaymentIntentHandler())
+		r.Post("/payment-links", a.CreatePaymentLinkHandler())
+		r.With(a.guard.ChallengeMiddleware).Post("/payment-links/{id}/pay", a.PayPaymentLinkHandler())
+		r.Post("/installment-plans", a.CreateInstallmentPlanHandler())
+		r.Post("/installment-plans/{id}/cancel", a.CancelInstallmentPlanHandler())
+		r.With(a.guard.AuthorizationMiddleware).Post("/card-verifications", a.CardVerificationHandler())
+		r.Post("/disputes", a.OpenDisputeHandler())
+		r.Post("/disputes/{id}/evidence", a.DisputeEvidenceHandler())
+		r.Post("/disputes/{id}/resolve", a.ResolveDisputeHandler())
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(a.limiter.Middleware(ratelimit.Admin))
+		r.Get("/admin/blocks", a.BlocksHandler())
+	})
 }
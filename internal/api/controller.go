@@ -2,12 +2,10 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 
-	"github.com/cko-recruitment/payment-gateway-challenge-go/docs"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/changelog"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/handlers"
-	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 type pong struct {
@@ -25,11 +23,26 @@ func (a *Api) PingHandler() http.HandlerFunc {
 	}
 }
 
-// SwaggerHandler returns an http.HandlerFunc that handles HTTP Swagger related requests.
-func (a *Api) SwaggerHandler() http.HandlerFunc {
-	return httpSwagger.Handler(
-		httpSwagger.URL(fmt.Sprintf("http://%s/swagger/doc.json", docs.SwaggerInfo.Host)),
-	)
+// ChangelogHandler returns an http.HandlerFunc that lists the changes made to the public API.
+func (a *Api) ChangelogHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(changelog.Entries()); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
+
+// BlocksHandler returns an http.HandlerFunc that lists the clients currently blocked for suspicious activity.
+func (a *Api) BlocksHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(a.guard.Blocks()); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
 }
 
 // GetPaymentHandler returns an http.HandlerFunc that handles Payments GET requests.
@@ -39,8 +52,176 @@ func (a *Api) GetPaymentHandler() http.HandlerFunc {
 	return h.GetHandler()
 }
 
+// ListPaymentsHandler returns an http.HandlerFunc that lists payments, narrowed by the request's query parameters.
+func (a *Api) ListPaymentsHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.ListHandler()
+}
+
 func (a *Api) PostPaymentHandler() http.HandlerFunc {
 	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
 
 	return h.PostHandler()
 }
+
+// CapturePaymentHandler returns an http.HandlerFunc that handles Payment capture POST requests.
+func (a *Api) CapturePaymentHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.CaptureHandler()
+}
+
+// VoidPaymentHandler returns an http.HandlerFunc that handles Payment void POST requests.
+func (a *Api) VoidPaymentHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.VoidHandler()
+}
+
+// RefundPaymentHandler returns an http.HandlerFunc that handles Payment refund POST requests.
+func (a *Api) RefundPaymentHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.RefundHandler()
+}
+
+// GetRefundsHandler returns an http.HandlerFunc that handles Payment refunds GET requests.
+func (a *Api) GetRefundsHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.RefundsHandler()
+}
+
+// StoreCardHandler returns an http.HandlerFunc that handles customer card POST requests.
+func (a *Api) StoreCardHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.StoreCardHandler()
+}
+
+// CreatePaymentIntentHandler returns an http.HandlerFunc that handles Payment intent POST requests.
+func (a *Api) CreatePaymentIntentHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.CreateIntentHandler()
+}
+
+// GetPaymentIntentHandler returns an http.HandlerFunc that handles Payment intent GET requests.
+func (a *Api) GetPaymentIntentHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.GetIntentHandler()
+}
+
+// ConfirmPaymentIntentHandler returns an http.HandlerFunc that handles Payment intent confirm POST requests.
+func (a *Api) ConfirmPaymentIntentHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.ConfirmIntentHandler()
+}
+
+// CreatePaymentLinkHandler returns an http.HandlerFunc that handles Payment link POST requests.
+func (a *Api) CreatePaymentLinkHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.CreateLinkHandler()
+}
+
+// GetPaymentLinkHandler returns an http.HandlerFunc that handles Payment link GET requests.
+func (a *Api) GetPaymentLinkHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.GetLinkHandler()
+}
+
+// PayPaymentLinkHandler returns an http.HandlerFunc that handles Payment link pay POST requests.
+func (a *Api) PayPaymentLinkHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.PayLinkHandler()
+}
+
+// CreateInstallmentPlanHandler returns an http.HandlerFunc that handles Installment plan POST requests.
+func (a *Api) CreateInstallmentPlanHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.CreatePlanHandler()
+}
+
+// GetInstallmentPlanHandler returns an http.HandlerFunc that handles Installment plan GET requests.
+func (a *Api) GetInstallmentPlanHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.GetPlanHandler()
+}
+
+// CancelInstallmentPlanHandler returns an http.HandlerFunc that handles Installment plan cancel POST requests.
+func (a *Api) CancelInstallmentPlanHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.CancelPlanHandler()
+}
+
+// CardVerificationHandler returns an http.HandlerFunc that handles Card verification POST requests.
+func (a *Api) CardVerificationHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.CardVerificationHandler()
+}
+
+// OpenDisputeHandler returns an http.HandlerFunc that handles Dispute POST requests.
+func (a *Api) OpenDisputeHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.OpenDisputeHandler()
+}
+
+// DisputeEvidenceHandler returns an http.HandlerFunc that handles Dispute evidence POST requests.
+func (a *Api) DisputeEvidenceHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.DisputeEvidenceHandler()
+}
+
+// ResolveDisputeHandler returns an http.HandlerFunc that handles Dispute resolve POST requests.
+func (a *Api) ResolveDisputeHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.ResolveDisputeHandler()
+}
+
+// GetDisputesHandler returns an http.HandlerFunc that handles Payment disputes GET requests.
+func (a *Api) GetDisputesHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.DisputesHandler()
+}
+
+// DisputeEvidenceBundleHandler returns an http.HandlerFunc that handles Dispute evidence bundle GET requests.
+func (a *Api) DisputeEvidenceBundleHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.EvidenceBundleHandler()
+}
+
+// CancelPaymentHandler returns an http.HandlerFunc that handles Payment DELETE requests.
+func (a *Api) CancelPaymentHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.CancelHandler()
+}
+
+// ReversePaymentHandler returns an http.HandlerFunc that handles Payment reversal POST requests.
+func (a *Api) ReversePaymentHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.ReverseHandler()
+}
+
+// RetryPaymentHandler returns an http.HandlerFunc that handles Payment retry POST requests.
+func (a *Api) RetryPaymentHandler() http.HandlerFunc {
+	h := handlers.NewPaymentsHandler(a.paymentsRepo, a.domain)
+
+	return h.RetryHandler()
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/abuse"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/changelog"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/openapi"
+	httpSwagger "github.com/swaggo/http-swagger"
+)
+
+// openAPIPath is where the OpenAPI document is served, the Swagger UI at /docs reads it from there.
+const openAPIPath = "/api/openapi.json"
+
+var openAPIInfo = openapi.Info{
+	Title:       "Payment Gateway",
+	Description: "Takes card payments on behalf of merchants and sends them to the acquiring bank.  The unversioned /api paths are aliases of /api/v1.",
+	Version:     "v1",
+}
+
+// errors most operations on an existing resource can answer with
+var (
+	lookupErrors    = []int{http.StatusNotFound, http.StatusTooManyRequests, http.StatusGatewayTimeout}
+	operationErrors = []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+)
+
+// v1Operations describes every v1 route for the OpenAPI document, a route added to v1Routes needs an entry here too.
+var v1Operations = []openapi.Operation{
+	{Method: "GET", Path: "/ping", Tag: "health", Summary: "Check the gateway is up", Response: pong{}},
+	{Method: "GET", Path: "/api/v1/changelog", Tag: "changelog", Summary: "List the changes made to the public API", Response: []changelog.Entry{}},
+	{
+		Method: "GET", Path: "/api/v1/payments", Tag: "payments", Summary: "List payments, oldest first",
+		Query: []openapi.Parameter{
+			{Name: "status", Description: "only payments with this status"},
+			{Name: "currency", Description: "only payments in this currency"},
+			{Name: "last_four", Description: "only payments made with a card or token ending in these four digits"},
+			{Name: "min_amount", Description: "only payments of at least this amount, in minor units", Type: "integer"},
+			{Name: "max_amount", Description: "only payments of at most this amount, in minor units", Type: "integer"},
+			{Name: "created_after", Description: "only payments created at or after this time", Format: "date-time"},
+			{Name: "created_before", Description: "only payments created at or before this time", Format: "date-time"},
+		},
+		Response: []models.GetPaymentHandlerResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusGatewayTimeout},
+	},
+	{
+		Method: "GET", Path: "/api/v1/payments/{id}", Tag: "payments", Summary: "Get a payment",
+		Query:    []openapi.Parameter{{Name: "as_of", Description: "return the payment as it was at this time", Format: "date-time"}},
+		Response: models.GetPaymentHandlerResponse{},
+		Errors:   append([]int{http.StatusBadRequest}, lookupErrors...),
+	},
+	{Method: "GET", Path: "/api/v1/payments/{id}/refunds", Tag: "payments", Summary: "List a payment's refunds, oldest first", Response: []models.Refund{}, Errors: lookupErrors},
+	{Method: "GET", Path: "/api/v1/payments/{id}/disputes", Tag: "disputes", Summary: "List the disputes raised against a payment", Response: []models.Dispute{}, Errors: lookupErrors},
+	{Method: "GET", Path: "/api/v1/disputes/{id}/evidence-bundle", Tag: "disputes", Summary: "Download the evidence the gateway holds for a dispute", Response: models.EvidenceBundle{}, Errors: lookupErrors},
+	{Method: "GET", Path: "/api/v1/payment-intents/{id}", Tag: "payment intents", Summary: "Get a payment intent", Response: models.PaymentIntent{}, Errors: lookupErrors},
+	{Method: "GET", Path: "/api/v1/payment-links/{id}", Tag: "payment links", Summary: "Get a payment link", Response: models.PaymentLink{}, Errors: lookupErrors},
+	{Method: "GET", Path: "/api/v1/installment-plans/{id}", Tag: "installment plans", Summary: "Get an installment plan and the status of its installments", Response: models.InstallmentPlan{}, Errors: lookupErrors},
+	{
+		Method: "POST", Path: "/api/v1/payments", Tag: "payments", Summary: "Make a payment",
+		Description: "Answers 202 with a Location header instead when the payment is sent with Prefer: respond-async or scheduled with execute_at.",
+		Request:     models.PostPaymentHandlerRequest{},
+		Response:    models.PostPaymentResponse{},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusConflict, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		ErrorBodies: map[int]any{http.StatusBadRequest: models.PostPayment400Response{}},
+	},
+	{Method: "POST", Path: "/api/v1/payments/{id}/capture", Tag: "payments", Summary: "Capture an authorized payment, all of it unless an amount is given", Request: models.CaptureRequest{}, Response: models.PostPaymentResponse{}, Errors: operationErrors},
+	{Method: "POST", Path: "/api/v1/payments/{id}/void", Tag: "payments", Summary: "Void an authorized payment", Response: models.PostPaymentResponse{}, Errors: operationErrors},
+	{Method: "POST", Path: "/api/v1/payments/{id}/refund", Tag: "payments", Summary: "Refund a captured payment, whatever is left unless an amount is given", Request: models.RefundRequest{}, Response: models.PostPaymentResponse{}, Errors: operationErrors},
+	{Method: "POST", Path: "/api/v1/payments/{id}/retry", Tag: "payments", Summary: "Retry a declined payment, sending the CVV again unless it was made with a stored card", Request: models.RetryRequest{}, Response: models.PostPaymentResponse{}, Errors: operationErrors},
+	{Method: "POST", Path: "/api/v1/payments/{id}/reverse", Tag: "payments", Summary: "Reverse a captured payment", Response: models.PostPaymentResponse{}, Errors: operationErrors},
+	{Method: "DELETE", Path: "/api/v1/payments/{id}", Tag: "payments", Summary: "Cancel a scheduled payment", Response: models.PostPaymentResponse{}, Errors: operationErrors},
+	{Method: "POST", Path: "/api/v1/customers/{id}/cards", Tag: "cards", Summary: "Keep a card on file for a customer", Request: models.StoreCardRequest{}, Status: http.StatusCreated, Response: models.StoredCard{}, Errors: []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusGatewayTimeout}},
+	{Method: "POST", Path: "/api/v1/payment-intents", Tag: "payment intents", Summary: "Create a payment intent", Request: models.CreatePaymentIntentRequest{}, Status: http.StatusCreated, Response: models.PaymentIntent{}, Errors: operationErrors},
+	{Method: "POST", Path: "/api/v1/payment-intents/{id}/confirm", Tag: "payment intents", Summary: "Confirm a payment intent with a card, making the payment", Request: models.ConfirmPaymentIntentRequest{}, Response: models.PaymentIntent{}, Errors: operationErrors},
+	{Method: "POST", Path: "/api/v1/payment-links", Tag: "payment links", Summary: "Create a payment link", Request: models.CreatePaymentLinkRequest{}, Status: http.StatusCreated, Response: models.PaymentLink{}, Errors: operationErrors},
+	{
+		Method: "POST", Path: "/api/v1/payment-links/{id}/pay", Tag: "payment links", Summary: "Pay a payment link",
+		Description: "A client that looks automated must first pass a challenge, sending its answer in the X-Challenge-Response header.",
+		Request:     models.PayPaymentLinkRequest{},
+		Response:    models.PostPaymentResponse{},
+		Errors:      append([]int{http.StatusForbidden}, operationErrors...),
+	},
+	{Method: "POST", Path: "/api/v1/installment-plans", Tag: "installment plans", Summary: "Take the first installment and schedule the rest", Request: models.CreateInstallmentPlanRequest{}, Status: http.StatusCreated, Response: models.InstallmentPlan{}, Errors: operationErrors},
+	{Method: "POST", Path: "/api/v1/installment-plans/{id}/cancel", Tag: "installment plans", Summary: "Cancel the installments still to be taken", Response: models.InstallmentPlan{}, Errors: operationErrors},
+	{Method: "POST", Path: "/api/v1/card-verifications", Tag: "cards", Summary: "Check a card is valid without taking money", Request: models.CardVerificationRequest{}, Response: models.CardVerification{}, Errors: operationErrors},
+	{Method: "POST", Path: "/api/v1/disputes", Tag: "disputes", Summary: "Record a dispute raised by the cardholder's issuer", Request: models.OpenDisputeRequest{}, Status: http.StatusCreated, Response: models.Dispute{}, Errors: operationErrors},
+	{Method: "POST", Path: "/api/v1/disputes/{id}/evidence", Tag: "disputes", Summary: "Submit evidence against a dispute", Request: models.DisputeEvidenceRequest{}, Response: models.Dispute{}, Errors: operationErrors},
+	{Method: "POST", Path: "/api/v1/disputes/{id}/resolve", Tag: "disputes", Summary: "Record the issuer's decision on a dispute", Request: models.ResolveDisputeRequest{}, Response: models.Dispute{}, Errors: operationErrors},
+	{Method: "GET", Path: "/api/v1/admin/blocks", Tag: "admin", Summary: "List the clients blocked for suspicious activity", Response: []abuse.Block{}, Errors: []int{http.StatusTooManyRequests}},
+}
+
+// OpenAPIHandler returns an http.HandlerFunc that serves the OpenAPI document describing the API.
+func (a *Api) OpenAPIHandler() http.HandlerFunc {
+	document := openapi.Document(openAPIInfo, v1Operations)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(document); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
+
+// DocsHandler returns an http.HandlerFunc that serves the Swagger UI for the OpenAPI document.
+func (a *Api) DocsHandler() http.HandlerFunc {
+	return httpSwagger.Handler(httpSwagger.URL(openAPIPath))
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPI_DocumentsEveryRoute(t *testing.T) {
+	a := New(client.NewFakeClient())
+
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, httptest.NewRequest("GET", openAPIPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var document struct {
+		OpenAPI string                               `json:"openapi"`
+		Paths   map[string]map[string]map[string]any `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &document))
+	assert.Equal(t, "3.0.3", document.OpenAPI)
+
+	documented := 0
+	err := chi.Walk(a.router, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		// the unversioned aliases, the document itself and the UI are not part of the API
+		if !strings.HasPrefix(route, "/api/v1/") && route != "/ping" {
+			return nil
+		}
+		route = strings.TrimSuffix(route, "/")
+		assert.Contains(t, document.Paths[route], strings.ToLower(method), "%s %s is not in the OpenAPI document", method, route)
+		documented++
+		return nil
+	})
+	require.NoError(t, err)
+
+	operations := 0
+	for _, item := range document.Paths {
+		operations += len(item)
+	}
+	assert.Equal(t, documented, operations, "the OpenAPI document describes routes that do not exist")
+}
+
+func TestOpenAPI_SchemasFromModels(t *testing.T) {
+	a := New(client.NewFakeClient())
+
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, httptest.NewRequest("GET", openAPIPath, nil))
+
+	var document struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &document))
+
+	request := document.Components.Schemas["PostPaymentHandlerRequest"].Properties
+	assert.Equal(t, "integer", request["card_number"]["type"])
+	assert.Equal(t, "#/components/schemas/PaymentMethod", request["payment_method"]["$ref"])
+	assert.Equal(t, "date-time", request["execute_at"]["format"])
+
+	// fields that never leave the gateway are not documented
+	assert.NotContains(t, document.Components.Schemas["StoredCard"].Properties, "CardNumber")
+	assert.NotContains(t, document.Components.Schemas["StoredCard"].Properties, "card_number")
+}
+
+func TestDocs_ServesSwaggerUI(t *testing.T) {
+	a := New(client.NewFakeClient())
+
+	for _, path := range []string{"/docs", "/swagger/index.html"} {
+		w := httptest.NewRecorder()
+		a.router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "/docs/index.html", w.Header().Get("Location"))
+	}
+
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, httptest.NewRequest("GET", "/docs/index.html", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "swagger-ui")
+	// the page points the UI at the document, with its slashes escaped for JavaScript
+	assert.Contains(t, w.Body.String(), strings.ReplaceAll(openAPIPath, "/", `\/`))
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutes_Versions(t *testing.T) {
+	a := New(client.NewFakeClient())
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		a.router.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	created := serve("POST", "/api/v1/payments", `{"card_number": 2222405343248877, "expiry_month": 4, "expiry_year": 2035, "currency": "GBP", "amount": 100, "cvv": 123}`)
+	require.Equal(t, http.StatusOK, created.Code)
+	var payment struct {
+		Id string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(created.Body.Bytes(), &payment))
+	id := payment.Id
+
+	// the unversioned paths are v1
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/v1/payments/"+id, "").Code)
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/payments/"+id, "").Code)
+	assert.Equal(t, serve("GET", "/api/v1/payments/"+id, "").Body.String(), serve("GET", "/api/payments/"+id, "").Body.String())
+
+	assert.Equal(t, http.StatusNotFound, serve("GET", "/api/v2/payments/"+id, "").Code)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/net/netutil"
+	"golang.org/x/sync/errgroup"
+)
+
+/*
+ServerConfig hardens the HTTP server in front of the gateway.  The net/http defaults have no header timeout and allow 1MB of headers, which lets a slow or malicious client hold connections open indefinitely, so every limit here has a safe default in DefaultServerConfig.
+
+H2C serves HTTP/2 over cleartext alongside HTTP/1.1, for deployments where TLS terminates at a load balancer that speaks HTTP/2 to the gateway.  It is off by default as it carries card data unencrypted between the load balancer and the gateway.  h2c connections are hijacked from the HTTP/1.1 server, so Shutdown does not drain them: streams still open when the server shuts down are cut off rather than waited for.
+*/
+
+type ServerConfig struct {
+	// MaxHeaderBytes caps the size of request headers, larger requests get a 431.
+	MaxHeaderBytes int
+	// ReadHeaderTimeout is how long a client has to send the request headers.
+	ReadHeaderTimeout time.Duration
+	// IdleTimeout is how long a keep-alive connection may sit unused before it is closed.
+	IdleTimeout time.Duration
+	// MaxConnections caps concurrently open connections, further connections wait to be accepted.  Zero means no limit.
+	MaxConnections int
+	// H2C enables HTTP/2 over cleartext.
+	H2C bool
+}
+
+var DefaultServerConfig = ServerConfig{
+	MaxHeaderBytes:    16 << 10,
+	ReadHeaderTimeout: 5 * time.Second,
+	IdleTimeout:       2 * time.Minute,
+	MaxConnections:    1000,
+	H2C:               false,
+}
+
+// h2MinReadFrameSize is the smallest frame size HTTP/2 allows a server to advertise.
+const h2MinReadFrameSize = 16 << 10
+
+// h2DefaultHeaderTableSize is the HPACK dynamic table size HTTP/2 starts with.
+const h2DefaultHeaderTableSize = 4 << 10
+
+// Run listens on addr and serves the gateway until ctx is cancelled.
+func (a *Api) Run(ctx context.Context, addr string, config ServerConfig) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return a.Serve(ctx, listener, config)
+}
+
+// Serve serves the gateway on listener until ctx is cancelled, the listener is closed on return.
+func (a *Api) Serve(ctx context.Context, listener net.Listener, config ServerConfig) error {
+	if config.MaxConnections > 0 {
+		listener = netutil.LimitListener(listener, config.MaxConnections)
+	}
+
+	var handler http.Handler = a.router
+	if config.H2C {
+		handler = h2c.NewHandler(handler, &http2.Server{
+			IdleTimeout: config.IdleTimeout,
+			// the header table never grows past the header limit, and frames stay at the smallest size allowed
+			MaxDecoderHeaderTableSize: uint32(min(config.MaxHeaderBytes, h2DefaultHeaderTableSize)),
+			MaxReadFrameSize:          h2MinReadFrameSize,
+		})
+	}
+
+	httpServer := &http.Server{
+		Handler:           handler,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		BaseContext:       func(_ net.Listener) context.Context { return ctx },
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		<-ctx.Done()
+		fmt.Printf("shutting down HTTP server\n")
+		return httpServer.Shutdown(ctx)
+	})
+
+	g.Go(func() error {
+		fmt.Printf("starting HTTP server on %s\n", listener.Addr())
+		err := httpServer.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			return err
+		}
+
+		return nil
+	})
+
+	return g.Wait()
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+func serveTest(t *testing.T, config ServerConfig) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- New(client.NewFakeClient()).Serve(ctx, listener, config)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return "http://" + listener.Addr().String()
+}
+
+func h2cClient() *http.Client {
+	return &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		},
+	}
+}
+
+func TestServe_H2C(t *testing.T) {
+	config := DefaultServerConfig
+	config.H2C = true
+	url := serveTest(t, config)
+
+	resp, err := h2cClient().Get(url + "/ping")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, resp.ProtoMajor)
+}
+
+func TestServe_H2COffByDefault(t *testing.T) {
+	url := serveTest(t, DefaultServerConfig)
+
+	_, err := h2cClient().Get(url + "/ping")
+	assert.Error(t, err)
+}
+
+func TestServe_HTTP1StillServed(t *testing.T) {
+	url := serveTest(t, DefaultServerConfig)
+
+	resp, err := http.Get(url + "/ping")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, resp.ProtoMajor)
+}
+
+func TestServe_RejectsOversizedHeaders(t *testing.T) {
+	config := DefaultServerConfig
+	config.MaxHeaderBytes = 1 << 10
+	url := serveTest(t, config)
+
+	req, err := http.NewRequest(http.MethodGet, url+"/ping", nil)
+	require.NoError(t, err)
+	// net/http allows 4KB of slack on top of MaxHeaderBytes
+	req.Header.Set("X-Padding", strings.Repeat("a", 8<<10))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/handlers"
+)
+
+// requestTimeout is the global deadline applied to every request, handlers can set a shorter budget for their own route.
+const requestTimeout = 30 * time.Second
+
+// timeoutMiddleware puts a deadline on the request context so no downstream call can wait forever.  If the budget runs
+// out and the handler gave up without writing a response, the client gets a 504 rather than an empty 200.
+func timeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &trackingWriter{ResponseWriter: w}
+			next.ServeHTTP(tw, r.WithContext(ctx))
+
+			if !tw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				log.Printf("request %s %s exceeded its %s budget", r.Method, r.URL.Path, timeout)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusGatewayTimeout)
+				if err := json.NewEncoder(w).Encode(handlers.HandlerErrorResponse{Message: handlers.TimeoutMessage}); err != nil {
+					log.Printf("Failed to encode error response: %v", err)
+				}
+			}
+		})
+	}
+}
+
+type trackingWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (tw *trackingWriter) WriteHeader(statusCode int) {
+	tw.wroteHeader = true
+	tw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (tw *trackingWriter) Write(b []byte) (int, error) {
+	tw.wroteHeader = true
+	return tw.ResponseWriter.Write(b)
+}
@@ -0,0 +1,343 @@
+package changelog
+
+/*
+The changelog is maintained by hand next to the code so that it ships with the binary that actually serves the endpoints.  When adding, changing or deprecating anything a merchant can see, add an entry to the end of the list.
+*/
+
+type ChangeType string
+
+const (
+	Added      ChangeType = "added"
+	Changed    ChangeType = "changed"
+	Deprecated ChangeType = "deprecated"
+	Removed    ChangeType = "removed"
+)
+
+type Entry struct {
+	EffectiveDate string     `json:"effective_date"`
+	Type          ChangeType `json:"type"`
+	Endpoint      string     `json:"endpoint"`
+	Description   string     `json:"description"`
+}
+
+var entries = []Entry{
+	{
+		EffectiveDate: "2025-01-06",
+		Type:          Added,
+		Endpoint:      "POST /api/payments",
+		Description:   "Process a card payment through the acquiring bank.",
+	},
+	{
+		EffectiveDate: "2025-01-06",
+		Type:          Added,
+		Endpoint:      "GET /api/payments/{id}",
+		Description:   "Retrieve a previously processed payment.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "GET /api/changelog",
+		Description:   "Machine-readable list of API changes.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments",
+		Description:   "An amount of 0 runs an account verification, a successful one has status verified.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "GET /api/payments/{id}",
+		Description:   "Authorized payments include expires_at, when the authorization lapses under the card scheme's rules.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments",
+		Description:   "Optional stored_credential (initiator, reason, original_transaction_id) marks merchant-initiated and customer-initiated payments on stored cards.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "GET /api/payments/{id}",
+		Description:   "Returns the stored_credential the payment was made with.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/payments/{id}/capture",
+		Description:   "Capture the funds held by an authorized payment.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments",
+		Description:   "Authorized payments include the acquiring bank's authorization_code.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/payments/{id}/void",
+		Description:   "Cancel an authorization before it is captured.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/payments/{id}/refund",
+		Description:   "Refund a captured payment in full.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "GET /api/payments/{id}",
+		Description:   "Refunded payments list their refunds.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments/{id}/refund",
+		Description:   "Optional amount for partial refunds, payments can be refunded several times until the captured amount is used up.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "GET /api/payments/{id}/refunds",
+		Description:   "List the refunds made on a payment.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments",
+		Description:   "Accepts capture to authorize and capture in one step, payments report their capture_method",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "GET /api/payments/{id}",
+		Description:   "Authorizations that are never captured are voided once they expire and reported with status expired",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "GET /api/payments/{id}?as_of",
+		Description:   "Returns the payment as it was at the given RFC 3339 timestamp",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/customers/{id}/cards",
+		Description:   "Stores a card for a customer and returns a token",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments",
+		Description:   "Accepts card_token with stored_credential to charge a stored card",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/payment-intents",
+		Description:   "Creates a payment intent to be confirmed with card details later",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "GET /api/payment-intents/{id}",
+		Description:   "Returns a payment intent",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/payment-intents/{id}/confirm",
+		Description:   "Pays a payment intent with the card in the body",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments",
+		Description:   "Accepts billing_address for address verification and returns avs_result",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/card-verifications",
+		Description:   "Checks a card with a zero amount authorization and returns its validity and scheme",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments/{id}/capture",
+		Description:   "Accepts an amount so a payment can be captured in several parts",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/disputes",
+		Description:   "Opens a dispute against a captured payment",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/disputes/{id}/evidence",
+		Description:   "Submits the merchant's evidence for an open dispute",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/disputes/{id}/resolve",
+		Description:   "Records the issuer's decision on a dispute",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "GET /api/payments/{id}/disputes",
+		Description:   "Lists the disputes raised against a payment",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/payments/{id}/retry",
+		Description:   "Sends a declined payment to the bank again as a new linked attempt",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments, GET /api/payments/{id}",
+		Description:   "Declined payments carry a decline_reason with the issuer's response code and message",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/payments/{id}/reverse",
+		Description:   "Reverses a payment captured today, or refunds it once it has been settled",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/payments",
+		Description:   "Prefer: respond-async answers 202 with a processing payment and sends it to the bank in the background",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/payment-links, GET /api/payment-links/{id}, POST /api/payment-links/{id}/pay",
+		Description:   "Shareable payment links for a fixed amount that make a payment when paid",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/payments, DELETE /api/payments/{id}",
+		Description:   "execute_at schedules a payment for later, DELETE cancels it before it is sent to the bank",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/installment-plans",
+		Description:   "Takes an amount from a card in 2 to 12 weekly or monthly installments, the first straight away and the rest as scheduled payments.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "GET /api/installment-plans/{id}",
+		Description:   "Returns an installment plan and the status of each installment.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "POST /api/installment-plans/{id}/cancel",
+		Description:   "Cancels the installments of a plan that are still scheduled.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "GET /api/disputes/{id}/evidence-bundle",
+		Description:   "Downloads the evidence the gateway holds for a dispute, including earlier undisputed payments on the same card.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments",
+		Description:   "Accepts a network token and cryptogram in payment_method instead of a card number, payments made that way show payment_method network_token.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments",
+		Description:   "Accepts Apple Pay and Google Pay payment tokens in payment_method, unwrapped into a network token before the bank is called.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments",
+		Description:   "payment_method accepts type card with the card details nested under card, as an alternative to the top level card fields.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "GET /api/admin/blocks",
+		Description:   "Lists the clients blocked for payment id enumeration or card testing.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments",
+		Description:   "Clients trying small payments with many different cards, or looking up many missing payments, are blocked for an hour with a 429.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payment-links/{id}/pay",
+		Description:   "Clients that look automated must pass a challenge, answered in the X-Challenge-Response header, before paying once a challenge provider is set.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "GET /api/payments",
+		Description:   "Lists payments, filtered by status, currency, min_amount, max_amount, created_after and created_before.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "GET /api/payments",
+		Description:   "last_four finds the payments made with a card from its last four digits.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/payments",
+		Description:   "An Idempotency-Key header makes any POST or DELETE safe to repeat, a repeat gets the original response instead of being processed again.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "/api/v1",
+		Description:   "Every endpoint is served under /api/v1, the unversioned /api paths remain as aliases of v1. Location headers now name the /api/v1 path.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Added,
+		Endpoint:      "GET /api/openapi.json",
+		Description:   "An OpenAPI 3 document describing every v1 endpoint, with a Swagger UI for it at /docs.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/v1/payments",
+		Description:   "A failure at the acquiring bank answers 502 with a message, as it does on the other writes, instead of an empty 500.",
+	},
+	{
+		EffectiveDate: "2026-10-16",
+		Type:          Changed,
+		Endpoint:      "POST /api/v1/payments/{id}/retry",
+		Description:   "The CVV is no longer held after a decline, retrying a payment made with card details needs it again in the body. Network token and wallet payments cannot be retried.",
+	},
+}
+
+// Entries returns a copy of the changelog, oldest entry first.
+func Entries() []Entry {
+	out := make([]Entry, len(entries))
+	copy(out, entries)
+	return out
+}
@@ -0,0 +1,34 @@
+package changelog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/changelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntries_AreValidAndOrdered(t *testing.T) {
+	entries := changelog.Entries()
+	require.NotEmpty(t, entries)
+
+	var previous time.Time
+	for _, entry := range entries {
+		date, err := time.Parse(time.DateOnly, entry.EffectiveDate)
+		require.NoError(t, err, entry.Endpoint)
+		assert.False(t, date.Before(previous), "%s is out of order", entry.Endpoint)
+		previous = date
+
+		assert.Contains(t, []changelog.ChangeType{changelog.Added, changelog.Changed, changelog.Deprecated, changelog.Removed}, entry.Type)
+		assert.NotEmpty(t, entry.Endpoint)
+		assert.NotEmpty(t, entry.Description)
+	}
+}
+
+func TestEntries_ReturnsCopy(t *testing.T) {
+	entries := changelog.Entries()
+	entries[0].Endpoint = "changed"
+
+	assert.NotEqual(t, "changed", changelog.Entries()[0].Endpoint)
+}
@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+/*
+AdaptiveLimitClient caps the number of concurrent calls to the acquiring bank using AIMD (additive increase, multiplicative decrease).  Every call that comes back healthy and under the latency threshold grows the limit by roughly one per limit's worth of calls, every slow call or call that shows the acquirer struggling (a timeout, a transport error or a 5xx) shrinks it by backoffRatio.  A call the caller cancelled, or one the bank rejected because of the request itself (a 4xx), says nothing about the acquirer's health and leaves the limit where it is.
+
+When the bank struggles the limit drops quickly and excess payments are turned away as a 503 straight away, instead of piling more load (and client retries) onto the acquirer.  Rejections are counted rather than logged one by one, with a summary logged at most once every rejectionLogInterval.
+*/
+
+const (
+	backoffRatio         = 0.9
+	rejectionLogInterval = 10 * time.Second
+)
+
+type AdaptiveLimitClient struct {
+	next             Client
+	latencyThreshold time.Duration
+	minLimit         float64
+	maxLimit         float64
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+
+	rejected         int64
+	unloggedRejected int64
+	lastRejectionLog time.Time
+}
+
+func NewAdaptiveLimitClient(next Client, initialLimit, minLimit, maxLimit int, latencyThreshold time.Duration) *AdaptiveLimitClient {
+	return &AdaptiveLimitClient{
+		next:             next,
+		latencyThreshold: latencyThreshold,
+		minLimit:         float64(minLimit),
+		maxLimit:         float64(maxLimit),
+		limit:            float64(initialLimit),
+	}
+}
+
+func (c *AdaptiveLimitClient) PostBankPayment(ctx context.Context, request *models.PostPaymentBankRequest) (*models.PostPaymentBankResponse, error) {
+	var response *models.PostPaymentBankResponse
+	err := c.call(func() (err error) {
+		response, err = c.next.PostBankPayment(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (c *AdaptiveLimitClient) PostBankTokenPayment(ctx context.Context, request *models.PostTokenBankRequest) (*models.PostPaymentBankResponse, error) {
+	var response *models.PostPaymentBankResponse
+	err := c.call(func() (err error) {
+		response, err = c.next.PostBankTokenPayment(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (c *AdaptiveLimitClient) CaptureBankPayment(ctx context.Context, request *models.CaptureBankRequest) (*models.CaptureBankResponse, error) {
+	var response *models.CaptureBankResponse
+	err := c.call(func() (err error) {
+		response, err = c.next.CaptureBankPayment(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (c *AdaptiveLimitClient) VoidBankPayment(ctx context.Context, request *models.VoidBankRequest) (*models.VoidBankResponse, error) {
+	var response *models.VoidBankResponse
+	err := c.call(func() (err error) {
+		response, err = c.next.VoidBankPayment(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (c *AdaptiveLimitClient) RefundBankPayment(ctx context.Context, request *models.RefundBankRequest) (*models.RefundBankResponse, error) {
+	var response *models.RefundBankResponse
+	err := c.call(func() (err error) {
+		response, err = c.next.RefundBankPayment(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (c *AdaptiveLimitClient) ReverseBankPayment(ctx context.Context, request *models.ReverseBankRequest) (*models.ReverseBankResponse, error) {
+	var response *models.ReverseBankResponse
+	err := c.call(func() (err error) {
+		response, err = c.next.ReverseBankPayment(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+// call runs bankCall if there is room under the limit and feeds its outcome back into the limit.
+func (c *AdaptiveLimitClient) call(bankCall func() error) error {
+	if !c.acquire() {
+		return gatewayerrors.NewBankError(
+			errors.New("acquiring bank concurrency limit reached"),
+			http.StatusServiceUnavailable,
+		)
+	}
+
+	start := time.Now()
+	err := bankCall()
+	c.release(time.Since(start), err)
+
+	return err
+}
+
+// Limit returns the current concurrency limit.
+func (c *AdaptiveLimitClient) Limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return int(c.limit)
+}
+
+// Rejected returns the total number of payments turned away because the limit was reached.
+func (c *AdaptiveLimitClient) Rejected() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.rejected
+}
+
+// InFlight returns the number of bank calls currently in progress.
+func (c *AdaptiveLimitClient) InFlight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.inFlight
+}
+
+func (c *AdaptiveLimitClient) acquire() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.inFlight >= int(c.limit) {
+		c.rejected++
+		c.unloggedRejected++
+		if now := time.Now(); now.Sub(c.lastRejectionLog) >= rejectionLogInterval {
+			log.Printf("bank concurrency limit %d reached, rejected %d payments since last report", int(c.limit), c.unloggedRejected)
+			c.unloggedRejected = 0
+			c.lastRejectionLog = now
+		}
+		return false
+	}
+	c.inFlight++
+
+	return true
+}
+
+func (c *AdaptiveLimitClient) release(latency time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.inFlight--
+
+	if err != nil && !acquirerStruggling(err) {
+		return
+	}
+
+	if err != nil || latency > c.latencyThreshold {
+		previous := int(c.limit)
+		c.limit = max(c.minLimit, c.limit*backoffRatio)
+		if int(c.limit) != previous {
+			log.Printf("bank concurrency limit decreased from %d to %d (latency %s, err %v)", previous, int(c.limit), latency, err)
+		}
+		return
+	}
+
+	c.limit = min(c.maxLimit, c.limit+1/c.limit)
+}
+
+// acquirerStruggling reports whether err points at the acquiring bank being slow or unhealthy, as opposed to the caller giving up or the request itself being bad.
+func acquirerStruggling(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var bankErr *gatewayerrors.BankError
+	if errors.As(err, &bankErr) {
+		return bankErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
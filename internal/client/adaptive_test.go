@@ -0,0 +1,125 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAdaptiveLimitClient_GrowsWhenHealthy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return(&models.PostPaymentBankResponse{Authorised: true}, nil).Times(10)
+
+	limited := client.NewAdaptiveLimitClient(mockClient, 2, 1, 3, time.Second)
+
+	for i := 0; i < 10; i++ {
+		_, err := limited.PostBankPayment(context.Background(), &models.PostPaymentBankRequest{})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 3, limited.Limit())
+	assert.Equal(t, 0, limited.InFlight())
+}
+
+func TestAdaptiveLimitClient_ShrinksOnErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return(nil, gatewayerrors.NewBankError(errors.New("boom"), http.StatusBadGateway)).Times(10)
+
+	limited := client.NewAdaptiveLimitClient(mockClient, 10, 2, 20, time.Second)
+
+	for i := 0; i < 10; i++ {
+		_, err := limited.PostBankPayment(context.Background(), &models.PostPaymentBankRequest{})
+		require.Error(t, err)
+	}
+
+	assert.Equal(t, 3, limited.Limit())
+}
+
+func TestAdaptiveLimitClient_ShrinksOnTimeouts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return(nil, context.DeadlineExceeded)
+
+	limited := client.NewAdaptiveLimitClient(mockClient, 10, 2, 20, time.Second)
+
+	_, err := limited.PostBankPayment(context.Background(), &models.PostPaymentBankRequest{})
+	require.Error(t, err)
+
+	assert.Equal(t, 9, limited.Limit())
+}
+
+func TestAdaptiveLimitClient_KeepsLimitOnCallerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "cancelled", err: context.Canceled},
+		{name: "bad request", err: gatewayerrors.NewBankError(errors.New("received non-200 response: 400"), http.StatusBadRequest)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockClient := mocks.NewMockClient(ctrl)
+
+			mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return(nil, tt.err).Times(10)
+
+			limited := client.NewAdaptiveLimitClient(mockClient, 10, 2, 20, time.Second)
+
+			for i := 0; i < 10; i++ {
+				_, err := limited.PostBankPayment(context.Background(), &models.PostPaymentBankRequest{})
+				require.ErrorIs(t, err, tt.err)
+			}
+
+			assert.Equal(t, 10, limited.Limit())
+			assert.Equal(t, 0, limited.InFlight())
+		})
+	}
+}
+
+func TestAdaptiveLimitClient_RejectsOverLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	limited := client.NewAdaptiveLimitClient(mockClient, 1, 1, 1, time.Second)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *models.PostPaymentBankRequest) (*models.PostPaymentBankResponse, error) {
+		close(started)
+		<-release
+		return &models.PostPaymentBankResponse{Authorised: true}, nil
+	})
+
+	go limited.PostBankPayment(context.Background(), &models.PostPaymentBankRequest{})
+	<-started
+
+	resp, err := limited.PostBankPayment(context.Background(), &models.PostPaymentBankRequest{})
+	close(release)
+
+	require.Nil(t, resp)
+	var bankErr *gatewayerrors.BankError
+	require.ErrorAs(t, err, &bankErr)
+	assert.Equal(t, http.StatusServiceUnavailable, bankErr.StatusCode)
+	assert.Equal(t, int64(1), limited.Rejected())
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,55 +12,135 @@ import (
 
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/redact"
 )
 
 type Client interface {
-	PostBankPayment(request *models.PostPaymentBankRequest) (*models.PostPaymentBankResponse, error)
+	PostBankPayment(ctx context.Context, request *models.PostPaymentBankRequest) (*models.PostPaymentBankResponse, error)
+	PostBankTokenPayment(ctx context.Context, request *models.PostTokenBankRequest) (*models.PostPaymentBankResponse, error)
+	CaptureBankPayment(ctx context.Context, request *models.CaptureBankRequest) (*models.CaptureBankResponse, error)
+	VoidBankPayment(ctx context.Context, request *models.VoidBankRequest) (*models.VoidBankResponse, error)
+	RefundBankPayment(ctx context.Context, request *models.RefundBankRequest) (*models.RefundBankResponse, error)
+	ReverseBankPayment(ctx context.Context, request *models.ReverseBankRequest) (*models.ReverseBankResponse, error)
 }
 
 type HTTPClient struct {
 	httpClient *http.Client
 	baseURL    string
+	timeout    time.Duration
 }
 
+// NewClient creates a bank client.  timeout caps every call, a caller's context deadline wins when it is sooner.
 func NewClient(baseURL string, timeout time.Duration) *HTTPClient {
 	return &HTTPClient{
-		httpClient: &http.Client{Timeout: timeout},
+		httpClient: &http.Client{},
 		baseURL:    baseURL,
+		timeout:    timeout,
 	}
 }
 
-func (c *HTTPClient) PostBankPayment(request *models.PostPaymentBankRequest) (*models.PostPaymentBankResponse, error) {
-	url := fmt.Sprintf("%s/payments", c.baseURL)
+func (c *HTTPClient) PostBankPayment(ctx context.Context, request *models.PostPaymentBankRequest) (*models.PostPaymentBankResponse, error) {
+	var response models.PostPaymentBankResponse
+	if err := c.post(ctx, "/payments", request, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// PostBankTokenPayment authorizes a payment made with a network token, the bank takes these on their own path.
+func (c *HTTPClient) PostBankTokenPayment(ctx context.Context, request *models.PostTokenBankRequest) (*models.PostPaymentBankResponse, error) {
+	var response models.PostPaymentBankResponse
+	if err := c.post(ctx, "/token-payments", request, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func (c *HTTPClient) CaptureBankPayment(ctx context.Context, request *models.CaptureBankRequest) (*models.CaptureBankResponse, error) {
+	var response models.CaptureBankResponse
+	if err := c.post(ctx, "/captures", request, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func (c *HTTPClient) VoidBankPayment(ctx context.Context, request *models.VoidBankRequest) (*models.VoidBankResponse, error) {
+	var response models.VoidBankResponse
+	if err := c.post(ctx, "/voids", request, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func (c *HTTPClient) RefundBankPayment(ctx context.Context, request *models.RefundBankRequest) (*models.RefundBankResponse, error) {
+	var response models.RefundBankResponse
+	if err := c.post(ctx, "/refunds", request, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func (c *HTTPClient) ReverseBankPayment(ctx context.Context, request *models.ReverseBankRequest) (*models.ReverseBankResponse, error) {
+	var response models.ReverseBankResponse
+	if err := c.post(ctx, "/reversals", request, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// post sends request as JSON to the bank and decodes a 200 answer into response.
+func (c *HTTPClient) post(ctx context.Context, path string, request, response any) error {
+	url := c.baseURL + path
 	body, err := json.Marshal(request)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Log the JSON payload
-	log.Printf("Sending request to %s with payload: %s", url, string(body))
+	// the payload carries the card number and CVV, only its redacted form may be logged
+	logged, err := redact.JSON(request, redact.Log)
+	if err != nil {
+		return fmt.Errorf("failed to redact request: %w", err)
+	}
+	log.Printf("Sending request to %s with payload: %s", url, logged)
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create POST request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Post(url, "application/json", bytes.NewBuffer(body))
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make POST request: %w", err)
+		return fmt.Errorf("failed to make POST request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusServiceUnavailable {
-		return nil, gatewayerrors.NewBankError(
+		return gatewayerrors.NewBankError(
 			errors.New("acquiring bank unavailble"),
 			http.StatusServiceUnavailable,
 		)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-200 response: %d", resp.StatusCode)
+		return gatewayerrors.NewBankError(
+			fmt.Errorf("received non-200 response: %d", resp.StatusCode),
+			resp.StatusCode,
+		)
 	}
 
-	var response models.PostPaymentBankResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &response, nil
+	return nil
 }
@@ -1,6 +1,7 @@
 package client_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -37,7 +38,7 @@ func TestHTTPClient_PostBankPayment(t *testing.T) {
 		CVV:        "123",
 	}
 
-	resp, err := httpClient.PostBankPayment(&postPayment)
+	resp, err := httpClient.PostBankPayment(context.Background(), &postPayment)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 
@@ -65,7 +66,7 @@ func TestHTTPClient_PostBankPayment_ServiceUnavailable(t *testing.T) {
 	}
 
 	// Make the request using the HTTP client
-	resp, err := httpClient.PostBankPayment(&postPayment)
+	resp, err := httpClient.PostBankPayment(context.Background(), &postPayment)
 	require.Error(t, err)
 	require.Nil(t, resp)
 
@@ -74,3 +75,98 @@ func TestHTTPClient_PostBankPayment_ServiceUnavailable(t *testing.T) {
 
 	assert.Equal(t, http.StatusServiceUnavailable, bankErr.StatusCode)
 }
+
+func TestHTTPClient_PostBankPayment_DeadlineExceeded(t *testing.T) {
+	// Create a test server that is slower than the caller's budget
+	release := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer testServer.Close()
+	defer close(release)
+
+	httpClient := client.NewClient(testServer.URL, 5*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	resp, err := httpClient.PostBankPayment(ctx, &models.PostPaymentBankRequest{})
+	require.Error(t, err)
+	require.Nil(t, resp)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestHTTPClient_CaptureBankPayment(t *testing.T) {
+	var received models.CaptureBankRequest
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/captures", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(&models.CaptureBankResponse{Captured: true})
+	}))
+	defer testServer.Close()
+
+	httpClient := client.NewClient(testServer.URL, 5*time.Second)
+
+	capture := models.CaptureBankRequest{AuthorizationCode: "123456", Amount: 100}
+	resp, err := httpClient.CaptureBankPayment(context.Background(), &capture)
+	require.NoError(t, err)
+	assert.True(t, resp.Captured)
+	assert.Equal(t, capture, received)
+}
+
+func TestHTTPClient_VoidBankPayment(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/voids", r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(&models.VoidBankResponse{Voided: true})
+	}))
+	defer testServer.Close()
+
+	httpClient := client.NewClient(testServer.URL, 5*time.Second)
+
+	resp, err := httpClient.VoidBankPayment(context.Background(), &models.VoidBankRequest{AuthorizationCode: "123456"})
+	require.NoError(t, err)
+	assert.True(t, resp.Voided)
+}
+
+func TestHTTPClient_RefundBankPayment(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/refunds", r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(&models.RefundBankResponse{Refunded: true})
+	}))
+	defer testServer.Close()
+
+	httpClient := client.NewClient(testServer.URL, 5*time.Second)
+
+	resp, err := httpClient.RefundBankPayment(context.Background(), &models.RefundBankRequest{AuthorizationCode: "123456", Amount: 100})
+	require.NoError(t, err)
+	assert.True(t, resp.Refunded)
+}
+
+func TestHTTPClient_PostBankTokenPayment(t *testing.T) {
+	var received models.PostTokenBankRequest
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/token-payments", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(&models.PostPaymentBankResponse{Authorised: true, AuthorizationCode: "123456"})
+	}))
+	defer testServer.Close()
+
+	httpClient := client.NewClient(testServer.URL, 5*time.Second)
+
+	payment := models.PostTokenBankRequest{
+		Token:      "4895370012003478",
+		ExpiryDate: "4/2035",
+		Cryptogram: "AgAAAAAABk4DWZ4C28yUQAAAAAA=",
+		Currency:   "GBP",
+		Amount:     100,
+	}
+	resp, err := httpClient.PostBankTokenPayment(context.Background(), &payment)
+	require.NoError(t, err)
+	assert.True(t, resp.Authorised)
+	assert.Equal(t, payment, received)
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/google/uuid"
+)
+
+/*
+FakeClient is an in-process stand in for the bank simulator so the gateway can run without docker compose.  It follows the same rules as imposters/bank_simulator.ejs, keyed on the last digit of the card number:
+
+  - odd: authorized
+  - even (not zero): declined, with a decline code that depends on the digit (see fakeDeclines)
+  - zero: 503 from the acquiring bank
+
+Network token payments follow the same rules keyed on the last digit of the token.
+
+Captures, voids, refunds and reversals of anything the fake authorized always succeed.
+
+When a billing address is sent the AVS result is Y, unless the postal code is 00000 which gives N.
+*/
+
+type FakeClient struct{}
+
+func NewFakeClient() *FakeClient {
+	return &FakeClient{}
+}
+
+func (c *FakeClient) PostBankPayment(ctx context.Context, request *models.PostPaymentBankRequest) (*models.PostPaymentBankResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// payments with a stored card are made without a CVV
+	if request.CardNumber == "" || request.ExpiryDate == "" || request.Currency == "" || (request.CVV == "" && request.StoredCredential == nil) {
+		return nil, gatewayerrors.NewBankError(
+			errors.New("received non-200 response: 400"),
+			http.StatusBadRequest,
+		)
+	}
+
+	return fakeAuthorization(request.CardNumber[len(request.CardNumber)-1:], request.BillingAddress)
+}
+
+func (c *FakeClient) PostBankTokenPayment(ctx context.Context, request *models.PostTokenBankRequest) (*models.PostPaymentBankResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if request.Token == "" || request.Cryptogram == "" || request.ExpiryDate == "" || request.Currency == "" {
+		return nil, gatewayerrors.NewBankError(
+			errors.New("received non-200 response: 400"),
+			http.StatusBadRequest,
+		)
+	}
+
+	return fakeAuthorization(request.Token[len(request.Token)-1:], request.BillingAddress)
+}
+
+// fakeAuthorization answers an authorization for a card or token ending in lastDigit.
+func fakeAuthorization(lastDigit string, address *models.BillingAddress) (*models.PostPaymentBankResponse, error) {
+	switch {
+	case lastDigit == "0":
+		return nil, gatewayerrors.NewBankError(
+			errors.New("acquiring bank unavailble"),
+			http.StatusServiceUnavailable,
+		)
+	case strings.Contains("13579", lastDigit):
+		return &models.PostPaymentBankResponse{
+			Authorised:        true,
+			AuthorizationCode: uuid.NewString(),
+			AVSResult:         fakeAVSResult(address),
+		}, nil
+	default:
+		decline := fakeDeclines[lastDigit]
+		return &models.PostPaymentBankResponse{
+			Authorised:        false,
+			AuthorizationCode: "",
+			DeclineCode:       decline.Code,
+			DeclineMessage:    decline.Message,
+		}, nil
+	}
+}
+
+func (c *FakeClient) CaptureBankPayment(ctx context.Context, request *models.CaptureBankRequest) (*models.CaptureBankResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if request.AuthorizationCode == "" {
+		return nil, gatewayerrors.NewBankError(
+			errors.New("received non-200 response: 400"),
+			http.StatusBadRequest,
+		)
+	}
+
+	return &models.CaptureBankResponse{Captured: true}, nil
+}
+
+func (c *FakeClient) VoidBankPayment(ctx context.Context, request *models.VoidBankRequest) (*models.VoidBankResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if request.AuthorizationCode == "" {
+		return nil, gatewayerrors.NewBankError(
+			errors.New("received non-200 response: 400"),
+			http.StatusBadRequest,
+		)
+	}
+
+	return &models.VoidBankResponse{Voided: true}, nil
+}
+
+func (c *FakeClient) RefundBankPayment(ctx context.Context, request *models.RefundBankRequest) (*models.RefundBankResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if request.AuthorizationCode == "" {
+		return nil, gatewayerrors.NewBankError(
+			errors.New("received non-200 response: 400"),
+			http.StatusBadRequest,
+		)
+	}
+
+	return &models.RefundBankResponse{Refunded: true}, nil
+}
+
+func (c *FakeClient) ReverseBankPayment(ctx context.Context, request *models.ReverseBankRequest) (*models.ReverseBankResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if request.AuthorizationCode == "" {
+		return nil, gatewayerrors.NewBankError(
+			errors.New("received non-200 response: 400"),
+			http.StatusBadRequest,
+		)
+	}
+
+	return &models.ReverseBankResponse{Reversed: true}, nil
+}
+
+// fakeDeclines are the issuer response codes the fake declines with, keyed on the last digit of the card number.
+var fakeDeclines = map[string]models.DeclineReason{
+	"2": {Code: "05", Message: "Do not honour"},
+	"4": {Code: "51", Message: "Insufficient funds"},
+	"6": {Code: "54", Message: "Expired card"},
+	"8": {Code: "14", Message: "Invalid card number"},
+}
+
+func fakeAVSResult(address *models.BillingAddress) string {
+	switch {
+	case address == nil:
+		return ""
+	case address.PostalCode == "00000":
+		return "N"
+	default:
+		return "Y"
+	}
+}
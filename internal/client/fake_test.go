@@ -0,0 +1,88 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClient_PostBankPayment(t *testing.T) {
+	fakeClient := client.NewFakeClient()
+
+	postPayment := models.PostPaymentBankRequest{
+		CardNumber: "2222405343248877",
+		ExpiryDate: "4/2035",
+		Currency:   "GBP",
+		Amount:     100,
+		CVV:        "123",
+	}
+
+	resp, err := fakeClient.PostBankPayment(context.Background(), &postPayment)
+	require.NoError(t, err)
+	assert.True(t, resp.Authorised)
+	assert.NotEmpty(t, resp.AuthorizationCode)
+
+	postPayment.CardNumber = "2222405343248878"
+	resp, err = fakeClient.PostBankPayment(context.Background(), &postPayment)
+	require.NoError(t, err)
+	assert.False(t, resp.Authorised)
+	assert.Empty(t, resp.AuthorizationCode)
+	assert.Equal(t, "14", resp.DeclineCode)
+	assert.Equal(t, "Invalid card number", resp.DeclineMessage)
+
+	postPayment.CardNumber = "2222405343248870"
+	resp, err = fakeClient.PostBankPayment(context.Background(), &postPayment)
+	require.Error(t, err)
+	require.Nil(t, resp)
+
+	var bankErr *gatewayerrors.BankError
+	require.True(t, errors.As(err, &bankErr))
+	assert.Equal(t, http.StatusServiceUnavailable, bankErr.StatusCode)
+}
+
+func TestFakeClient_CaptureBankPayment(t *testing.T) {
+	fakeClient := client.NewFakeClient()
+
+	resp, err := fakeClient.CaptureBankPayment(context.Background(), &models.CaptureBankRequest{AuthorizationCode: "123456", Amount: 100})
+	require.NoError(t, err)
+	assert.True(t, resp.Captured)
+
+	_, err = fakeClient.CaptureBankPayment(context.Background(), &models.CaptureBankRequest{Amount: 100})
+	var bankErr *gatewayerrors.BankError
+	require.ErrorAs(t, err, &bankErr)
+	assert.Equal(t, http.StatusBadRequest, bankErr.StatusCode)
+}
+
+func TestFakeClient_PostBankTokenPayment(t *testing.T) {
+	fakeClient := client.NewFakeClient()
+
+	payment := models.PostTokenBankRequest{
+		Token:      "4895370012003477",
+		ExpiryDate: "4/2035",
+		Cryptogram: "AgAAAAAABk4DWZ4C28yUQAAAAAA=",
+		Currency:   "GBP",
+		Amount:     100,
+	}
+	resp, err := fakeClient.PostBankTokenPayment(context.Background(), &payment)
+	require.NoError(t, err)
+	assert.True(t, resp.Authorised)
+
+	payment.Token = "4895370012003474"
+	resp, err = fakeClient.PostBankTokenPayment(context.Background(), &payment)
+	require.NoError(t, err)
+	assert.False(t, resp.Authorised)
+	assert.Equal(t, "51", resp.DeclineCode)
+
+	payment.Cryptogram = ""
+	_, err = fakeClient.PostBankTokenPayment(context.Background(), &payment)
+	var bankErr *gatewayerrors.BankError
+	require.ErrorAs(t, err, &bankErr)
+	assert.Equal(t, http.StatusBadRequest, bankErr.StatusCode)
+}
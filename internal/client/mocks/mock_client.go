@@ -1,15 +1,16 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: /Users/ehsanashouri/go/src/github.com/redscaresu/payment-gateway-challenge-go-1/internal/client/client.go
+// Source: internal/client/client.go
 //
 // Generated by this command:
 //
-//	mockgen -source=/Users/ehsanashouri/go/src/github.com/redscaresu/payment-gateway-challenge-go-1/internal/client/client.go -destination=/Users/ehsanashouri/go/src/github.com/redscaresu/payment-gateway-challenge-go-1/internal/client/mocks/mock_client.go -package=mocks
+//	mockgen -source=internal/client/client.go -destination=internal/client/mocks/mock_client.go -package=mocks
 //
 
 // Package mocks is a generated GoMock package.
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	models "github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
@@ -40,17 +41,92 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// CaptureBankPayment mocks base method.
+func (m *MockClient) CaptureBankPayment(ctx context.Context, request *models.CaptureBankRequest) (*models.CaptureBankResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureBankPayment", ctx, request)
+	ret0, _ := ret[0].(*models.CaptureBankResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaptureBankPayment indicates an expected call of CaptureBankPayment.
+func (mr *MockClientMockRecorder) CaptureBankPayment(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureBankPayment", reflect.TypeOf((*MockClient)(nil).CaptureBankPayment), ctx, request)
+}
+
 // PostBankPayment mocks base method.
-func (m *MockClient) PostBankPayment(request *models.PostPaymentBankRequest) (*models.PostPaymentBankResponse, error) {
+func (m *MockClient) PostBankPayment(ctx context.Context, request *models.PostPaymentBankRequest) (*models.PostPaymentBankResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PostBankPayment", request)
+	ret := m.ctrl.Call(m, "PostBankPayment", ctx, request)
 	ret0, _ := ret[0].(*models.PostPaymentBankResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PostBankPayment indicates an expected call of PostBankPayment.
-func (mr *MockClientMockRecorder) PostBankPayment(request any) *gomock.Call {
+func (mr *MockClientMockRecorder) PostBankPayment(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostBankPayment", reflect.TypeOf((*MockClient)(nil).PostBankPayment), ctx, request)
+}
+
+// PostBankTokenPayment mocks base method.
+func (m *MockClient) PostBankTokenPayment(ctx context.Context, request *models.PostTokenBankRequest) (*models.PostPaymentBankResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostBankTokenPayment", ctx, request)
+	ret0, _ := ret[0].(*models.PostPaymentBankResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostBankTokenPayment indicates an expected call of PostBankTokenPayment.
+func (mr *MockClientMockRecorder) PostBankTokenPayment(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostBankTokenPayment", reflect.TypeOf((*MockClient)(nil).PostBankTokenPayment), ctx, request)
+}
+
+// RefundBankPayment mocks base method.
+func (m *MockClient) RefundBankPayment(ctx context.Context, request *models.RefundBankRequest) (*models.RefundBankResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundBankPayment", ctx, request)
+	ret0, _ := ret[0].(*models.RefundBankResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefundBankPayment indicates an expected call of RefundBankPayment.
+func (mr *MockClientMockRecorder) RefundBankPayment(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundBankPayment", reflect.TypeOf((*MockClient)(nil).RefundBankPayment), ctx, request)
+}
+
+// ReverseBankPayment mocks base method.
+func (m *MockClient) ReverseBankPayment(ctx context.Context, request *models.ReverseBankRequest) (*models.ReverseBankResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseBankPayment", ctx, request)
+	ret0, _ := ret[0].(*models.ReverseBankResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseBankPayment indicates an expected call of ReverseBankPayment.
+func (mr *MockClientMockRecorder) ReverseBankPayment(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseBankPayment", reflect.TypeOf((*MockClient)(nil).ReverseBankPayment), ctx, request)
+}
+
+// VoidBankPayment mocks base method.
+func (m *MockClient) VoidBankPayment(ctx context.Context, request *models.VoidBankRequest) (*models.VoidBankResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidBankPayment", ctx, request)
+	ret0, _ := ret[0].(*models.VoidBankResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VoidBankPayment indicates an expected call of VoidBankPayment.
+func (mr *MockClientMockRecorder) VoidBankPayment(ctx, request any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostBankPayment", reflect.TypeOf((*MockClient)(nil).PostBankPayment), request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidBankPayment", reflect.TypeOf((*MockClient)(nil).VoidBankPayment), ctx, request)
 }
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+/*
+In async mode a payment is validated and stored as processing straight away, and a pool of workers sends it to the bank in the background.  The merchant polls GET /api/payments/{id} until it leaves processing.  A payment the bank could not take, because it was unavailable or did not answer in time, ends up failed with a failure_reason rather than the error a synchronous payment would get.
+
+Payments wait in a queue of asyncQueueSize.  When it is full Submit turns the payment away as a 503 rather than let the backlog grow, and payments still queued when the gateway shuts down stay processing.
+*/
+
+const asyncQueueSize = 1000
+
+// SetAsyncWorkers sets how many workers send async payments to the bank, zero turns async mode off and Submit makes
+// the payment synchronously.
+func (p *PaymentServiceImpl) SetAsyncWorkers(workers int) {
+	p.asyncWorkers = workers
+}
+
+// RunWorkers starts the async workers, they stop when ctx is done.
+func (p *PaymentServiceImpl) RunWorkers(ctx context.Context) {
+	for i := 0; i < p.asyncWorkers; i++ {
+		go p.work(ctx)
+	}
+}
+
+// Submit validates a payment and stores it as processing, leaving a worker to send it to the bank.  Validation errors
+// are returned straight away.
+func (p *PaymentServiceImpl) Submit(ctx context.Context, request *models.PostPaymentHandlerRequest) (*models.PostPaymentResponse, error) {
+	// scheduled payments wait for the scheduler rather than a worker
+	if p.asyncWorkers == 0 || request.ExecuteAt != nil {
+		return p.Create(ctx, request)
+	}
+
+	pending, err := p.prepare(ctx, request, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// held until the payment is stored, so a worker cannot pick it up before it exists
+	unlock := p.locks.lock(pending.id)
+	defer unlock()
+
+	select {
+	case p.queue <- pending:
+	default:
+		return nil, gatewayerrors.NewBankError(
+			errors.New("too many payments are waiting for the acquiring bank"),
+			http.StatusServiceUnavailable,
+		)
+	}
+
+	request = pending.request
+	payment := &models.PostPaymentResponse{
+		Id:                 pending.id,
+		PaymentStatus:      string(StatusProcessing),
+		CardNumberLastFour: lastFour(request),
+		ExpiryMonth:        request.ExpiryMonth,
+		ExpiryYear:         request.ExpiryYear,
+		Currency:           request.Currency,
+		Amount:             request.Amount,
+		StoredCredential:   request.StoredCredential,
+		CardToken:          request.CardToken,
+		CaptureMethod:      captureMethodDelayed,
+		PaymentMethod:      recordedPaymentMethod(request),
+	}
+	if request.Capture {
+		payment.CaptureMethod = captureMethodImmediate
+	}
+
+	// queued by now so it must be stored even if the caller has given up
+	if err := p.repo.AddPayment(context.WithoutCancel(ctx), *payment); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+func (p *PaymentServiceImpl) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case pending := <-p.queue:
+			p.process(ctx, pending, StatusProcessing)
+		}
+	}
+}
+
+// process sends a stored payment to the bank and records the outcome, or that it failed.  The payment is left alone
+// unless it is still in status from, a scheduled payment may have been cancelled while it waited.
+func (p *PaymentServiceImpl) process(ctx context.Context, pending pendingPayment, from Status) {
+	unlock := p.locks.lock(pending.id)
+	defer unlock()
+
+	payment, err := p.repo.GetPayment(context.WithoutCancel(ctx), pending.id)
+	if err != nil || payment == nil {
+		log.Printf("processing payment %s: %v (found: %t)", pending.id, err, payment != nil)
+		return
+	}
+	if Status(payment.PaymentStatus) != from {
+		return
+	}
+
+	if _, err = p.authorize(ctx, pending, true); err == nil {
+		return
+	}
+
+	payment.PaymentStatus = string(StatusFailed)
+	payment.FailureReason = failureReason(err)
+	if err := p.repo.UpdatePayment(context.WithoutCancel(ctx), *payment); err != nil {
+		log.Printf("recording failure of payment %s: %v", pending.id, err)
+		return
+	}
+	p.bus.Publish(events.NewEvent(events.PaymentFailed, *payment))
+}
+
+// failureReason says why an async or scheduled payment failed, in the words a synchronous payment's error response would use.
+func failureReason(err error) string {
+	var timeoutErr *gatewayerrors.TimeoutError
+	var bankErr *gatewayerrors.BankError
+	switch {
+	case errors.As(err, &timeoutErr) && timeoutErr.BankCalled:
+		return "the acquiring bank did not answer in time, the outcome of the payment is unknown"
+	case errors.As(err, &timeoutErr), errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return "the payment was not sent to the acquiring bank in time"
+	case errors.As(err, &bankErr) && bankErr.StatusCode == http.StatusServiceUnavailable:
+		return "the acquiring bank was unavailable"
+	case errors.As(err, &bankErr):
+		return "the acquiring bank did not complete the payment"
+	default:
+		return "the payment could not be processed"
+	}
+}
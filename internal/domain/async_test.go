@@ -0,0 +1,147 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func asyncRequest() models.PostPaymentHandlerRequest {
+	return models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+	}
+}
+
+// waitForStatus polls the repository until the payment leaves processing and returns it.
+func waitForStatus(t *testing.T, repo *repository.PaymentsRepository, id string) *models.PostPaymentResponse {
+	var payment *models.PostPaymentResponse
+	require.Eventually(t, func() bool {
+		var err error
+		payment, err = repo.GetPayment(context.Background(), id)
+		require.NoError(t, err)
+		return payment.PaymentStatus != "processing"
+	}, time.Second, time.Millisecond)
+	return payment
+}
+
+func TestSubmit_ProcessesInBackground(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	release := make(chan struct{})
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *models.PostPaymentBankRequest) (*models.PostPaymentBankResponse, error) {
+		<-release
+		return &models.PostPaymentBankResponse{Authorised: true, AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2"}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := repository.NewPaymentsRepository()
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+	domain.SetAsyncWorkers(1)
+	domain.RunWorkers(ctx)
+
+	request := asyncRequest()
+	response, err := domain.Submit(context.Background(), &request)
+	require.NoError(t, err)
+	assert.Equal(t, "processing", response.PaymentStatus)
+	assert.Equal(t, 8877, response.CardNumberLastFour)
+
+	stored, err := repo.GetPayment(context.Background(), response.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "processing", stored.PaymentStatus)
+
+	close(release)
+	payment := waitForStatus(t, repo, response.Id)
+	assert.Equal(t, "authorized", payment.PaymentStatus)
+	assert.Equal(t, "abb53d1a-42dd-4ecc-9a25-dca064d35eb2", payment.AuthorizationCode)
+}
+
+func TestSubmit_BankUnavailableFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return(nil,
+		gatewayerrors.NewBankError(errors.New("acquiring bank unavailble"), http.StatusServiceUnavailable))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := events.NewInMemoryBus()
+	failed := make(chan events.Event, 1)
+	bus.Subscribe(events.PaymentFailed, func(event events.Event) {
+		failed <- event
+	})
+
+	repo := repository.NewPaymentsRepository()
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, bus)
+	domain.SetAsyncWorkers(1)
+	domain.RunWorkers(ctx)
+
+	request := asyncRequest()
+	response, err := domain.Submit(context.Background(), &request)
+	require.NoError(t, err)
+
+	payment := waitForStatus(t, repo, response.Id)
+	assert.Equal(t, "failed", payment.PaymentStatus)
+	assert.Equal(t, "the acquiring bank was unavailable", payment.FailureReason)
+	assert.Equal(t, response.Id, (<-failed).Payment.Id)
+}
+
+func TestSubmit_ValidationErrorReturnedStraightAway(t *testing.T) {
+	repo := repository.NewPaymentsRepository()
+	// no bank call is expected
+	domain := domain.NewPaymentServiceImpl(repo, nil, events.NewInMemoryBus())
+	domain.SetAsyncWorkers(1)
+
+	request := asyncRequest()
+	request.Currency = "JPY"
+	response, err := domain.Submit(context.Background(), &request)
+	require.Nil(t, response)
+
+	var validationErr *gatewayerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "currency", validationErr.Field)
+
+	payments, err := repo.ListPayments(context.Background(), models.PaymentFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, payments)
+}
+
+func TestSubmit_WithoutWorkersIsSynchronous(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return(&models.PostPaymentBankResponse{
+		Authorised:        true,
+		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+	}, nil)
+
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), mockClient, events.NewInMemoryBus())
+
+	request := asyncRequest()
+	response, err := domain.Submit(context.Background(), &request)
+	require.NoError(t, err)
+	assert.Equal(t, "authorized", response.PaymentStatus)
+}
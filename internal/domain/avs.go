@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+/*
+Address verification (AVS) compares the billing address the customer typed with the one the issuer holds.  The issuer answers with a single letter result code:
+
+  - Y: street and postal code match
+  - A: street matches, postal code does not
+  - Z: postal code matches, street does not
+  - N: neither matches
+  - U: the issuer could not check
+
+The bank authorizes regardless of the result, so a merchant that wants to refuse mismatches names the codes to decline with SetAVSDeclineResults.  The gateway then voids the authorization and records the payment as declined.
+*/
+
+// SetAVSDeclineResults sets the AVS result codes that make the gateway decline a payment the bank authorized.
+func (p *PaymentServiceImpl) SetAVSDeclineResults(results []string) {
+	p.avsDecline = map[string]bool{}
+	for _, result := range results {
+		p.avsDecline[result] = true
+	}
+}
+
+func (p *PaymentServiceImpl) avsDeclines(result string) bool {
+	return result != "" && p.avsDecline[result]
+}
+
+// releaseAVSMismatch voids an authorization the gateway is declining because of its AVS result.
+func (p *PaymentServiceImpl) releaseAVSMismatch(ctx context.Context, id string, bankResponse *models.PostPaymentBankResponse) {
+	// the hold must be released even if the caller has given up
+	_, err := p.client.VoidBankPayment(context.WithoutCancel(ctx), &models.VoidBankRequest{
+		AuthorizationCode: bankResponse.AuthorizationCode,
+	})
+	if err != nil {
+		log.Printf("releasing payment %s declined on AVS result %s: %v", id, bankResponse.AVSResult, err)
+	}
+}
+
+func avsDeclineReason(result string) *models.DeclineReason {
+	return &models.DeclineReason{
+		Code:    "avs_mismatch",
+		Message: fmt.Sprintf("the billing address did not pass address verification (result %s)", result),
+	}
+}
+
+func validateBillingAddress(address *models.BillingAddress, id string) error {
+	if address == nil {
+		return nil
+	}
+
+	if address.Line1 == "" || address.PostalCode == "" {
+		return gatewayerrors.NewValidationError(
+			errors.New("billing address needs line1 and postal_code"),
+			id,
+			"billing_address",
+		)
+	}
+
+	if len(address.Country) != 2 {
+		return gatewayerrors.NewValidationError(
+			errors.New("billing address country must be a two letter ISO 3166 code"),
+			id,
+			"billing_address",
+		)
+	}
+
+	return nil
+}
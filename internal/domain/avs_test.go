@@ -0,0 +1,100 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func avsPayment() models.PostPaymentHandlerRequest {
+	return models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+		BillingAddress: &models.BillingAddress{
+			Line1:      "1 Main Street",
+			PostalCode: "SW1A 1AA",
+			Country:    "GB",
+		},
+	}
+}
+
+func TestPostPayment_AVSResult(t *testing.T) {
+	tests := []struct {
+		name           string
+		avsResult      string
+		expectedStatus string
+		expectVoid     bool
+	}{
+		{name: "Match", avsResult: "Y", expectedStatus: "authorized"},
+		{name: "MismatchDeclined", avsResult: "N", expectedStatus: "declined", expectVoid: true},
+		{name: "UnavailableAllowed", avsResult: "U", expectedStatus: "authorized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockClient := mocks.NewMockClient(ctrl)
+
+			request := avsPayment()
+			mockClient.EXPECT().PostBankPayment(gomock.Any(), &models.PostPaymentBankRequest{
+				CardNumber:     "2222405343248877",
+				ExpiryDate:     "4/2035",
+				Currency:       "GBP",
+				Amount:         100,
+				CVV:            "123",
+				BillingAddress: request.BillingAddress,
+			}).Return(&models.PostPaymentBankResponse{
+				Authorised:        true,
+				AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+				AVSResult:         tt.avsResult,
+			}, nil)
+			if tt.expectVoid {
+				mockClient.EXPECT().VoidBankPayment(gomock.Any(), &models.VoidBankRequest{
+					AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+				}).Return(&models.VoidBankResponse{Voided: true}, nil)
+			}
+
+			domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), mockClient, events.NewInMemoryBus())
+			domain.SetAVSDeclineResults([]string{"N", "A"})
+
+			response, err := domain.Create(context.Background(), &request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, response.PaymentStatus)
+			assert.Equal(t, tt.avsResult, response.AVSResult)
+			if tt.expectVoid {
+				require.NotNil(t, response.DeclineReason)
+				assert.Equal(t, "avs_mismatch", response.DeclineReason.Code)
+			} else {
+				assert.Nil(t, response.DeclineReason)
+			}
+		})
+	}
+}
+
+func TestPostPayment_InvalidBillingAddress(t *testing.T) {
+	request := avsPayment()
+	request.BillingAddress.Country = "GBR"
+
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+
+	response, err := domain.Create(context.Background(), &request)
+	require.Nil(t, response)
+
+	var validationErr *gatewayerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "billing_address", validationErr.Field)
+}
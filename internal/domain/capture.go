@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/google/uuid"
+)
+
+// CapturePayment asks the acquiring bank to settle amount of the funds held by an authorized payment and records the
+// capture against the payment.  An amount of 0 captures whatever is still held.  A payment shipped in parts can be
+// captured once per shipment, it stays partially_captured until the authorized amount is used up.
+func (p *PaymentServiceImpl) CapturePayment(ctx context.Context, id string, amount int) (*models.PostPaymentResponse, error) {
+	if amount < 0 {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("invalid capture amount"),
+			id,
+			"amount",
+		)
+	}
+
+	return p.run(ctx, id, operation{
+		name: "capture",
+		check: func(payment *models.PostPaymentResponse) error {
+			if payment.CaptureMethod == captureMethodImmediate {
+				return gatewayerrors.NewStateError(
+					errors.New("payment was captured when it was authorized"),
+					id,
+					payment.PaymentStatus,
+				)
+			}
+			if err := transition(id, "capture", Status(payment.PaymentStatus), StatusPartiallyCaptured, StatusCaptured); err != nil {
+				return err
+			}
+			if payment.ExpiresAt != nil && time.Now().After(*payment.ExpiresAt) {
+				return gatewayerrors.NewStateError(
+					errors.New("cannot capture an expired authorization"),
+					id,
+					payment.PaymentStatus,
+				)
+			}
+			if amount > capturable(payment) {
+				return gatewayerrors.NewStateError(
+					fmt.Errorf("cannot capture %d, only %d is left to capture", amount, capturable(payment)),
+					id,
+					payment.PaymentStatus,
+				)
+			}
+			// resolved here, under the payment lock, so it cannot race another capture
+			if amount == 0 {
+				amount = capturable(payment)
+			}
+			return nil
+		},
+		bankCall: func(ctx context.Context, payment *models.PostPaymentResponse) error {
+			bankResponse, err := p.client.CaptureBankPayment(ctx, &models.CaptureBankRequest{
+				AuthorizationCode: payment.AuthorizationCode,
+				Amount:            amount,
+			})
+			if err != nil {
+				return err
+			}
+			if !bankResponse.Captured {
+				return gatewayerrors.NewBankError(
+					errors.New("acquiring bank refused the capture"),
+					http.StatusBadGateway,
+				)
+			}
+			return nil
+		},
+		apply: func(payment *models.PostPaymentResponse) {
+			capture := models.Capture{
+				Id:        uuid.New().String(),
+				Amount:    amount,
+				CreatedAt: time.Now().UTC(),
+			}
+			if payment.CapturedAt == nil {
+				payment.CapturedAt = &capture.CreatedAt
+			}
+			payment.Captures = append(payment.Captures, capture)
+			payment.PaymentStatus = string(StatusPartiallyCaptured)
+			if capturable(payment) == 0 {
+				// once fully captured the authorization no longer lapses
+				payment.PaymentStatus = string(StatusCaptured)
+				payment.ExpiresAt = nil
+			}
+		},
+		event: events.PaymentCaptured,
+	})
+}
+
+// capturable is how much of the authorized amount has not been captured yet.
+func capturable(payment *models.PostPaymentResponse) int {
+	left := payment.Amount
+	for _, capture := range payment.Captures {
+		left -= capture.Amount
+	}
+	return left
+}
+
+// captured is how much of the payment has been captured.  Payments captured in one go, when they were authorized or
+// before captures were recorded, have no capture records and count as captured in full.
+func captured(payment *models.PostPaymentResponse) int {
+	if len(payment.Captures) == 0 {
+		return payment.Amount
+	}
+	total := 0
+	for _, capture := range payment.Captures {
+		total += capture.Amount
+	}
+	return total
+}
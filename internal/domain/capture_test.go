@@ -0,0 +1,239 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func authorizedPayment() models.PostPaymentResponse {
+	expiresAt := time.Now().Add(7 * 24 * time.Hour)
+	return models.PostPaymentResponse{
+		Id:                 "test-id",
+		PaymentStatus:      "authorized",
+		CardNumberLastFour: 8877,
+		ExpiryMonth:        4,
+		ExpiryYear:         2035,
+		Currency:           "GBP",
+		Amount:             100,
+		ExpiresAt:          &expiresAt,
+		AuthorizationCode:  "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+	}
+}
+
+func TestCapturePayment_Authorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), authorizedPayment()))
+
+	mockClient.EXPECT().CaptureBankPayment(gomock.Any(), &models.CaptureBankRequest{
+		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+		Amount:            100,
+	}).Return(&models.CaptureBankResponse{Captured: true}, nil)
+
+	bus := events.NewInMemoryBus()
+	var published []events.Event
+	bus.Subscribe(events.PaymentCaptured, func(event events.Event) {
+		published = append(published, event)
+	})
+
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, bus)
+
+	response, err := domain.CapturePayment(context.Background(), "test-id", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "captured", response.PaymentStatus)
+	assert.Nil(t, response.ExpiresAt)
+	require.NotNil(t, response.CapturedAt)
+	assert.Equal(t, response.Captures[0].CreatedAt, *response.CapturedAt)
+
+	dbPayment, err := repo.GetPayment(context.Background(), "test-id")
+	require.NoError(t, err)
+	assert.Equal(t, "captured", dbPayment.PaymentStatus)
+
+	require.Len(t, published, 1)
+	assert.Equal(t, *response, published[0].Payment)
+}
+
+func TestCapturePayment_RejectedStates(t *testing.T) {
+	expired := authorizedPayment()
+	expiredAt := time.Now().Add(-time.Minute)
+	expired.ExpiresAt = &expiredAt
+
+	declined := authorizedPayment()
+	declined.PaymentStatus = "declined"
+
+	captured := authorizedPayment()
+	captured.PaymentStatus = "captured"
+
+	tests := []struct {
+		name    string
+		payment models.PostPaymentResponse
+	}{
+		{name: "declined", payment: declined},
+		{name: "already captured", payment: captured},
+		{name: "expired", payment: expired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			// no bank call is expected
+			mockClient := mocks.NewMockClient(ctrl)
+
+			repo := repository.NewPaymentsRepository()
+			require.NoError(t, repo.AddPayment(context.Background(), tt.payment))
+
+			domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+			response, err := domain.CapturePayment(context.Background(), "test-id", 0)
+			require.Nil(t, response)
+			var stateErr *gatewayerrors.StateError
+			require.ErrorAs(t, err, &stateErr)
+			assert.Equal(t, tt.payment.PaymentStatus, stateErr.Status)
+		})
+	}
+}
+
+func TestCapturePayment_ConcurrentCapturesReachBankOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), authorizedPayment()))
+
+	mockClient.EXPECT().CaptureBankPayment(gomock.Any(), gomock.Any()).Return(&models.CaptureBankResponse{Captured: true}, nil).Times(1)
+
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := domain.CapturePayment(context.Background(), "test-id", 0)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+}
+
+func TestCapturePayment_NotFound(t *testing.T) {
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, nil)
+
+	response, err := domain.CapturePayment(context.Background(), "test-id", 0)
+	assert.Nil(t, response)
+	assert.ErrorIs(t, err, gatewayerrors.ErrPaymentNotFound)
+}
+
+func TestCapturePayment_BankErrorKeepsAuthorization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), authorizedPayment()))
+
+	mockClient.EXPECT().CaptureBankPayment(gomock.Any(), gomock.Any()).Return(nil, gatewayerrors.NewBankError(errors.New("acquiring bank unavailble"), http.StatusServiceUnavailable))
+
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	_, err := domain.CapturePayment(context.Background(), "test-id", 0)
+	require.Error(t, err)
+
+	dbPayment, err := repo.GetPayment(context.Background(), "test-id")
+	require.NoError(t, err)
+	assert.Equal(t, "authorized", dbPayment.PaymentStatus)
+}
+
+func TestCapturePayment_PartialCapturesUntilExhausted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), authorizedPayment()))
+
+	for _, amount := range []int{30, 70} {
+		mockClient.EXPECT().CaptureBankPayment(gomock.Any(), &models.CaptureBankRequest{
+			AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+			Amount:            amount,
+		}).Return(&models.CaptureBankResponse{Captured: true}, nil)
+	}
+
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	response, err := domain.CapturePayment(context.Background(), "test-id", 30)
+	require.NoError(t, err)
+	assert.Equal(t, "partially_captured", response.PaymentStatus)
+	assert.NotNil(t, response.ExpiresAt)
+
+	_, err = domain.CapturePayment(context.Background(), "test-id", 80)
+	var stateErr *gatewayerrors.StateError
+	require.ErrorAs(t, err, &stateErr)
+
+	response, err = domain.CapturePayment(context.Background(), "test-id", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "captured", response.PaymentStatus)
+	assert.Nil(t, response.ExpiresAt)
+	require.Len(t, response.Captures, 2)
+	assert.Equal(t, 30, response.Captures[0].Amount)
+	assert.Equal(t, 70, response.Captures[1].Amount)
+}
+
+func TestVoidPayment_ReleasesRestOfPartialCapture(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	partial := authorizedPayment()
+	partial.PaymentStatus = "partially_captured"
+	partial.Captures = []models.Capture{{Id: "capture-id", Amount: 30}}
+
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), partial))
+
+	mockClient.EXPECT().VoidBankPayment(gomock.Any(), gomock.Any()).Return(&models.VoidBankResponse{Voided: true}, nil)
+	mockClient.EXPECT().RefundBankPayment(gomock.Any(), &models.RefundBankRequest{
+		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+		Amount:            30,
+	}).Return(&models.RefundBankResponse{Refunded: true}, nil)
+
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	response, err := domain.VoidPayment(context.Background(), "test-id")
+	require.NoError(t, err)
+	assert.Equal(t, "captured", response.PaymentStatus)
+
+	// only what was captured can be refunded
+	response, err = domain.RefundPayment(context.Background(), "test-id", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "refunded", response.PaymentStatus)
+}
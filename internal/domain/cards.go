@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/google/uuid"
+)
+
+/*
+Customers can keep a card on file so later payments, including ones the merchant starts on its own such as a subscription renewal, do not need the card details again.  StoreCard checks the card and hands back a token, and a payment made with that token says in its stored_credential who initiated it so the acquiring bank can tell customer-initiated from merchant-initiated transactions.
+
+The CVV is never stored, so a payment with a token only carries one when the customer has just given it.
+*/
+
+// StoreCard keeps the card on file for the customer and returns the token that payments can use in its place.
+func (p *PaymentServiceImpl) StoreCard(ctx context.Context, customerID string, request *models.StoreCardRequest) (*models.StoredCard, error) {
+	token := uuid.New().String()
+
+	err := validateCardNumber(strconv.Itoa(request.CardNumber), token)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = validateExpiryDate(request.ExpiryMonth, request.ExpiryYear, token)
+	if err != nil {
+		return nil, err
+	}
+
+	card := models.StoredCard{
+		Token:              token,
+		CustomerId:         customerID,
+		CardNumberLastFour: request.CardNumber % 10000,
+		ExpiryMonth:        request.ExpiryMonth,
+		ExpiryYear:         request.ExpiryYear,
+		CreatedAt:          time.Now().UTC(),
+		CardNumber:         request.CardNumber,
+	}
+	if err := p.cards.AddCard(ctx, card); err != nil {
+		return nil, err
+	}
+
+	return &card, nil
+}
+
+// resolveCardToken fills in the details of the stored card a payment request refers to, requests without a token are returned as they are.
+func (p *PaymentServiceImpl) resolveCardToken(ctx context.Context, request *models.PostPaymentHandlerRequest, id string) (*models.PostPaymentHandlerRequest, error) {
+	if request.CardToken == "" {
+		return request, nil
+	}
+
+	if request.CardNumber != 0 || request.ExpiryMonth != 0 || request.ExpiryYear != 0 {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("card_token cannot be combined with card details"),
+			id,
+			"card_token",
+		)
+	}
+
+	if request.StoredCredential == nil {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("payments with a card_token must say who initiated them in stored_credential"),
+			id,
+			"stored_credential",
+		)
+	}
+
+	card, err := p.cards.GetCard(ctx, request.CardToken)
+	if err != nil {
+		return nil, err
+	}
+	if card == nil {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("unknown card_token"),
+			id,
+			"card_token",
+		)
+	}
+
+	resolved := *request
+	resolved.CardNumber = card.CardNumber
+	resolved.ExpiryMonth = card.ExpiryMonth
+	resolved.ExpiryYear = card.ExpiryYear
+	return &resolved, nil
+}
@@ -0,0 +1,124 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStoreCard_RecurringPaymentWithToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), mockClient, events.NewInMemoryBus())
+
+	card, err := domain.StoreCard(context.Background(), "customer-id", &models.StoreCardRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, card.Token)
+	assert.Equal(t, "customer-id", card.CustomerId)
+	assert.Equal(t, 8877, card.CardNumberLastFour)
+
+	storedCredential := &models.StoredCredential{
+		Initiator:             "merchant",
+		Reason:                "recurring",
+		OriginalTransactionId: "original-id",
+	}
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), &models.PostPaymentBankRequest{
+		CardNumber:       "2222405343248877",
+		ExpiryDate:       "4/2035",
+		Currency:         "GBP",
+		Amount:           100,
+		StoredCredential: storedCredential,
+	}).Return(&models.PostPaymentBankResponse{
+		Authorised:        true,
+		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+	}, nil)
+
+	response, err := domain.Create(context.Background(), &models.PostPaymentHandlerRequest{
+		CardToken:        card.Token,
+		Currency:         "GBP",
+		Amount:           100,
+		StoredCredential: storedCredential,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "authorized", response.PaymentStatus)
+	assert.Equal(t, card.Token, response.CardToken)
+	assert.Equal(t, 8877, response.CardNumberLastFour)
+	assert.Equal(t, 4, response.ExpiryMonth)
+	assert.Equal(t, 2035, response.ExpiryYear)
+}
+
+func TestStoreCard_InvalidCard(t *testing.T) {
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+
+	card, err := domain.StoreCard(context.Background(), "customer-id", &models.StoreCardRequest{
+		CardNumber:  1234,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+	})
+	require.Nil(t, card)
+
+	var validationErr *gatewayerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "card_number", validationErr.Field)
+}
+
+func TestPostPayment_InvalidCardToken(t *testing.T) {
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+
+	card, err := domain.StoreCard(context.Background(), "customer-id", &models.StoreCardRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+	})
+	require.NoError(t, err)
+
+	customerInitiated := &models.StoredCredential{Initiator: "customer"}
+
+	tests := []struct {
+		name          string
+		request       models.PostPaymentHandlerRequest
+		expectedField string
+	}{
+		{
+			name:          "UnknownToken",
+			request:       models.PostPaymentHandlerRequest{CardToken: "unknown", Currency: "GBP", Amount: 100, StoredCredential: customerInitiated},
+			expectedField: "card_token",
+		},
+		{
+			name:          "TokenWithCardDetails",
+			request:       models.PostPaymentHandlerRequest{CardToken: card.Token, CardNumber: 2222405343248877, Currency: "GBP", Amount: 100, StoredCredential: customerInitiated},
+			expectedField: "card_token",
+		},
+		{
+			name:          "TokenWithoutStoredCredential",
+			request:       models.PostPaymentHandlerRequest{CardToken: card.Token, Currency: "GBP", Amount: 100},
+			expectedField: "stored_credential",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := domain.Create(context.Background(), &tt.request)
+			require.Nil(t, response)
+
+			var validationErr *gatewayerrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.expectedField, validationErr.Field)
+		})
+	}
+}
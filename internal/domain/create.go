@@ -1,14 +1,18 @@
 package domain
 
 import (
+	"context"
 	"errors"
 	"strconv"
 	"time"
 
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/scheme"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/wallet"
 
 	"github.com/google/uuid"
 )
@@ -24,96 +28,298 @@ func NewDomain(paymentService PaymentService) *Domain {
 }
 
 type PaymentService interface {
-	Create(request *models.PostPaymentHandlerRequest) (*models.PostPaymentResponse, error)
+	Create(ctx context.Context, request *models.PostPaymentHandlerRequest) (*models.PostPaymentResponse, error)
+	Submit(ctx context.Context, request *models.PostPaymentHandlerRequest) (*models.PostPaymentResponse, error)
+	CapturePayment(ctx context.Context, id string, amount int) (*models.PostPaymentResponse, error)
+	VoidPayment(ctx context.Context, id string) (*models.PostPaymentResponse, error)
+	RefundPayment(ctx context.Context, id string, amount int) (*models.PostPaymentResponse, error)
+	StoreCard(ctx context.Context, customerID string, request *models.StoreCardRequest) (*models.StoredCard, error)
+	CreatePaymentIntent(ctx context.Context, request *models.CreatePaymentIntentRequest) (*models.PaymentIntent, error)
+	GetPaymentIntent(ctx context.Context, id string) (*models.PaymentIntent, error)
+	ConfirmPaymentIntent(ctx context.Context, id string, request *models.ConfirmPaymentIntentRequest) (*models.PaymentIntent, error)
+	VerifyCard(ctx context.Context, request *models.CardVerificationRequest) (*models.CardVerification, error)
+	OpenDispute(ctx context.Context, request *models.OpenDisputeRequest) (*models.Dispute, error)
+	SubmitDisputeEvidence(ctx context.Context, id string, evidence string) (*models.Dispute, error)
+	ResolveDispute(ctx context.Context, id string, outcome string) (*models.Dispute, error)
+	ListDisputes(ctx context.Context, paymentID string) ([]models.Dispute, error)
+	GetDisputeEvidenceBundle(ctx context.Context, id string) (*models.EvidenceBundle, error)
+	RetryPayment(ctx context.Context, id string, cvv int) (*models.PostPaymentResponse, error)
+	ReversePayment(ctx context.Context, id string) (*models.PostPaymentResponse, error)
+	CancelPayment(ctx context.Context, id string) (*models.PostPaymentResponse, error)
+	CreatePaymentLink(ctx context.Context, request *models.CreatePaymentLinkRequest) (*models.PaymentLink, error)
+	GetPaymentLink(ctx context.Context, id string) (*models.PaymentLink, error)
+	PayPaymentLink(ctx context.Context, id string, request *models.PayPaymentLinkRequest) (*models.PostPaymentResponse, error)
+	CreateInstallmentPlan(ctx context.Context, request *models.CreateInstallmentPlanRequest) (*models.InstallmentPlan, error)
+	GetInstallmentPlan(ctx context.Context, id string) (*models.InstallmentPlan, error)
+	CancelInstallmentPlan(ctx context.Context, id string) (*models.InstallmentPlan, error)
 }
 
 type PaymentServiceImpl struct {
 	repo               *repository.PaymentsRepository
 	PostPaymentService PaymentService
 	client             client.Client
+	bus                events.Bus
+	locks              paymentLocks
+	cards              *repository.CardsRepository
+	intents            *repository.IntentsRepository
+	disputes           *repository.DisputesRepository
+	links              *repository.LinksRepository
+	plans              *repository.PlansRepository
+	held               *heldRequests
+	scheduled          scheduledPayments
+	queue              chan pendingPayment
+	asyncWorkers       int
+	// avsDecline holds the AVS result codes the gateway declines on
+	avsDecline map[string]bool
+	// validity overrides DefaultAuthorizationValidity for every scheme when it is set
+	validity time.Duration
+	// methods holds the processor for each payment method type
+	methods map[string]paymentMethodProcessor
+	// wallets holds the decrypter for each wallet type the gateway accepts
+	wallets map[string]wallet.Decrypter
 }
 
-func NewPaymentServiceImpl(repo *repository.PaymentsRepository, client client.Client) *PaymentServiceImpl {
-	return &PaymentServiceImpl{
-		repo:   repo,
-		client: client,
+func NewPaymentServiceImpl(repo *repository.PaymentsRepository, client client.Client, bus events.Bus) *PaymentServiceImpl {
+	p := &PaymentServiceImpl{
+		repo:     repo,
+		client:   client,
+		bus:      bus,
+		cards:    repository.NewCardsRepository(),
+		intents:  repository.NewIntentsRepository(),
+		disputes: repository.NewDisputesRepository(),
+		links:    repository.NewLinksRepository(),
+		plans:    repository.NewPlansRepository(),
+		held:     &heldRequests{},
+		queue:    make(chan pendingPayment, asyncQueueSize),
 	}
+	p.methods = map[string]paymentMethodProcessor{
+		paymentMethodCard:         cardMethod{p},
+		paymentMethodNetworkToken: networkTokenMethod{},
+		paymentMethodWallet:       walletMethod{p},
+	}
+	return p
 }
 
-func (p *PaymentServiceImpl) Create(request *models.PostPaymentHandlerRequest) (*models.PostPaymentResponse, error) {
+func (p *PaymentServiceImpl) Create(ctx context.Context, request *models.PostPaymentHandlerRequest) (*models.PostPaymentResponse, error) {
+	return p.create(ctx, request, "")
+}
 
-	uuid := uuid.New().String()
-	err := validateCardNumber(strconv.Itoa(request.CardNumber), uuid)
+// create makes a payment, retryOf names the declined payment it retries if there is one.
+func (p *PaymentServiceImpl) create(ctx context.Context, request *models.PostPaymentHandlerRequest, retryOf string) (*models.PostPaymentResponse, error) {
+	pending, err := p.prepare(ctx, request, retryOf)
 	if err != nil {
 		return nil, err
 	}
-	cardNumber := strconv.Itoa(request.CardNumber)
+
+	if request.ExecuteAt != nil {
+		return p.schedule(ctx, pending, *request.ExecuteAt)
+	}
+
+	// no point calling the bank if the caller has already given up
+	if err := ctx.Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, gatewayerrors.NewTimeoutError(err, false)
+		}
+		return nil, err
+	}
+
+	return p.authorize(ctx, pending, false)
+}
+
+// pendingPayment is a validated payment that has not been sent to the bank yet.
+type pendingPayment struct {
+	id string
+	// submitted is the request as the merchant sent it, held for a retry if the payment is declined
+	submitted *models.PostPaymentHandlerRequest
+	// request has any stored card or wallet token filled in
+	request       *models.PostPaymentHandlerRequest
+	authorization authorization
+	retryOf       string
+}
+
+// prepare fills in any stored card or wallet token, validates the request and builds what is sent to the bank.
+func (p *PaymentServiceImpl) prepare(ctx context.Context, request *models.PostPaymentHandlerRequest, retryOf string) (pendingPayment, error) {
+	uuid := uuid.New().String()
+	submitted := request
+
+	method, err := p.paymentMethod(request, uuid)
+	if err != nil {
+		return pendingPayment{}, err
+	}
+
+	request, err = method.resolve(ctx, request, uuid)
+	if err != nil {
+		return pendingPayment{}, err
+	}
 
 	expiryDate, err := validateExpiryDate(request.ExpiryMonth, request.ExpiryYear, uuid)
 	if err != nil {
-		return nil, err
+		return pendingPayment{}, err
+	}
+
+	authorization, err := method.authorization(request, expiryDate, uuid)
+	if err != nil {
+		return pendingPayment{}, err
 	}
 
 	err = validateCurrencyISO(request.Currency, uuid)
 	if err != nil {
-		return nil, err
+		return pendingPayment{}, err
 	}
 
 	err = validateAmount(request.Amount, uuid)
 	if err != nil {
-		return nil, err
+		return pendingPayment{}, err
 	}
 
-	err = validateCVV(request.Cvv, uuid)
+	err = validateStoredCredential(request.StoredCredential, uuid)
 	if err != nil {
-		return nil, err
+		return pendingPayment{}, err
 	}
 
-	cvvString := strconv.Itoa(request.Cvv)
-
-	PostPaymentBankRequest := &models.PostPaymentBankRequest{
-		CardNumber: cardNumber,
-		ExpiryDate: expiryDate,
-		Currency:   request.Currency,
-		Amount:     request.Amount,
-		CVV:        cvvString,
+	err = validateBillingAddress(request.BillingAddress, uuid)
+	if err != nil {
+		return pendingPayment{}, err
 	}
 
-	bankResponse, err := p.client.PostBankPayment(PostPaymentBankRequest)
+	err = validateExecuteAt(request.ExecuteAt, uuid, time.Now())
 	if err != nil {
-		return nil, err
+		return pendingPayment{}, err
+	}
+
+	if request.Capture && request.Amount == 0 {
+		return pendingPayment{}, gatewayerrors.NewValidationError(
+			errors.New("verifications cannot be captured"),
+			uuid,
+			"capture",
+		)
 	}
 
-	cardNumberLastFour, err := strconv.Atoi(getLastFourCharacters(cardNumber))
+	return pendingPayment{
+		id:            uuid,
+		submitted:     submitted,
+		request:       request,
+		authorization: authorization,
+		retryOf:       retryOf,
+	}, nil
+}
+
+// authorize sends a prepared payment to the bank and records the outcome.  processing is true when the payment was
+// already stored as processing by Submit, and is updated rather than added.
+func (p *PaymentServiceImpl) authorize(ctx context.Context, pending pendingPayment, processing bool) (*models.PostPaymentResponse, error) {
+	uuid, request := pending.id, pending.request
+
+	bankResponse, err := pending.authorization.send(ctx, p.client)
 	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, gatewayerrors.NewTimeoutError(err, true)
+		}
 		return nil, err
 	}
 
-	paymentStatus := "declined"
-	if bankResponse.Authorised {
-		paymentStatus = "authorized"
+	cardNumberLastFour := lastFour(request)
+
+	paymentStatus := StatusDeclined
+	eventType := events.PaymentDeclined
+	var expiresAt *time.Time
+	var declineReason *models.DeclineReason
+	var capturedAt *time.Time
+	switch {
+	case bankResponse.Authorised && p.avsDeclines(bankResponse.AVSResult):
+		p.releaseAVSMismatch(ctx, uuid, bankResponse)
+		declineReason = avsDeclineReason(bankResponse.AVSResult)
+	case bankResponse.Authorised && request.Capture:
+		// the bank authorized and captured in one go, there is no hold left to expire
+		paymentStatus = StatusCaptured
+		eventType = events.PaymentCaptured
+		now := time.Now().UTC()
+		capturedAt = &now
+	case bankResponse.Authorised && request.Amount == 0:
+		// a zero amount authorization only checks the card is good, no money is held so it is never settled
+		paymentStatus = StatusVerified
+		eventType = events.PaymentVerified
+	case bankResponse.Authorised:
+		paymentStatus = StatusAuthorized
+		eventType = events.PaymentAuthorized
+		expiresAt = p.authorizationExpiry(pending.authorization.number(), time.Now())
+	case bankResponse.DeclineCode != "":
+		declineReason = &models.DeclineReason{
+			Code:    bankResponse.DeclineCode,
+			Message: bankResponse.DeclineMessage,
+		}
 	}
 
 	paymentResponse := &models.PostPaymentResponse{
 		Id:                 uuid,
-		PaymentStatus:      paymentStatus,
+		PaymentStatus:      string(paymentStatus),
 		CardNumberLastFour: cardNumberLastFour,
 		ExpiryMonth:        request.ExpiryMonth,
 		ExpiryYear:         request.ExpiryYear,
 		Currency:           request.Currency,
 		Amount:             request.Amount,
+		ExpiresAt:          expiresAt,
+		StoredCredential:   request.StoredCredential,
+		AuthorizationCode:  bankResponse.AuthorizationCode,
+		CaptureMethod:      captureMethodDelayed,
+		CardToken:          request.CardToken,
+		AVSResult:          bankResponse.AVSResult,
+		RetryOf:            pending.retryOf,
+		DeclineReason:      declineReason,
+		CapturedAt:         capturedAt,
+		PaymentMethod:      recordedPaymentMethod(request),
+	}
+	if request.Capture {
+		paymentResponse.CaptureMethod = captureMethodImmediate
+	}
+	if request.ExecuteAt != nil {
+		// a scheduled payment keeps the time it was scheduled for once it is sent
+		executeAt := request.ExecuteAt.UTC()
+		paymentResponse.ExecuteAt = &executeAt
 	}
 
-	p.repo.AddPayment(*paymentResponse)
+	// the bank has authorized or declined by now so the record must be kept even if the caller's budget is spent
+	store := p.repo.AddPayment
+	if processing {
+		store = p.repo.UpdatePayment
+	}
+	if err := store(context.WithoutCancel(ctx), *paymentResponse); err != nil {
+		return nil, err
+	}
+	// network tokens and wallets are paid with a cryptogram that is only good once, so only cards can be retried
+	if paymentStatus == StatusDeclined && recordedPaymentMethod(request) == "" {
+		p.held.hold(uuid, *pending.submitted, time.Now())
+	}
+	p.bus.Publish(events.NewEvent(eventType, *paymentResponse))
 
 	return paymentResponse, nil
 }
 
-func getLastFourCharacters(s string) string {
-	if len(s) < 4 {
-		return s
+const (
+	captureMethodImmediate = "immediate"
+	captureMethodDelayed   = "delayed"
+)
+
+// DefaultAuthorizationValidity is how long each scheme lets an authorization hold funds before the issuer may release them.
+var DefaultAuthorizationValidity = map[scheme.Scheme]time.Duration{
+	scheme.Visa:       7 * 24 * time.Hour,
+	scheme.Mastercard: 30 * 24 * time.Hour,
+	scheme.Amex:       7 * 24 * time.Hour,
+	scheme.Discover:   10 * 24 * time.Hour,
+	scheme.Unknown:    7 * 24 * time.Hour,
+}
+
+// SetAuthorizationValidity makes new authorizations expire after validity whatever their scheme, zero goes back to DefaultAuthorizationValidity.
+func (p *PaymentServiceImpl) SetAuthorizationValidity(validity time.Duration) {
+	p.validity = validity
+}
+
+func (p *PaymentServiceImpl) authorizationExpiry(cardNumber string, authorizedAt time.Time) *time.Time {
+	validity := p.validity
+	if validity == 0 {
+		validity = DefaultAuthorizationValidity[scheme.Detect(cardNumber)]
 	}
-	return s[len(s)-4:]
+	expiresAt := authorizedAt.UTC().Add(validity)
+	return &expiresAt
 }
 
 func validateCardNumber(cardNumber, id string) error {
@@ -133,7 +339,7 @@ func validateExpiryDate(requestMonth, requestYear int, id string) (string, error
 	month := int(now.Month())
 	year := now.Year()
 
-	if requestMonth < month || requestMonth > 12 || requestMonth < 1 {
+	if requestMonth > 12 || requestMonth < 1 {
 		return "", gatewayerrors.NewValidationError(
 			errors.New("invalid expiry month"),
 			id,
@@ -149,7 +355,7 @@ func validateExpiryDate(requestMonth, requestYear int, id string) (string, error
 		)
 	}
 
-	if requestMonth < month {
+	if requestYear == year && requestMonth < month {
 		return "", gatewayerrors.NewValidationError(
 			errors.New("month in past"),
 			id,
@@ -157,7 +363,12 @@ func validateExpiryDate(requestMonth, requestYear int, id string) (string, error
 		)
 	}
 
-	return strconv.Itoa(requestMonth) + "/" + strconv.Itoa(requestYear), nil
+	// built in one buffer, this runs on every payment
+	expiryDate := make([]byte, 0, len("12/2035"))
+	expiryDate = strconv.AppendInt(expiryDate, int64(requestMonth), 10)
+	expiryDate = append(expiryDate, '/')
+	expiryDate = strconv.AppendInt(expiryDate, int64(requestYear), 10)
+	return string(expiryDate), nil
 }
 
 var validCurrencyCodes = map[string]bool{
@@ -178,8 +389,9 @@ func validateCurrencyISO(currency, id string) error {
 	return nil
 }
 
+// validateAmount allows zero, which the acquirer treats as an account verification rather than a payment.
 func validateAmount(amount int, id string) error {
-	if amount <= 0 {
+	if amount < 0 {
 		return gatewayerrors.NewValidationError(
 			errors.New("invalid amount"),
 			id,
@@ -200,3 +412,52 @@ func validateCVV(cvv int, id string) error {
 
 	return nil
 }
+
+var validStoredCredentialReasons = map[string]bool{
+	"recurring":   true,
+	"installment": true,
+	"unscheduled": true,
+}
+
+// validateStoredCredential checks the stored credential framework fields, a merchant-initiated payment must say why
+// it is being taken and which customer-initiated transaction agreed to it.
+func validateStoredCredential(storedCredential *models.StoredCredential, id string) error {
+	if storedCredential == nil {
+		return nil
+	}
+
+	switch storedCredential.Initiator {
+	case "customer":
+	case "merchant":
+		if storedCredential.Reason == "" {
+			return gatewayerrors.NewValidationError(
+				errors.New("merchant-initiated payments need a reason"),
+				id,
+				"stored_credential.reason",
+			)
+		}
+		if storedCredential.OriginalTransactionId == "" {
+			return gatewayerrors.NewValidationError(
+				errors.New("merchant-initiated payments need the original transaction"),
+				id,
+				"stored_credential.original_transaction_id",
+			)
+		}
+	default:
+		return gatewayerrors.NewValidationError(
+			errors.New("invalid initiator"),
+			id,
+			"stored_credential.initiator",
+		)
+	}
+
+	if storedCredential.Reason != "" && !validStoredCredentialReasons[storedCredential.Reason] {
+		return gatewayerrors.NewValidationError(
+			errors.New("invalid stored credential reason"),
+			id,
+			"stored_credential.reason",
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+)
+
+// maxCreateAllocs guards the create hot path against allocation regressions, raise it only with a reason.
+const maxCreateAllocs = 10
+
+// authorizingClient answers every payment with the same authorization so benchmarks measure the domain and not the bank.
+type authorizingClient struct {
+	client.Client
+}
+
+var authorizedResponse = &models.PostPaymentBankResponse{Authorised: true, AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2"}
+
+func (authorizingClient) PostBankPayment(context.Context, *models.PostPaymentBankRequest) (*models.PostPaymentBankResponse, error) {
+	return authorizedResponse, nil
+}
+
+var benchmarkRequest = models.PostPaymentHandlerRequest{
+	CardNumber:  2222405343248877,
+	ExpiryMonth: 4,
+	ExpiryYear:  2035,
+	Currency:    "GBP",
+	Amount:      100,
+	Cvv:         123,
+}
+
+func TestCreate_Allocations(t *testing.T) {
+	service := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), authorizingClient{}, events.NewInMemoryBus())
+	request := benchmarkRequest
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := service.Create(context.Background(), &request); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if allocs > maxCreateAllocs {
+		t.Fatalf("Create allocated %.0f times per call, want at most %d", allocs, maxCreateAllocs)
+	}
+}
+
+func BenchmarkCreate(b *testing.B) {
+	service := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), authorizingClient{}, events.NewInMemoryBus())
+	request := benchmarkRequest
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := service.Create(ctx, &request); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,11 +1,15 @@
 package domain_test
 
 import (
+	"context"
+	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
@@ -23,15 +27,15 @@ func TestPostPayment_Authorized(t *testing.T) {
 	postPayment := models.PostPaymentHandlerRequest{
 		CardNumber:  2222405343248877,
 		ExpiryMonth: 4,
-		ExpiryYear:  2025,
+		ExpiryYear:  2035,
 		Currency:    "GBP",
 		Amount:      100,
 		Cvv:         123,
 	}
 
-	mockClient.EXPECT().PostBankPayment((&models.PostPaymentBankRequest{
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), (&models.PostPaymentBankRequest{
 		CardNumber: "2222405343248877",
-		ExpiryDate: "4/2025",
+		ExpiryDate: "4/2035",
 		Currency:   "GBP",
 		Amount:     100,
 		CVV:        "123",
@@ -41,9 +45,9 @@ func TestPostPayment_Authorized(t *testing.T) {
 	}), nil)
 
 	repo := repository.NewPaymentsRepository()
-	domain := domain.NewPaymentServiceImpl(repo, mockClient)
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
 
-	response, err := domain.Create(&postPayment)
+	response, err := domain.Create(context.Background(), &postPayment)
 	require.NoError(t, err)
 
 	_, err = uuid.Parse(response.Id)
@@ -60,24 +64,318 @@ func TestPostPayment_Authorized(t *testing.T) {
 	assert.Equal(t, postPayment.Amount, response.Amount)
 
 	// Check if the payment was saved in the repository
-	dbPayment := repo.GetPayment(response.Id)
+	dbPayment, err := repo.GetPayment(context.Background(), response.Id)
+	require.NoError(t, err)
 	assert.Equal(t, response.Id, dbPayment.Id)
 }
 
+func TestPostPayment_PublishesEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	postPayment := models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+	}
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return((&models.PostPaymentBankResponse{
+		Authorised:        true,
+		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+	}), nil)
+
+	bus := events.NewInMemoryBus()
+	var published []events.Event
+	bus.Subscribe(events.PaymentAuthorized, func(event events.Event) {
+		published = append(published, event)
+	})
+
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), mockClient, bus)
+
+	response, err := domain.Create(context.Background(), &postPayment)
+	require.NoError(t, err)
+
+	require.Len(t, published, 1)
+	assert.Equal(t, *response, published[0].Payment)
+}
+
+func TestPostPayment_AuthorizationExpiresPerScheme(t *testing.T) {
+	tests := []struct {
+		name       string
+		cardNumber int
+		validity   time.Duration
+	}{
+		{name: "visa", cardNumber: 4111111111111111, validity: 7 * 24 * time.Hour},
+		{name: "mastercard", cardNumber: 2222405343248877, validity: 30 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockClient := mocks.NewMockClient(ctrl)
+
+			postPayment := models.PostPaymentHandlerRequest{
+				CardNumber:  tt.cardNumber,
+				ExpiryMonth: 4,
+				ExpiryYear:  2035,
+				Currency:    "GBP",
+				Amount:      100,
+				Cvv:         123,
+			}
+
+			mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return((&models.PostPaymentBankResponse{
+				Authorised:        true,
+				AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+			}), nil)
+
+			domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), mockClient, events.NewInMemoryBus())
+
+			before := time.Now()
+			response, err := domain.Create(context.Background(), &postPayment)
+			require.NoError(t, err)
+
+			require.NotNil(t, response.ExpiresAt)
+			assert.WithinRange(t, *response.ExpiresAt, before.Add(tt.validity), time.Now().Add(tt.validity))
+		})
+	}
+}
+
+func TestPostPayment_ZeroAmountVerified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	postPayment := models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      0,
+		Cvv:         123,
+	}
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), (&models.PostPaymentBankRequest{
+		CardNumber: "2222405343248877",
+		ExpiryDate: "4/2035",
+		Currency:   "GBP",
+		Amount:     0,
+		CVV:        "123",
+	})).Return((&models.PostPaymentBankResponse{
+		Authorised:        true,
+		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+	}), nil)
+
+	bus := events.NewInMemoryBus()
+	var published []events.Event
+	bus.Subscribe(events.PaymentVerified, func(event events.Event) {
+		published = append(published, event)
+	})
+
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), mockClient, bus)
+
+	response, err := domain.Create(context.Background(), &postPayment)
+	require.NoError(t, err)
+
+	assert.Equal(t, "verified", response.PaymentStatus)
+	assert.Equal(t, 0, response.Amount)
+	require.Len(t, published, 1)
+	assert.Equal(t, *response, published[0].Payment)
+}
+
+func TestPostPayment_ImmediateCapture(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	postPayment := models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+		Capture:     true,
+	}
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), (&models.PostPaymentBankRequest{
+		CardNumber: "2222405343248877",
+		ExpiryDate: "4/2035",
+		Currency:   "GBP",
+		Amount:     100,
+		CVV:        "123",
+		Capture:    true,
+	})).Return((&models.PostPaymentBankResponse{
+		Authorised:        true,
+		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+	}), nil)
+
+	bus := events.NewInMemoryBus()
+	var published []events.Event
+	bus.Subscribe(events.PaymentCaptured, func(event events.Event) {
+		published = append(published, event)
+	})
+
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), mockClient, bus)
+
+	response, err := domain.Create(context.Background(), &postPayment)
+	require.NoError(t, err)
+
+	assert.Equal(t, "captured", response.PaymentStatus)
+	assert.Equal(t, "immediate", response.CaptureMethod)
+	assert.Nil(t, response.ExpiresAt)
+	require.Len(t, published, 1)
+	assert.Equal(t, *response, published[0].Payment)
+
+	_, err = domain.CapturePayment(context.Background(), response.Id, 0)
+	var stateErr *gatewayerrors.StateError
+	require.ErrorAs(t, err, &stateErr)
+}
+
+func TestPostPayment_ZeroAmountCannotCapture(t *testing.T) {
+	postPayment := models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      0,
+		Cvv:         123,
+		Capture:     true,
+	}
+
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+
+	response, err := domain.Create(context.Background(), &postPayment)
+	require.Nil(t, response)
+
+	var validationErr *gatewayerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "capture", validationErr.Field)
+}
+
+func TestPostPayment_DeadlineExceededBeforeBankCall(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	postPayment := models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), mockClient, events.NewInMemoryBus())
+
+	response, err := domain.Create(ctx, &postPayment)
+	require.Nil(t, response)
+
+	var timeoutErr *gatewayerrors.TimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.False(t, timeoutErr.BankCalled)
+}
+
+func TestPostPayment_DeadlineExceededDuringBankCall(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	postPayment := models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+	}
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("failed to make POST request: %w", context.DeadlineExceeded))
+
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), mockClient, events.NewInMemoryBus())
+
+	response, err := domain.Create(context.Background(), &postPayment)
+	require.Nil(t, response)
+
+	var timeoutErr *gatewayerrors.TimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.True(t, timeoutErr.BankCalled)
+}
+
+func TestPostPayment_FutureYearEarlierMonth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	// January next year is always in the future even though the month is not after the current one
+	postPayment := models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 1,
+		ExpiryYear:  time.Now().Year() + 1,
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+	}
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return((&models.PostPaymentBankResponse{
+		Authorised:        true,
+		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+	}), nil)
+
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), mockClient, events.NewInMemoryBus())
+
+	response, err := domain.Create(context.Background(), &postPayment)
+	require.NoError(t, err)
+	assert.Equal(t, "authorized", response.PaymentStatus)
+}
+
+func TestPostPayment_CurrentYearPastMonth(t *testing.T) {
+	now := time.Now()
+	if now.Month() == time.January {
+		t.Skip("no month earlier than the current one this year")
+	}
+
+	postPayment := models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: int(now.Month()) - 1,
+		ExpiryYear:  now.Year(),
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+	}
+
+	domain := domain.NewPaymentServiceImpl(nil, nil, nil)
+
+	var validationError *gatewayerrors.ValidationError
+	response, err := domain.Create(context.Background(), &postPayment)
+	require.Nil(t, response)
+	require.ErrorAs(t, err, &validationError)
+	assert.Equal(t, "month in past", validationError.Error())
+	assert.Equal(t, "expiry_month", validationError.GetFieldError())
+}
+
 func TestPostPayment_InvalidCardNumber(t *testing.T) {
 	postPayment := models.PostPaymentHandlerRequest{
 		CardNumber:  123,
 		ExpiryMonth: 4,
-		ExpiryYear:  2025,
+		ExpiryYear:  2035,
 		Currency:    "GBP",
 		Amount:      100,
 		Cvv:         123,
 	}
 
-	domain := domain.NewPaymentServiceImpl(nil, nil)
+	domain := domain.NewPaymentServiceImpl(nil, nil, nil)
 
 	var validationError *gatewayerrors.ValidationError
-	response, err := domain.Create(&postPayment)
+	response, err := domain.Create(context.Background(), &postPayment)
 	require.Nil(t, response)
 	require.Error(t, err)
 	require.ErrorAs(t, err, &validationError)
@@ -99,10 +397,10 @@ func TestPostPayment_InvalidExpiryDate(t *testing.T) {
 		Cvv:         123,
 	}
 
-	domain := domain.NewPaymentServiceImpl(nil, nil)
+	domain := domain.NewPaymentServiceImpl(nil, nil, nil)
 
 	var validationError *gatewayerrors.ValidationError
-	response, err := domain.Create(&postPayment)
+	response, err := domain.Create(context.Background(), &postPayment)
 	require.Nil(t, response)
 	require.Error(t, err)
 	require.ErrorAs(t, err, &validationError)
@@ -118,16 +416,16 @@ func TestPostPayment_InvalidCurrency(t *testing.T) {
 	postPayment := models.PostPaymentHandlerRequest{
 		CardNumber:  2222405343248877,
 		ExpiryMonth: 4,
-		ExpiryYear:  2025,
+		ExpiryYear:  2035,
 		Currency:    "invalid_currency",
 		Amount:      100,
 		Cvv:         123,
 	}
 
-	domain := domain.NewPaymentServiceImpl(nil, nil)
+	domain := domain.NewPaymentServiceImpl(nil, nil, nil)
 
 	var validationError *gatewayerrors.ValidationError
-	response, err := domain.Create(&postPayment)
+	response, err := domain.Create(context.Background(), &postPayment)
 	require.Nil(t, response)
 	require.Error(t, err)
 	require.ErrorAs(t, err, &validationError)
@@ -143,16 +441,16 @@ func TestPostPayment_InvalidCVV(t *testing.T) {
 	postPayment := models.PostPaymentHandlerRequest{
 		CardNumber:  2222405343248877,
 		ExpiryMonth: 4,
-		ExpiryYear:  2025,
+		ExpiryYear:  2035,
 		Currency:    "GBP",
 		Amount:      100,
 		Cvv:         1,
 	}
 
-	domain := domain.NewPaymentServiceImpl(nil, nil)
+	domain := domain.NewPaymentServiceImpl(nil, nil, nil)
 
 	var validationError *gatewayerrors.ValidationError
-	response, err := domain.Create(&postPayment)
+	response, err := domain.Create(context.Background(), &postPayment)
 	require.Nil(t, response)
 	require.Error(t, err)
 	require.ErrorAs(t, err, &validationError)
@@ -168,16 +466,16 @@ func TestPostPayment_InvalidAmount(t *testing.T) {
 	postPayment := models.PostPaymentHandlerRequest{
 		CardNumber:  2222405343248877,
 		ExpiryMonth: 4,
-		ExpiryYear:  2025,
+		ExpiryYear:  2035,
 		Currency:    "GBP",
 		Amount:      -1,
 		Cvv:         123,
 	}
 
-	domain := domain.NewPaymentServiceImpl(nil, nil)
+	domain := domain.NewPaymentServiceImpl(nil, nil, nil)
 
 	var validationError *gatewayerrors.ValidationError
-	response, err := domain.Create(&postPayment)
+	response, err := domain.Create(context.Background(), &postPayment)
 	require.Nil(t, response)
 	require.Error(t, err)
 	require.ErrorAs(t, err, &validationError)
@@ -196,15 +494,15 @@ func TestPostPayment_NotAuthorized(t *testing.T) {
 	postPayment := models.PostPaymentHandlerRequest{
 		CardNumber:  2222405343248877,
 		ExpiryMonth: 4,
-		ExpiryYear:  2025,
+		ExpiryYear:  2035,
 		Currency:    "GBP",
 		Amount:      100,
 		Cvv:         123,
 	}
 
-	mockClient.EXPECT().PostBankPayment((&models.PostPaymentBankRequest{
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), (&models.PostPaymentBankRequest{
 		CardNumber: "2222405343248877",
-		ExpiryDate: "4/2025",
+		ExpiryDate: "4/2035",
 		Currency:   "GBP",
 		Amount:     100,
 		CVV:        "123",
@@ -212,12 +510,14 @@ func TestPostPayment_NotAuthorized(t *testing.T) {
 		// test that we can handle a declined payment
 		Authorised:        false,
 		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+		DeclineCode:       "51",
+		DeclineMessage:    "Insufficient funds",
 	}), nil)
 
 	repo := repository.NewPaymentsRepository()
-	domain := domain.NewPaymentServiceImpl(repo, mockClient)
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
 
-	response, err := domain.Create(&postPayment)
+	response, err := domain.Create(context.Background(), &postPayment)
 	require.NoError(t, err)
 
 	_, err = uuid.Parse(response.Id)
@@ -227,6 +527,8 @@ func TestPostPayment_NotAuthorized(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, "declined", response.PaymentStatus)
+	assert.Equal(t, &models.DeclineReason{Code: "51", Message: "Insufficient funds"}, response.DeclineReason)
+	assert.Nil(t, response.ExpiresAt)
 	assert.Equal(t, lastFourCharacters, response.CardNumberLastFour)
 	assert.Equal(t, postPayment.ExpiryMonth, response.ExpiryMonth)
 	assert.Equal(t, postPayment.ExpiryYear, response.ExpiryYear)
@@ -234,7 +536,8 @@ func TestPostPayment_NotAuthorized(t *testing.T) {
 	assert.Equal(t, postPayment.Amount, response.Amount)
 
 	// Check if the payment was saved in the repository
-	dbPayment := repo.GetPayment(response.Id)
+	dbPayment, err := repo.GetPayment(context.Background(), response.Id)
+	require.NoError(t, err)
 	assert.Equal(t, response.Id, dbPayment.Id)
 }
 
@@ -245,3 +548,98 @@ func getLastFourCharacters(t *testing.T, i int) string {
 	require.Equal(t, 16, len(s))
 	return s[len(s)-4:]
 }
+
+func TestPostPayment_StoredCredentialForwardedAndStored(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	storedCredential := &models.StoredCredential{
+		Initiator:             "merchant",
+		Reason:                "recurring",
+		OriginalTransactionId: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+	}
+	postPayment := models.PostPaymentHandlerRequest{
+		CardNumber:       2222405343248877,
+		ExpiryMonth:      4,
+		ExpiryYear:       2035,
+		Currency:         "GBP",
+		Amount:           100,
+		Cvv:              123,
+		StoredCredential: storedCredential,
+	}
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), (&models.PostPaymentBankRequest{
+		CardNumber:       "2222405343248877",
+		ExpiryDate:       "4/2035",
+		Currency:         "GBP",
+		Amount:           100,
+		CVV:              "123",
+		StoredCredential: storedCredential,
+	})).Return((&models.PostPaymentBankResponse{
+		Authorised:        true,
+		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+	}), nil)
+
+	repo := repository.NewPaymentsRepository()
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	response, err := domain.Create(context.Background(), &postPayment)
+	require.NoError(t, err)
+	assert.Equal(t, storedCredential, response.StoredCredential)
+
+	dbPayment, err := repo.GetPayment(context.Background(), response.Id)
+	require.NoError(t, err)
+	assert.Equal(t, storedCredential, dbPayment.StoredCredential)
+}
+
+func TestPostPayment_InvalidStoredCredential(t *testing.T) {
+	tests := []struct {
+		name             string
+		storedCredential models.StoredCredential
+		field            string
+	}{
+		{
+			name:             "unknown initiator",
+			storedCredential: models.StoredCredential{Initiator: "bank"},
+			field:            "stored_credential.initiator",
+		},
+		{
+			name:             "merchant without reason",
+			storedCredential: models.StoredCredential{Initiator: "merchant", OriginalTransactionId: "abc"},
+			field:            "stored_credential.reason",
+		},
+		{
+			name:             "merchant without original transaction",
+			storedCredential: models.StoredCredential{Initiator: "merchant", Reason: "unscheduled"},
+			field:            "stored_credential.original_transaction_id",
+		},
+		{
+			name:             "unknown reason",
+			storedCredential: models.StoredCredential{Initiator: "customer", Reason: "subscription"},
+			field:            "stored_credential.reason",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			postPayment := models.PostPaymentHandlerRequest{
+				CardNumber:       2222405343248877,
+				ExpiryMonth:      4,
+				ExpiryYear:       2035,
+				Currency:         "GBP",
+				Amount:           100,
+				Cvv:              123,
+				StoredCredential: &tt.storedCredential,
+			}
+
+			domain := domain.NewPaymentServiceImpl(nil, nil, nil)
+
+			var validationError *gatewayerrors.ValidationError
+			response, err := domain.Create(context.Background(), &postPayment)
+			require.Nil(t, response)
+			require.ErrorAs(t, err, &validationError)
+			assert.Equal(t, tt.field, validationError.GetFieldError())
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/google/uuid"
+)
+
+/*
+A dispute is a chargeback the cardholder's issuer raises against a payment that took money.  The issuer's notification opens it, the merchant answers with evidence, and the issuer decides whether the merchant keeps the funds:
+
+	open -> evidence_submitted -> won | lost
+
+The issuer can also decide a dispute the merchant never answered.  The gateway has no issuer connection, so POST /api/disputes and the resolve action stand in for the notifications a real acquirer would forward.
+*/
+
+// OpenDispute records a chargeback against a payment that has captured funds.
+func (p *PaymentServiceImpl) OpenDispute(ctx context.Context, request *models.OpenDisputeRequest) (*models.Dispute, error) {
+	id := uuid.New().String()
+
+	if request.Reason == "" {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("dispute reason is required"),
+			id,
+			"reason",
+		)
+	}
+
+	if request.Amount < 0 {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("invalid dispute amount"),
+			id,
+			"amount",
+		)
+	}
+
+	unlock := p.locks.lock(request.PaymentId)
+	defer unlock()
+
+	payment, err := p.repo.GetPayment(ctx, request.PaymentId)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, gatewayerrors.ErrPaymentNotFound
+	}
+
+	if !Status(payment.PaymentStatus).settled() {
+		return nil, gatewayerrors.NewStateError(
+			fmt.Errorf("cannot dispute a %s payment", payment.PaymentStatus),
+			payment.Id,
+			payment.PaymentStatus,
+		)
+	}
+
+	amount := request.Amount
+	if amount == 0 {
+		amount = captured(payment)
+	}
+	if amount > captured(payment) {
+		return nil, gatewayerrors.NewStateError(
+			fmt.Errorf("cannot dispute %d, only %d was captured", amount, captured(payment)),
+			payment.Id,
+			payment.PaymentStatus,
+		)
+	}
+
+	now := time.Now().UTC()
+	dispute := models.Dispute{
+		Id:        id,
+		PaymentId: payment.Id,
+		Status:    "open",
+		Amount:    amount,
+		Reason:    request.Reason,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := p.disputes.AddDispute(ctx, dispute); err != nil {
+		return nil, err
+	}
+	p.bus.Publish(events.NewEvent(events.PaymentDisputed, *payment))
+
+	return &dispute, nil
+}
+
+// SubmitDisputeEvidence records the merchant's answer to an open dispute.
+func (p *PaymentServiceImpl) SubmitDisputeEvidence(ctx context.Context, id string, evidence string) (*models.Dispute, error) {
+	if evidence == "" {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("evidence is required"),
+			id,
+			"evidence",
+		)
+	}
+
+	return p.updateDispute(ctx, id, func(dispute *models.Dispute) error {
+		if dispute.Status != "open" {
+			return gatewayerrors.NewStateError(
+				fmt.Errorf("cannot submit evidence for a %s dispute", dispute.Status),
+				id,
+				dispute.Status,
+			)
+		}
+		dispute.Evidence = evidence
+		dispute.Status = "evidence_submitted"
+		return nil
+	})
+}
+
+// ResolveDispute records the issuer's decision, outcome is won or lost from the merchant's side.
+func (p *PaymentServiceImpl) ResolveDispute(ctx context.Context, id string, outcome string) (*models.Dispute, error) {
+	if outcome != "won" && outcome != "lost" {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("outcome must be won or lost"),
+			id,
+			"outcome",
+		)
+	}
+
+	return p.updateDispute(ctx, id, func(dispute *models.Dispute) error {
+		if dispute.Status != "open" && dispute.Status != "evidence_submitted" {
+			return gatewayerrors.NewStateError(
+				fmt.Errorf("cannot resolve a %s dispute", dispute.Status),
+				id,
+				dispute.Status,
+			)
+		}
+		dispute.Status = outcome
+		return nil
+	})
+}
+
+// ListDisputes returns the disputes raised against a payment, it fails with gatewayerrors.ErrPaymentNotFound if there is no such payment.
+func (p *PaymentServiceImpl) ListDisputes(ctx context.Context, paymentID string) ([]models.Dispute, error) {
+	payment, err := p.repo.GetPayment(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, gatewayerrors.ErrPaymentNotFound
+	}
+
+	return p.disputes.ListDisputes(ctx, paymentID)
+}
+
+// GetDisputeEvidenceBundle assembles the evidence the gateway holds for a dispute.  Payments only keep the last four
+// digits and expiry of their card, so a prior payment is taken to be on the same card when those match.
+func (p *PaymentServiceImpl) GetDisputeEvidenceBundle(ctx context.Context, id string) (*models.EvidenceBundle, error) {
+	dispute, err := p.disputes.GetDispute(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if dispute == nil {
+		return nil, gatewayerrors.ErrDisputeNotFound
+	}
+
+	payment, err := p.repo.GetPayment(ctx, dispute.PaymentId)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, gatewayerrors.ErrPaymentNotFound
+	}
+
+	payments, err := p.repo.ListPayments(ctx, models.PaymentFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	prior := []models.PriorPayment{}
+	for _, other := range payments {
+		// payments are listed oldest first
+		if other.Id == payment.Id {
+			break
+		}
+		if other.CardNumberLastFour != payment.CardNumberLastFour || other.ExpiryMonth != payment.ExpiryMonth ||
+			other.ExpiryYear != payment.ExpiryYear || !Status(other.PaymentStatus).settled() {
+			continue
+		}
+
+		disputes, err := p.disputes.ListDisputes(ctx, other.Id)
+		if err != nil {
+			return nil, err
+		}
+		if len(disputes) > 0 {
+			continue
+		}
+
+		prior = append(prior, models.PriorPayment{
+			PaymentId:  other.Id,
+			Status:     other.PaymentStatus,
+			Amount:     other.Amount,
+			Currency:   other.Currency,
+			CapturedAt: other.CapturedAt,
+		})
+	}
+
+	return &models.EvidenceBundle{
+		DisputeId:          dispute.Id,
+		Reason:             dispute.Reason,
+		Amount:             dispute.Amount,
+		PaymentId:          payment.Id,
+		Currency:           payment.Currency,
+		CardNumberLastFour: payment.CardNumberLastFour,
+		AuthorizationCode:  payment.AuthorizationCode,
+		AVSResult:          payment.AVSResult,
+		StoredCredential:   payment.StoredCredential,
+		Captures:           payment.Captures,
+		Refunds:            payment.Refunds,
+		PriorPayments:      prior,
+		GeneratedAt:        time.Now().UTC(),
+	}, nil
+}
+
+// updateDispute applies change to the dispute with the given id while holding its lock and stores the result.
+func (p *PaymentServiceImpl) updateDispute(ctx context.Context, id string, change func(dispute *models.Dispute) error) (*models.Dispute, error) {
+	unlock := p.locks.lock(id)
+	defer unlock()
+
+	dispute, err := p.disputes.GetDispute(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if dispute == nil {
+		return nil, gatewayerrors.ErrDisputeNotFound
+	}
+
+	if err := change(dispute); err != nil {
+		return nil, err
+	}
+	dispute.UpdatedAt = time.Now().UTC()
+
+	if err := p.disputes.UpdateDispute(ctx, *dispute); err != nil {
+		return nil, err
+	}
+	return dispute, nil
+}
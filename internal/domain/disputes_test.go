@@ -0,0 +1,124 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispute_Lifecycle(t *testing.T) {
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), capturedPayment()))
+
+	bus := events.NewInMemoryBus()
+	var published []events.Event
+	bus.Subscribe(events.PaymentDisputed, func(event events.Event) {
+		published = append(published, event)
+	})
+
+	domain := domain.NewPaymentServiceImpl(repo, nil, bus)
+
+	dispute, err := domain.OpenDispute(context.Background(), &models.OpenDisputeRequest{PaymentId: "test-id", Reason: "product_not_received"})
+	require.NoError(t, err)
+	assert.Equal(t, "open", dispute.Status)
+	assert.Equal(t, 100, dispute.Amount)
+	require.Len(t, published, 1)
+	assert.Equal(t, "test-id", published[0].Payment.Id)
+
+	dispute, err = domain.SubmitDisputeEvidence(context.Background(), dispute.Id, "tracking number 1Z999")
+	require.NoError(t, err)
+	assert.Equal(t, "evidence_submitted", dispute.Status)
+
+	_, err = domain.SubmitDisputeEvidence(context.Background(), dispute.Id, "more")
+	var stateErr *gatewayerrors.StateError
+	require.ErrorAs(t, err, &stateErr)
+
+	dispute, err = domain.ResolveDispute(context.Background(), dispute.Id, "won")
+	require.NoError(t, err)
+	assert.Equal(t, "won", dispute.Status)
+
+	_, err = domain.ResolveDispute(context.Background(), dispute.Id, "lost")
+	require.ErrorAs(t, err, &stateErr)
+
+	disputes, err := domain.ListDisputes(context.Background(), "test-id")
+	require.NoError(t, err)
+	require.Len(t, disputes, 1)
+	assert.Equal(t, "won", disputes[0].Status)
+	assert.Equal(t, "tracking number 1Z999", disputes[0].Evidence)
+}
+
+func TestOpenDispute_Rejected(t *testing.T) {
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), authorizedPayment()))
+	captured := capturedPayment()
+	captured.Id = "captured-id"
+	require.NoError(t, repo.AddPayment(context.Background(), captured))
+
+	domain := domain.NewPaymentServiceImpl(repo, nil, events.NewInMemoryBus())
+
+	_, err := domain.OpenDispute(context.Background(), &models.OpenDisputeRequest{PaymentId: "test-id", Reason: "fraud"})
+	var stateErr *gatewayerrors.StateError
+	assert.ErrorAs(t, err, &stateErr, "authorized payments have taken no money")
+
+	_, err = domain.OpenDispute(context.Background(), &models.OpenDisputeRequest{PaymentId: "captured-id", Amount: 101, Reason: "fraud"})
+	assert.ErrorAs(t, err, &stateErr, "more than was captured")
+
+	_, err = domain.OpenDispute(context.Background(), &models.OpenDisputeRequest{PaymentId: "captured-id"})
+	var validationErr *gatewayerrors.ValidationError
+	assert.ErrorAs(t, err, &validationErr, "no reason")
+
+	_, err = domain.OpenDispute(context.Background(), &models.OpenDisputeRequest{PaymentId: "missing", Reason: "fraud"})
+	assert.ErrorIs(t, err, gatewayerrors.ErrPaymentNotFound)
+
+	_, err = domain.ResolveDispute(context.Background(), "missing", "won")
+	assert.ErrorIs(t, err, gatewayerrors.ErrDisputeNotFound)
+}
+
+func TestGetDisputeEvidenceBundle(t *testing.T) {
+	repo := repository.NewPaymentsRepository()
+
+	prior := capturedPayment()
+	prior.Id = "prior-id"
+	disputedPrior := capturedPayment()
+	disputedPrior.Id = "disputed-prior-id"
+	otherCard := capturedPayment()
+	otherCard.Id = "other-card-id"
+	otherCard.CardNumberLastFour = 1111
+	declined := capturedPayment()
+	declined.Id = "declined-id"
+	declined.PaymentStatus = "declined"
+	payment := capturedPayment()
+	payment.AVSResult = "Y"
+	later := capturedPayment()
+	later.Id = "later-id"
+	for _, p := range []models.PostPaymentResponse{prior, disputedPrior, otherCard, declined, payment, later} {
+		require.NoError(t, repo.AddPayment(context.Background(), p))
+	}
+
+	domain := domain.NewPaymentServiceImpl(repo, nil, events.NewInMemoryBus())
+
+	_, err := domain.OpenDispute(context.Background(), &models.OpenDisputeRequest{PaymentId: "disputed-prior-id", Reason: "fraudulent"})
+	require.NoError(t, err)
+	dispute, err := domain.OpenDispute(context.Background(), &models.OpenDisputeRequest{PaymentId: "test-id", Reason: "fraudulent"})
+	require.NoError(t, err)
+
+	bundle, err := domain.GetDisputeEvidenceBundle(context.Background(), dispute.Id)
+	require.NoError(t, err)
+	assert.Equal(t, dispute.Id, bundle.DisputeId)
+	assert.Equal(t, "fraudulent", bundle.Reason)
+	assert.Equal(t, "test-id", bundle.PaymentId)
+	assert.Equal(t, "Y", bundle.AVSResult)
+	assert.Equal(t, "abb53d1a-42dd-4ecc-9a25-dca064d35eb2", bundle.AuthorizationCode)
+	require.Len(t, bundle.PriorPayments, 1)
+	assert.Equal(t, "prior-id", bundle.PriorPayments[0].PaymentId)
+
+	_, err = domain.GetDisputeEvidenceBundle(context.Background(), "missing")
+	assert.ErrorIs(t, err, gatewayerrors.ErrDisputeNotFound)
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+/*
+An authorization that is never captured keeps the customer's funds on hold until the issuer gives up on it.  The expiry sweep releases those holds on the merchant's behalf: every authorized payment whose ExpiresAt has passed is voided with the acquiring bank and marked expired.
+
+A partially captured payment keeps what was captured: the rest of the hold is released and it becomes captured.  A payment the bank refuses to void stays as it was and is tried again on the next sweep.
+*/
+
+// ExpireAuthorizations voids every authorized payment that expired before now and returns how many were expired.
+func (p *PaymentServiceImpl) ExpireAuthorizations(ctx context.Context, now time.Time) (int, error) {
+	payments, err := p.repo.ListPayments(ctx, models.PaymentFilter{})
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, payment := range payments {
+		if !expiredAt(&payment, now) {
+			continue
+		}
+		_, err := p.expire(ctx, payment.Id, now)
+		var stateErr *gatewayerrors.StateError
+		switch {
+		case err == nil:
+			expired++
+		case errors.As(err, &stateErr):
+			// captured or voided since the payments were listed
+		case ctx.Err() != nil:
+			return expired, ctx.Err()
+		default:
+			log.Printf("expiring payment %s: %v", payment.Id, err)
+		}
+	}
+	return expired, nil
+}
+
+// RunExpiry calls ExpireAuthorizations every interval until ctx is done.
+func (p *PaymentServiceImpl) RunExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			expired, err := p.ExpireAuthorizations(ctx, now)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("expiring authorizations: %v", err)
+			}
+			if expired > 0 {
+				log.Printf("expired %d authorizations", expired)
+			}
+		}
+	}
+}
+
+func (p *PaymentServiceImpl) expire(ctx context.Context, id string, now time.Time) (*models.PostPaymentResponse, error) {
+	return p.run(ctx, id, operation{
+		name: "expire",
+		check: func(payment *models.PostPaymentResponse) error {
+			if !expiredAt(payment, now) {
+				return gatewayerrors.NewStateError(
+					fmt.Errorf("cannot expire a %s payment", payment.PaymentStatus),
+					id,
+					payment.PaymentStatus,
+				)
+			}
+			return nil
+		},
+		bankCall: func(ctx context.Context, payment *models.PostPaymentResponse) error {
+			bankResponse, err := p.client.VoidBankPayment(ctx, &models.VoidBankRequest{
+				AuthorizationCode: payment.AuthorizationCode,
+			})
+			if err != nil {
+				return err
+			}
+			if !bankResponse.Voided {
+				return gatewayerrors.NewBankError(
+					errors.New("acquiring bank refused the void"),
+					http.StatusBadGateway,
+				)
+			}
+			return nil
+		},
+		apply: func(payment *models.PostPaymentResponse) {
+			if Status(payment.PaymentStatus) == StatusPartiallyCaptured {
+				payment.PaymentStatus = string(StatusCaptured)
+			} else {
+				payment.PaymentStatus = string(StatusExpired)
+			}
+			payment.ExpiresAt = nil
+		},
+		event: events.PaymentExpired,
+	})
+}
+
+func expiredAt(payment *models.PostPaymentResponse, now time.Time) bool {
+	return Status(payment.PaymentStatus).held() && payment.ExpiresAt != nil && !now.Before(*payment.ExpiresAt)
+}
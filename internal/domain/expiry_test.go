@@ -0,0 +1,131 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExpireAuthorizations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	now := time.Now()
+	past := now.Add(-time.Minute)
+
+	stale := authorizedPayment()
+	stale.Id = "stale"
+	stale.ExpiresAt = &past
+
+	fresh := authorizedPayment()
+	fresh.Id = "fresh"
+
+	captured := authorizedPayment()
+	captured.Id = "captured"
+	captured.PaymentStatus = "captured"
+	captured.ExpiresAt = nil
+
+	repo := repository.NewPaymentsRepository()
+	for _, payment := range []models.PostPaymentResponse{stale, fresh, captured} {
+		require.NoError(t, repo.AddPayment(context.Background(), payment))
+	}
+
+	mockClient.EXPECT().VoidBankPayment(gomock.Any(), &models.VoidBankRequest{
+		AuthorizationCode: stale.AuthorizationCode,
+	}).Return(&models.VoidBankResponse{Voided: true}, nil)
+
+	bus := events.NewInMemoryBus()
+	var published []events.Event
+	bus.Subscribe(events.PaymentExpired, func(event events.Event) {
+		published = append(published, event)
+	})
+
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, bus)
+
+	expired, err := domain.ExpireAuthorizations(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	dbPayment, err := repo.GetPayment(context.Background(), "stale")
+	require.NoError(t, err)
+	assert.Equal(t, "expired", dbPayment.PaymentStatus)
+	assert.Nil(t, dbPayment.ExpiresAt)
+
+	dbPayment, err = repo.GetPayment(context.Background(), "fresh")
+	require.NoError(t, err)
+	assert.Equal(t, "authorized", dbPayment.PaymentStatus)
+
+	require.Len(t, published, 1)
+	assert.Equal(t, "stale", published[0].Payment.Id)
+}
+
+func TestExpireAuthorizations_BankErrorRetriedNextSweep(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	stale := authorizedPayment()
+	stale.ExpiresAt = &past
+
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), stale))
+
+	gomock.InOrder(
+		mockClient.EXPECT().VoidBankPayment(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused")),
+		mockClient.EXPECT().VoidBankPayment(gomock.Any(), gomock.Any()).Return(&models.VoidBankResponse{Voided: true}, nil),
+	)
+
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	expired, err := domain.ExpireAuthorizations(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired)
+
+	dbPayment, err := repo.GetPayment(context.Background(), stale.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "authorized", dbPayment.PaymentStatus)
+
+	expired, err = domain.ExpireAuthorizations(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+}
+
+func TestPostPayment_AuthorizationValidityOverride(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return(&models.PostPaymentBankResponse{
+		Authorised:        true,
+		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+	}, nil)
+
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), mockClient, events.NewInMemoryBus())
+	domain.SetAuthorizationValidity(time.Hour)
+
+	before := time.Now()
+	response, err := domain.Create(context.Background(), &models.PostPaymentHandlerRequest{
+		CardNumber:  5555555555554444,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, response.ExpiresAt)
+	assert.WithinDuration(t, before.Add(time.Hour), *response.ExpiresAt, time.Minute)
+}
@@ -0,0 +1,205 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/google/uuid"
+)
+
+/*
+An installment plan takes an amount from a card in equal payments one interval apart.  The first installment is a customer-initiated payment made straight away, the rest are scheduled merchant-initiated payments that reference it, so they are sent to the bank by the scheduler like any other scheduled payment.  Any remainder of the split goes on the first installment.
+
+A plan is active until every installment has been sent, then completed.  One whose first installment is declined is declined and nothing is scheduled.  Cancelling a plan cancels the installments that are still scheduled, those already taken stay as they are.
+*/
+
+const (
+	minInstallments = 2
+	maxInstallments = 12
+)
+
+// installmentIntervals gives the time between installments, as years, months and days for time.AddDate.
+var installmentIntervals = map[string][3]int{
+	"weekly":  {0, 0, 7},
+	"monthly": {0, 1, 0},
+}
+
+// CreateInstallmentPlan takes the first installment and schedules the rest.
+func (p *PaymentServiceImpl) CreateInstallmentPlan(ctx context.Context, request *models.CreateInstallmentPlanRequest) (*models.InstallmentPlan, error) {
+	id := uuid.New().String()
+
+	if request.Installments < minInstallments || request.Installments > maxInstallments {
+		return nil, gatewayerrors.NewValidationError(
+			fmt.Errorf("installments must be between %d and %d", minInstallments, maxInstallments),
+			id,
+			"installments",
+		)
+	}
+
+	interval, ok := installmentIntervals[request.Interval]
+	if !ok {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("interval must be weekly or monthly"),
+			id,
+			"interval",
+		)
+	}
+
+	// every installment must be for something
+	if request.Amount < request.Installments {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("invalid amount"),
+			id,
+			"amount",
+		)
+	}
+
+	unlock := p.locks.lock(id)
+	defer unlock()
+
+	amount := request.Amount / request.Installments
+	first, err := p.Create(ctx, &models.PostPaymentHandlerRequest{
+		CardNumber:       request.CardNumber,
+		ExpiryMonth:      request.ExpiryMonth,
+		ExpiryYear:       request.ExpiryYear,
+		Currency:         request.Currency,
+		Amount:           amount + request.Amount%request.Installments,
+		Cvv:              request.Cvv,
+		BillingAddress:   request.BillingAddress,
+		Capture:          true,
+		StoredCredential: &models.StoredCredential{Initiator: "customer", Reason: "installment"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	plan := models.InstallmentPlan{
+		Id:           id,
+		Status:       "active",
+		Currency:     request.Currency,
+		Amount:       request.Amount,
+		Interval:     request.Interval,
+		Installments: []models.Installment{{PaymentId: first.Id, Amount: first.Amount}},
+		CreatedAt:    now,
+	}
+	if Status(first.PaymentStatus) == StatusDeclined {
+		plan.Status = "declined"
+	}
+
+	// the first installment has been taken, so the plan must be recorded even if the caller's budget is spent
+	store := context.WithoutCancel(ctx)
+	for i := 1; i < request.Installments && plan.Status == "active"; i++ {
+		executeAt := now.AddDate(i*interval[0], i*interval[1], i*interval[2])
+		payment, err := p.Create(store, &models.PostPaymentHandlerRequest{
+			CardNumber:     request.CardNumber,
+			ExpiryMonth:    request.ExpiryMonth,
+			ExpiryYear:     request.ExpiryYear,
+			Currency:       request.Currency,
+			Amount:         amount,
+			Cvv:            request.Cvv,
+			BillingAddress: request.BillingAddress,
+			Capture:        true,
+			StoredCredential: &models.StoredCredential{
+				Initiator:             "merchant",
+				Reason:                "installment",
+				OriginalTransactionId: first.Id,
+			},
+			ExecuteAt: &executeAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		plan.Installments = append(plan.Installments, models.Installment{PaymentId: payment.Id, Amount: amount})
+	}
+
+	if err := p.plans.AddPlan(store, plan); err != nil {
+		return nil, err
+	}
+
+	return p.planProgress(store, plan)
+}
+
+// GetInstallmentPlan returns the installment plan with the given id and the status of each of its installments.
+func (p *PaymentServiceImpl) GetInstallmentPlan(ctx context.Context, id string) (*models.InstallmentPlan, error) {
+	plan, err := p.plans.GetPlan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, gatewayerrors.ErrInstallmentPlanNotFound
+	}
+
+	return p.planProgress(ctx, *plan)
+}
+
+// CancelInstallmentPlan cancels every installment of an active plan that is still scheduled.
+func (p *PaymentServiceImpl) CancelInstallmentPlan(ctx context.Context, id string) (*models.InstallmentPlan, error) {
+	unlock := p.locks.lock(id)
+	defer unlock()
+
+	plan, err := p.GetInstallmentPlan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.Status != "active" {
+		return nil, gatewayerrors.NewStateError(
+			fmt.Errorf("cannot cancel a %s installment plan", plan.Status),
+			id,
+			plan.Status,
+		)
+	}
+
+	for _, installment := range plan.Installments {
+		if Status(installment.Status) != StatusScheduled {
+			continue
+		}
+		_, err := p.CancelPayment(ctx, installment.PaymentId)
+		// the scheduler may have sent it since the plan was read
+		var stateErr *gatewayerrors.StateError
+		if err != nil && !errors.As(err, &stateErr) {
+			return nil, err
+		}
+	}
+
+	plan.Status = "cancelled"
+	if err := p.plans.UpdatePlan(context.WithoutCancel(ctx), *plan); err != nil {
+		return nil, err
+	}
+
+	return p.planProgress(ctx, *plan)
+}
+
+// planProgress fills in the status and due time of each installment from its payment, an active plan with nothing
+// left to send is completed.
+func (p *PaymentServiceImpl) planProgress(ctx context.Context, plan models.InstallmentPlan) (*models.InstallmentPlan, error) {
+	installments := make([]models.Installment, len(plan.Installments))
+	pending := false
+	for i, installment := range plan.Installments {
+		payment, err := p.repo.GetPayment(ctx, installment.PaymentId)
+		if err != nil {
+			return nil, err
+		}
+		if payment == nil {
+			return nil, gatewayerrors.ErrPaymentNotFound
+		}
+
+		installment.Status = payment.PaymentStatus
+		installment.ExecuteAt = payment.ExecuteAt
+		installments[i] = installment
+
+		status := Status(payment.PaymentStatus)
+		pending = pending || status == StatusScheduled || status == StatusProcessing
+	}
+
+	plan.Installments = installments
+	if plan.Status == "active" && !pending {
+		plan.Status = "completed"
+	}
+	return &plan, nil
+}
@@ -0,0 +1,190 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func planRequest() models.CreateInstallmentPlanRequest {
+	return models.CreateInstallmentPlanRequest{
+		CardNumber:   2222405343248877,
+		ExpiryMonth:  4,
+		ExpiryYear:   2035,
+		Cvv:          123,
+		Currency:     "GBP",
+		Amount:       1000,
+		Installments: 3,
+		Interval:     "monthly",
+	}
+}
+
+func TestCreateInstallmentPlan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	repo := repository.NewPaymentsRepository()
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	// the remainder of the split goes on the first installment
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), &models.PostPaymentBankRequest{
+		CardNumber:       "2222405343248877",
+		ExpiryDate:       "4/2035",
+		Currency:         "GBP",
+		Amount:           334,
+		CVV:              "123",
+		Capture:          true,
+		StoredCredential: &models.StoredCredential{Initiator: "customer", Reason: "installment"},
+	}).Return(&models.PostPaymentBankResponse{Authorised: true, AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2"}, nil)
+
+	request := planRequest()
+	plan, err := domain.CreateInstallmentPlan(context.Background(), &request)
+	require.NoError(t, err)
+	assert.Equal(t, "active", plan.Status)
+	assert.Equal(t, 1000, plan.Amount)
+	require.Len(t, plan.Installments, 3)
+
+	assert.Equal(t, "captured", plan.Installments[0].Status)
+	assert.Equal(t, 334, plan.Installments[0].Amount)
+	for i, installment := range plan.Installments[1:] {
+		assert.Equal(t, "scheduled", installment.Status)
+		assert.Equal(t, 333, installment.Amount)
+		require.NotNil(t, installment.ExecuteAt)
+		assert.WithinDuration(t, plan.CreatedAt.AddDate(0, i+1, 0), *installment.ExecuteAt, time.Second)
+
+		payment, err := repo.GetPayment(context.Background(), installment.PaymentId)
+		require.NoError(t, err)
+		assert.Equal(t, &models.StoredCredential{
+			Initiator:             "merchant",
+			Reason:                "installment",
+			OriginalTransactionId: plan.Installments[0].PaymentId,
+		}, payment.StoredCredential)
+	}
+
+	got, err := domain.GetInstallmentPlan(context.Background(), plan.Id)
+	require.NoError(t, err)
+	assert.Equal(t, plan, got)
+}
+
+func TestCreateInstallmentPlan_FirstDeclined(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	repo := repository.NewPaymentsRepository()
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return(&models.PostPaymentBankResponse{Authorised: false}, nil)
+
+	request := planRequest()
+	plan, err := domain.CreateInstallmentPlan(context.Background(), &request)
+	require.NoError(t, err)
+	assert.Equal(t, "declined", plan.Status)
+	require.Len(t, plan.Installments, 1)
+	assert.Equal(t, "declined", plan.Installments[0].Status)
+
+	// nothing was scheduled
+	assert.Equal(t, 0, domain.ExecuteScheduled(context.Background(), time.Now().AddDate(1, 0, 0)))
+}
+
+func TestCreateInstallmentPlan_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*models.CreateInstallmentPlanRequest)
+		field  string
+	}{
+		{name: "TooFewInstallments", modify: func(r *models.CreateInstallmentPlanRequest) { r.Installments = 1 }, field: "installments"},
+		{name: "TooManyInstallments", modify: func(r *models.CreateInstallmentPlanRequest) { r.Installments = 13 }, field: "installments"},
+		{name: "InvalidInterval", modify: func(r *models.CreateInstallmentPlanRequest) { r.Interval = "daily" }, field: "interval"},
+		{name: "AmountBelowInstallments", modify: func(r *models.CreateInstallmentPlanRequest) { r.Amount = 2 }, field: "amount"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			// no bank call is expected
+			mockClient := mocks.NewMockClient(ctrl)
+
+			domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), mockClient, events.NewInMemoryBus())
+
+			request := planRequest()
+			tt.modify(&request)
+			_, err := domain.CreateInstallmentPlan(context.Background(), &request)
+
+			var validationErr *gatewayerrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.field, validationErr.Field)
+		})
+	}
+}
+
+func TestCancelInstallmentPlan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	repo := repository.NewPaymentsRepository()
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return(&models.PostPaymentBankResponse{Authorised: true, AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2"}, nil).Times(2)
+
+	request := planRequest()
+	request.Interval = "weekly"
+	plan, err := domain.CreateInstallmentPlan(context.Background(), &request)
+	require.NoError(t, err)
+
+	// the second installment falls due before the plan is cancelled
+	assert.Equal(t, 1, domain.ExecuteScheduled(context.Background(), time.Now().AddDate(0, 0, 8)))
+
+	cancelled, err := domain.CancelInstallmentPlan(context.Background(), plan.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", cancelled.Status)
+	assert.Equal(t, "captured", cancelled.Installments[0].Status)
+	assert.Equal(t, "captured", cancelled.Installments[1].Status)
+	assert.Equal(t, "cancelled", cancelled.Installments[2].Status)
+
+	// the cancelled installment is never sent
+	assert.Equal(t, 0, domain.ExecuteScheduled(context.Background(), time.Now().AddDate(1, 0, 0)))
+
+	_, err = domain.CancelInstallmentPlan(context.Background(), plan.Id)
+	var stateErr *gatewayerrors.StateError
+	require.ErrorAs(t, err, &stateErr)
+	assert.Equal(t, "cancelled", stateErr.Status)
+
+	_, err = domain.CancelInstallmentPlan(context.Background(), "missing")
+	assert.ErrorIs(t, err, gatewayerrors.ErrInstallmentPlanNotFound)
+}
+
+func TestGetInstallmentPlan_Completed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	repo := repository.NewPaymentsRepository()
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return(&models.PostPaymentBankResponse{Authorised: true, AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2"}, nil).Times(2)
+
+	request := planRequest()
+	request.Installments = 2
+	plan, err := domain.CreateInstallmentPlan(context.Background(), &request)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, domain.ExecuteScheduled(context.Background(), time.Now().AddDate(0, 2, 0)))
+
+	plan, err = domain.GetInstallmentPlan(context.Background(), plan.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", plan.Status)
+}
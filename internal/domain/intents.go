@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/google/uuid"
+)
+
+/*
+A payment intent lets a merchant say how much it wants to take before the customer has entered their card, so the frontend can create the intent when checkout starts and confirm it with the card details at the end.  Confirming makes an ordinary payment and the intent records which one.
+
+An intent goes requires_confirmation -> processing -> succeeded or failed.  A confirmation that never reaches the bank, for example because the card details were invalid, puts it back to requires_confirmation so it can be confirmed again.  One that timed out after the bank was asked stays processing: its outcome is unknown and confirming again could take the money twice.
+*/
+
+// CreatePaymentIntent records the amount the merchant means to take, waiting for the card to be attached by ConfirmPaymentIntent.
+func (p *PaymentServiceImpl) CreatePaymentIntent(ctx context.Context, request *models.CreatePaymentIntentRequest) (*models.PaymentIntent, error) {
+	id := uuid.New().String()
+
+	if err := validateCurrencyISO(request.Currency, id); err != nil {
+		return nil, err
+	}
+
+	if err := validateAmount(request.Amount, id); err != nil {
+		return nil, err
+	}
+
+	intent := models.PaymentIntent{
+		Id:        id,
+		Status:    "requires_confirmation",
+		Amount:    request.Amount,
+		Currency:  request.Currency,
+		Capture:   request.Capture,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := p.intents.AddIntent(ctx, intent); err != nil {
+		return nil, err
+	}
+
+	return &intent, nil
+}
+
+// GetPaymentIntent returns the payment intent with the given id, it fails with gatewayerrors.ErrPaymentIntentNotFound if there is none.
+func (p *PaymentServiceImpl) GetPaymentIntent(ctx context.Context, id string) (*models.PaymentIntent, error) {
+	intent, err := p.intents.GetIntent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if intent == nil {
+		return nil, gatewayerrors.ErrPaymentIntentNotFound
+	}
+	return intent, nil
+}
+
+// ConfirmPaymentIntent pays the intent with the given card and records the outcome.
+func (p *PaymentServiceImpl) ConfirmPaymentIntent(ctx context.Context, id string, request *models.ConfirmPaymentIntentRequest) (*models.PaymentIntent, error) {
+	unlock := p.locks.lock(id)
+	defer unlock()
+
+	intent, err := p.GetPaymentIntent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if intent.Status != "requires_confirmation" {
+		return nil, gatewayerrors.NewStateError(
+			fmt.Errorf("cannot confirm a %s payment intent", intent.Status),
+			id,
+			intent.Status,
+		)
+	}
+
+	intent.Status = "processing"
+	if err := p.intents.UpdateIntent(ctx, *intent); err != nil {
+		return nil, err
+	}
+
+	payment, err := p.Create(ctx, &models.PostPaymentHandlerRequest{
+		CardNumber:       request.CardNumber,
+		ExpiryMonth:      request.ExpiryMonth,
+		ExpiryYear:       request.ExpiryYear,
+		Currency:         intent.Currency,
+		Amount:           intent.Amount,
+		Cvv:              request.Cvv,
+		StoredCredential: request.StoredCredential,
+		CardToken:        request.CardToken,
+		Capture:          intent.Capture,
+	})
+
+	// whatever happened the intent must be recorded even if the caller's budget is spent
+	store := context.WithoutCancel(ctx)
+	if err != nil {
+		var timeoutErr *gatewayerrors.TimeoutError
+		if errors.As(err, &timeoutErr) && timeoutErr.BankCalled {
+			log.Printf("payment intent %s left processing, the bank may have taken the payment: %v", id, err)
+			return nil, err
+		}
+		intent.Status = "requires_confirmation"
+		if updateErr := p.intents.UpdateIntent(store, *intent); updateErr != nil {
+			return nil, updateErr
+		}
+		return nil, err
+	}
+
+	intent.PaymentId = payment.Id
+	intent.Status = "succeeded"
+	if Status(payment.PaymentStatus) == StatusDeclined {
+		intent.Status = "failed"
+	}
+	if err := p.intents.UpdateIntent(store, *intent); err != nil {
+		return nil, err
+	}
+
+	return intent, nil
+}
@@ -0,0 +1,99 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestConfirmPaymentIntent(t *testing.T) {
+	tests := []struct {
+		name           string
+		authorised     bool
+		expectedStatus string
+	}{
+		{name: "Authorized", authorised: true, expectedStatus: "succeeded"},
+		{name: "Declined", authorised: false, expectedStatus: "failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockClient := mocks.NewMockClient(ctrl)
+
+			repo := repository.NewPaymentsRepository()
+			domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+			intent, err := domain.CreatePaymentIntent(context.Background(), &models.CreatePaymentIntentRequest{Amount: 100, Currency: "GBP"})
+			require.NoError(t, err)
+			assert.Equal(t, "requires_confirmation", intent.Status)
+
+			mockClient.EXPECT().PostBankPayment(gomock.Any(), &models.PostPaymentBankRequest{
+				CardNumber: "2222405343248877",
+				ExpiryDate: "4/2035",
+				Currency:   "GBP",
+				Amount:     100,
+				CVV:        "123",
+			}).Return(&models.PostPaymentBankResponse{Authorised: tt.authorised}, nil)
+
+			confirmed, err := domain.ConfirmPaymentIntent(context.Background(), intent.Id, &models.ConfirmPaymentIntentRequest{
+				CardNumber:  2222405343248877,
+				ExpiryMonth: 4,
+				ExpiryYear:  2035,
+				Cvv:         123,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, confirmed.Status)
+
+			payment, err := repo.GetPayment(context.Background(), confirmed.PaymentId)
+			require.NoError(t, err)
+			require.NotNil(t, payment)
+			assert.Equal(t, 100, payment.Amount)
+
+			_, err = domain.ConfirmPaymentIntent(context.Background(), intent.Id, &models.ConfirmPaymentIntentRequest{})
+			var stateErr *gatewayerrors.StateError
+			require.ErrorAs(t, err, &stateErr)
+		})
+	}
+}
+
+func TestConfirmPaymentIntent_InvalidCardCanBeRetried(t *testing.T) {
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+
+	intent, err := domain.CreatePaymentIntent(context.Background(), &models.CreatePaymentIntentRequest{Amount: 100, Currency: "GBP"})
+	require.NoError(t, err)
+
+	_, err = domain.ConfirmPaymentIntent(context.Background(), intent.Id, &models.ConfirmPaymentIntentRequest{
+		CardNumber:  1234,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Cvv:         123,
+	})
+	var validationErr *gatewayerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	stored, err := domain.GetPaymentIntent(context.Background(), intent.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "requires_confirmation", stored.Status)
+	assert.Empty(t, stored.PaymentId)
+}
+
+func TestPaymentIntent_NotFound(t *testing.T) {
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+
+	_, err := domain.GetPaymentIntent(context.Background(), "missing")
+	assert.ErrorIs(t, err, gatewayerrors.ErrPaymentIntentNotFound)
+
+	_, err = domain.ConfirmPaymentIntent(context.Background(), "missing", &models.ConfirmPaymentIntentRequest{})
+	assert.ErrorIs(t, err, gatewayerrors.ErrPaymentIntentNotFound)
+}
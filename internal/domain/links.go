@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/google/uuid"
+)
+
+/*
+A payment link lets a merchant ask for a fixed amount without building a checkout, they share the link's id and the customer pays it with their card.  Paying makes an ordinary payment and the link records which one.
+
+A link goes active -> processing -> paid.  A payment the bank declined, or one that never reached it, puts the link back to active so the customer can try another card.  One that timed out after the bank was asked stays processing, as a payment intent does, because paying again could take the money twice.  An active link that is not paid by its expires_at becomes expired the next time it is looked at.
+*/
+
+// defaultLinkValidity is how long a payment link can be paid when the merchant does not say.
+const defaultLinkValidity = 7 * 24 * time.Hour
+
+// CreatePaymentLink records the amount the merchant wants to be paid and returns the link to share with the customer.
+func (p *PaymentServiceImpl) CreatePaymentLink(ctx context.Context, request *models.CreatePaymentLinkRequest) (*models.PaymentLink, error) {
+	id := uuid.New().String()
+
+	if err := validateCurrencyISO(request.Currency, id); err != nil {
+		return nil, err
+	}
+
+	// a link for nothing would only verify the card, which is what card verifications are for
+	if request.Amount <= 0 {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("invalid amount"),
+			id,
+			"amount",
+		)
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(defaultLinkValidity)
+	if request.ExpiresAt != nil {
+		if !request.ExpiresAt.After(now) {
+			return nil, gatewayerrors.NewValidationError(
+				errors.New("expires_at must be in the future"),
+				id,
+				"expires_at",
+			)
+		}
+		expiresAt = request.ExpiresAt.UTC()
+	}
+
+	link := models.PaymentLink{
+		Id:          id,
+		Status:      "active",
+		Amount:      request.Amount,
+		Currency:    request.Currency,
+		Description: request.Description,
+		Capture:     request.Capture,
+		ExpiresAt:   expiresAt,
+		CreatedAt:   now,
+	}
+	if err := p.links.AddLink(ctx, link); err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+// GetPaymentLink returns the payment link with the given id, it fails with gatewayerrors.ErrPaymentLinkNotFound if there is none.
+func (p *PaymentServiceImpl) GetPaymentLink(ctx context.Context, id string) (*models.PaymentLink, error) {
+	unlock := p.locks.lock(id)
+	defer unlock()
+
+	return p.getPaymentLink(ctx, id, time.Now())
+}
+
+// getPaymentLink looks up a payment link, expiring it if it is still active after its expires_at.  The caller holds the link's lock.
+func (p *PaymentServiceImpl) getPaymentLink(ctx context.Context, id string, now time.Time) (*models.PaymentLink, error) {
+	link, err := p.links.GetLink(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, gatewayerrors.ErrPaymentLinkNotFound
+	}
+
+	if link.Status == "active" && !now.Before(link.ExpiresAt) {
+		link.Status = "expired"
+		if err := p.links.UpdateLink(context.WithoutCancel(ctx), *link); err != nil {
+			return nil, err
+		}
+	}
+	return link, nil
+}
+
+// PayPaymentLink pays the link with the given card and returns the payment, declined or not.
+func (p *PaymentServiceImpl) PayPaymentLink(ctx context.Context, id string, request *models.PayPaymentLinkRequest) (*models.PostPaymentResponse, error) {
+	unlock := p.locks.lock(id)
+	defer unlock()
+
+	link, err := p.getPaymentLink(ctx, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if link.Status != "active" {
+		return nil, gatewayerrors.NewStateError(
+			fmt.Errorf("cannot pay a %s payment link", link.Status),
+			id,
+			link.Status,
+		)
+	}
+
+	link.Status = "processing"
+	if err := p.links.UpdateLink(ctx, *link); err != nil {
+		return nil, err
+	}
+
+	payment, err := p.Create(ctx, &models.PostPaymentHandlerRequest{
+		CardNumber:     request.CardNumber,
+		ExpiryMonth:    request.ExpiryMonth,
+		ExpiryYear:     request.ExpiryYear,
+		Currency:       link.Currency,
+		Amount:         link.Amount,
+		Cvv:            request.Cvv,
+		BillingAddress: request.BillingAddress,
+		Capture:        link.Capture,
+	})
+
+	// whatever happened the link must be recorded even if the caller's budget is spent
+	store := context.WithoutCancel(ctx)
+	if err != nil {
+		var timeoutErr *gatewayerrors.TimeoutError
+		if errors.As(err, &timeoutErr) && timeoutErr.BankCalled {
+			log.Printf("payment link %s left processing, the bank may have taken the payment: %v", id, err)
+			return nil, err
+		}
+		link.Status = "active"
+		if updateErr := p.links.UpdateLink(store, *link); updateErr != nil {
+			return nil, updateErr
+		}
+		return nil, err
+	}
+
+	link.Status = "paid"
+	link.PaymentId = payment.Id
+	if Status(payment.PaymentStatus) == StatusDeclined {
+		link.Status = "active"
+		link.PaymentId = ""
+	}
+	if err := p.links.UpdateLink(store, *link); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
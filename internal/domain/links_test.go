@@ -0,0 +1,155 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var linkCard = models.PayPaymentLinkRequest{
+	CardNumber:  2222405343248877,
+	ExpiryMonth: 4,
+	ExpiryYear:  2035,
+	Cvv:         123,
+}
+
+func TestPayPaymentLink(t *testing.T) {
+	tests := []struct {
+		name           string
+		authorised     bool
+		expectedStatus string
+	}{
+		{name: "Authorized", authorised: true, expectedStatus: "paid"},
+		{name: "DeclinedCanBePaidAgain", authorised: false, expectedStatus: "active"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockClient := mocks.NewMockClient(ctrl)
+
+			repo := repository.NewPaymentsRepository()
+			domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+			link, err := domain.CreatePaymentLink(context.Background(), &models.CreatePaymentLinkRequest{Amount: 100, Currency: "GBP"})
+			require.NoError(t, err)
+			assert.Equal(t, "active", link.Status)
+			assert.WithinDuration(t, time.Now().Add(7*24*time.Hour), link.ExpiresAt, time.Minute)
+
+			mockClient.EXPECT().PostBankPayment(gomock.Any(), &models.PostPaymentBankRequest{
+				CardNumber: "2222405343248877",
+				ExpiryDate: "4/2035",
+				Currency:   "GBP",
+				Amount:     100,
+				CVV:        "123",
+			}).Return(&models.PostPaymentBankResponse{Authorised: tt.authorised}, nil)
+
+			request := linkCard
+			payment, err := domain.PayPaymentLink(context.Background(), link.Id, &request)
+			require.NoError(t, err)
+			assert.Equal(t, 100, payment.Amount)
+
+			link, err = domain.GetPaymentLink(context.Background(), link.Id)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, link.Status)
+			if tt.authorised {
+				assert.Equal(t, payment.Id, link.PaymentId)
+			} else {
+				assert.Empty(t, link.PaymentId)
+			}
+		})
+	}
+}
+
+func TestPayPaymentLink_PaidOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), mockClient, events.NewInMemoryBus())
+
+	link, err := domain.CreatePaymentLink(context.Background(), &models.CreatePaymentLinkRequest{Amount: 100, Currency: "GBP"})
+	require.NoError(t, err)
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), gomock.Any()).Return(&models.PostPaymentBankResponse{Authorised: true}, nil).Times(1)
+
+	request := linkCard
+	_, err = domain.PayPaymentLink(context.Background(), link.Id, &request)
+	require.NoError(t, err)
+
+	payment, err := domain.PayPaymentLink(context.Background(), link.Id, &request)
+	require.Nil(t, payment)
+	var stateErr *gatewayerrors.StateError
+	require.ErrorAs(t, err, &stateErr)
+	assert.Equal(t, "paid", stateErr.Status)
+}
+
+func TestPayPaymentLink_Expired(t *testing.T) {
+	// no bank call is expected
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+
+	expiresAt := time.Now().Add(20 * time.Millisecond)
+	link, err := domain.CreatePaymentLink(context.Background(), &models.CreatePaymentLinkRequest{Amount: 100, Currency: "GBP", ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+
+	time.Sleep(30 * time.Millisecond)
+
+	request := linkCard
+	payment, err := domain.PayPaymentLink(context.Background(), link.Id, &request)
+	require.Nil(t, payment)
+	var stateErr *gatewayerrors.StateError
+	require.ErrorAs(t, err, &stateErr)
+	assert.Equal(t, "expired", stateErr.Status)
+
+	link, err = domain.GetPaymentLink(context.Background(), link.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "expired", link.Status)
+}
+
+func TestCreatePaymentLink_Invalid(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		request       models.CreatePaymentLinkRequest
+		expectedField string
+	}{
+		{name: "ZeroAmount", request: models.CreatePaymentLinkRequest{Amount: 0, Currency: "GBP"}, expectedField: "amount"},
+		{name: "Currency", request: models.CreatePaymentLinkRequest{Amount: 100, Currency: "JPY"}, expectedField: "currency"},
+		{name: "ExpiresInPast", request: models.CreatePaymentLinkRequest{Amount: 100, Currency: "GBP", ExpiresAt: &past}, expectedField: "expires_at"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+
+			link, err := domain.CreatePaymentLink(context.Background(), &tt.request)
+			require.Nil(t, link)
+			var validationErr *gatewayerrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.expectedField, validationErr.Field)
+		})
+	}
+}
+
+func TestPaymentLink_NotFound(t *testing.T) {
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+
+	_, err := domain.GetPaymentLink(context.Background(), "missing")
+	assert.ErrorIs(t, err, gatewayerrors.ErrPaymentLinkNotFound)
+
+	request := linkCard
+	_, err = domain.PayPaymentLink(context.Background(), "missing", &request)
+	assert.ErrorIs(t, err, gatewayerrors.ErrPaymentLinkNotFound)
+}
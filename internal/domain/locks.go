@@ -0,0 +1,43 @@
+package domain
+
+import "sync"
+
+// paymentLocks serialises operations on the same payment, so that two concurrent captures cannot both see it authorized and both reach the bank.
+// A payment's lock is only kept while an operation holds or waits for it, so the map does not grow with every payment ever made.
+type paymentLocks struct {
+	mu    sync.Mutex
+	locks map[string]*paymentLock
+}
+
+type paymentLock struct {
+	sync.Mutex
+	// users counts the operations holding or waiting for the lock, it is guarded by paymentLocks.mu
+	users int
+}
+
+// lock blocks until no other operation holds the payment with the given id and returns the function that releases it.
+func (l *paymentLocks) lock(id string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[string]*paymentLock{}
+	}
+	lock, ok := l.locks[id]
+	if !ok {
+		lock = &paymentLock{}
+		l.locks[id] = lock
+	}
+	lock.users++
+	l.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		lock.users--
+		if lock.users == 0 {
+			delete(l.locks, id)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentLocks_ForgottenOnceReleased(t *testing.T) {
+	var locks paymentLocks
+
+	var wg sync.WaitGroup
+	held := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := locks.lock("payment")
+			defer unlock()
+			held++
+		}()
+	}
+	wg.Wait()
+
+	unlock := locks.lock("other")
+	assert.Len(t, locks.locks, 1)
+	unlock()
+
+	assert.Equal(t, 50, held, "operations on the same payment never overlap")
+	assert.Empty(t, locks.locks)
+}
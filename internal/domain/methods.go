@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+/*
+A payment's payment_method says what it is made with, its type picking the processor that handles it.  The processor fills in anything the request only refers to, then checks the fields particular to the method and builds the authorization sent to the bank, while prepare checks everything the methods share (expiry, currency, amount and so on).  Adding a way to pay is a matter of writing a processor and registering it in NewPaymentServiceImpl, the handlers and the rest of the payment flow do not change.
+
+A request without a payment_method pays with its top level card fields, as it did before payment methods existed.
+*/
+
+const (
+	paymentMethodCard         = "card"
+	paymentMethodNetworkToken = "network_token"
+	paymentMethodWallet       = "wallet"
+)
+
+type paymentMethodProcessor interface {
+	// resolve fills in anything the request refers to rather than carries, a stored card or the token in a wallet payload
+	resolve(ctx context.Context, request *models.PostPaymentHandlerRequest, id string) (*models.PostPaymentHandlerRequest, error)
+	// authorization checks the fields particular to the payment method and builds what is sent to the bank
+	authorization(request *models.PostPaymentHandlerRequest, expiryDate, id string) (authorization, error)
+}
+
+// authorization is what is sent to the bank to authorize a prepared payment.
+type authorization interface {
+	send(ctx context.Context, bank client.Client) (*models.PostPaymentBankResponse, error)
+	// number is the card number or token the payment is made with, it decides the card scheme
+	number() string
+}
+
+// paymentMethod returns the processor for the request's payment method.
+func (p *PaymentServiceImpl) paymentMethod(request *models.PostPaymentHandlerRequest, id string) (paymentMethodProcessor, error) {
+	methodType := paymentMethodCard
+	if request.PaymentMethod != nil {
+		methodType = request.PaymentMethod.Type
+	}
+
+	method, ok := p.methods[methodType]
+	if !ok {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("invalid payment method type"),
+			id,
+			"payment_method.type",
+		)
+	}
+	return method, nil
+}
+
+// recordedPaymentMethod returns the payment method type recorded on the payment, which is the wallet's type for wallet
+// payments and left empty for cards.
+func recordedPaymentMethod(request *models.PostPaymentHandlerRequest) string {
+	method := request.PaymentMethod
+	switch {
+	case method == nil || method.Type == paymentMethodCard:
+		return ""
+	case method.Type == paymentMethodWallet:
+		return method.Wallet.Type
+	default:
+		return method.Type
+	}
+}
+
+// lastFour returns the last four digits of the card number or network token a validated payment is made with.
+func lastFour(request *models.PostPaymentHandlerRequest) int {
+	if recordedPaymentMethod(request) == "" {
+		return request.CardNumber % 10000
+	}
+
+	token := request.PaymentMethod.NetworkToken.Token
+	digits, _ := strconv.Atoi(token[len(token)-4:])
+	return digits
+}
+
+// cardMethod pays with card details, sent in the payment_method or at the top level of the request, or a stored card.
+type cardMethod struct {
+	p *PaymentServiceImpl
+}
+
+func (m cardMethod) resolve(ctx context.Context, request *models.PostPaymentHandlerRequest, id string) (*models.PostPaymentHandlerRequest, error) {
+	if request.PaymentMethod != nil && request.PaymentMethod.Card != nil {
+		if request.CardNumber != 0 || request.ExpiryMonth != 0 || request.ExpiryYear != 0 || request.Cvv != 0 || request.CardToken != "" {
+			return nil, gatewayerrors.NewValidationError(
+				errors.New("send card details either in payment_method or at the top level"),
+				id,
+				"payment_method.card",
+			)
+		}
+
+		card := request.PaymentMethod.Card
+		resolved := *request
+		resolved.CardNumber = card.Number
+		resolved.ExpiryMonth = card.ExpiryMonth
+		resolved.ExpiryYear = card.ExpiryYear
+		resolved.Cvv = card.Cvv
+		request = &resolved
+	}
+
+	return m.p.resolveCardToken(ctx, request, id)
+}
+
+func (m cardMethod) authorization(request *models.PostPaymentHandlerRequest, expiryDate, id string) (authorization, error) {
+	cardNumber := strconv.Itoa(request.CardNumber)
+	err := validateCardNumber(cardNumber, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// stored cards are charged without a CVV, it is only checked when the customer gave one
+	if request.CardToken == "" || request.Cvv != 0 {
+		err = validateCVV(request.Cvv, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var cvvString string
+	if request.Cvv != 0 {
+		cvvString = strconv.Itoa(request.Cvv)
+	}
+
+	return cardAuthorization{&models.PostPaymentBankRequest{
+		CardNumber:       cardNumber,
+		ExpiryDate:       expiryDate,
+		Currency:         request.Currency,
+		Amount:           request.Amount,
+		CVV:              cvvString,
+		StoredCredential: request.StoredCredential,
+		Capture:          request.Capture,
+		BillingAddress:   request.BillingAddress,
+	}}, nil
+}
+
+type cardAuthorization struct {
+	request *models.PostPaymentBankRequest
+}
+
+func (a cardAuthorization) send(ctx context.Context, bank client.Client) (*models.PostPaymentBankResponse, error) {
+	return bank.PostBankPayment(ctx, a.request)
+}
+
+func (a cardAuthorization) number() string {
+	return a.request.CardNumber
+}
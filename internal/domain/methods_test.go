@@ -0,0 +1,87 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func cardMethodRequest() models.PostPaymentHandlerRequest {
+	return models.PostPaymentHandlerRequest{
+		Currency: "GBP",
+		Amount:   100,
+		PaymentMethod: &models.PaymentMethod{
+			Type: "card",
+			Card: &models.CardDetails{
+				Number:      2222405343248877,
+				ExpiryMonth: 4,
+				ExpiryYear:  2035,
+				Cvv:         123,
+			},
+		},
+	}
+}
+
+func TestPostPayment_CardPaymentMethod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	// the bank sees the same request as for card details at the top level
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), &models.PostPaymentBankRequest{
+		CardNumber: "2222405343248877",
+		ExpiryDate: "4/2035",
+		Currency:   "GBP",
+		Amount:     100,
+		CVV:        "123",
+	}).Return(&models.PostPaymentBankResponse{Authorised: true, AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2"}, nil)
+
+	repo := repository.NewPaymentsRepository()
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	request := cardMethodRequest()
+	response, err := domain.Create(context.Background(), &request)
+	require.NoError(t, err)
+	assert.Equal(t, "authorized", response.PaymentStatus)
+	assert.Equal(t, "", response.PaymentMethod)
+	assert.Equal(t, 8877, response.CardNumberLastFour)
+	assert.Equal(t, 4, response.ExpiryMonth)
+	assert.Equal(t, 2035, response.ExpiryYear)
+}
+
+func TestPostPayment_InvalidCardPaymentMethod(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*models.PostPaymentHandlerRequest)
+		field  string
+	}{
+		{name: "TopLevelCardDetailsToo", modify: func(r *models.PostPaymentHandlerRequest) { r.CardNumber = 2222405343248877 }, field: "payment_method.card"},
+		{name: "CardTokenToo", modify: func(r *models.PostPaymentHandlerRequest) { r.CardToken = "tok_123" }, field: "payment_method.card"},
+		{name: "InvalidNumber", modify: func(r *models.PostPaymentHandlerRequest) { r.PaymentMethod.Card.Number = 1234 }, field: "card_number"},
+		{name: "InvalidCvv", modify: func(r *models.PostPaymentHandlerRequest) { r.PaymentMethod.Card.Cvv = 1 }, field: "cvv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// no bank call is expected
+			domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+
+			request := cardMethodRequest()
+			tt.modify(&request)
+			_, err := domain.Create(context.Background(), &request)
+
+			var validationErr *gatewayerrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.field, validationErr.Field)
+		})
+	}
+}
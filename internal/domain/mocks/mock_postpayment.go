@@ -1,15 +1,16 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: /Users/ehsanashouri/go/src/github.com/redscaresu/payment-gateway-challenge-go-1/internal/domain/postpayment.go
+// Source: internal/domain/create.go
 //
 // Generated by this command:
 //
-//	mockgen -source=/Users/ehsanashouri/go/src/github.com/redscaresu/payment-gateway-challenge-go-1/internal/domain/postpayment.go -destination=/Users/ehsanashouri/go/src/github.com/redscaresu/payment-gateway-challenge-go-1/internal/domain/mocks/mock_postpayment.go -package=mocks
+//	mockgen -source=internal/domain/create.go -destination=internal/domain/mocks/mock_postpayment.go -package=mocks
 //
 
 // Package mocks is a generated GoMock package.
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	models "github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
@@ -40,17 +41,362 @@ func (m *MockPaymentService) EXPECT() *MockPaymentServiceMockRecorder {
 	return m.recorder
 }
 
+// CancelInstallmentPlan mocks base method.
+func (m *MockPaymentService) CancelInstallmentPlan(ctx context.Context, id string) (*models.InstallmentPlan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelInstallmentPlan", ctx, id)
+	ret0, _ := ret[0].(*models.InstallmentPlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelInstallmentPlan indicates an expected call of CancelInstallmentPlan.
+func (mr *MockPaymentServiceMockRecorder) CancelInstallmentPlan(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelInstallmentPlan", reflect.TypeOf((*MockPaymentService)(nil).CancelInstallmentPlan), ctx, id)
+}
+
+// CancelPayment mocks base method.
+func (m *MockPaymentService) CancelPayment(ctx context.Context, id string) (*models.PostPaymentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelPayment", ctx, id)
+	ret0, _ := ret[0].(*models.PostPaymentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelPayment indicates an expected call of CancelPayment.
+func (mr *MockPaymentServiceMockRecorder) CancelPayment(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelPayment", reflect.TypeOf((*MockPaymentService)(nil).CancelPayment), ctx, id)
+}
+
+// CapturePayment mocks base method.
+func (m *MockPaymentService) CapturePayment(ctx context.Context, id string, amount int) (*models.PostPaymentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CapturePayment", ctx, id, amount)
+	ret0, _ := ret[0].(*models.PostPaymentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CapturePayment indicates an expected call of CapturePayment.
+func (mr *MockPaymentServiceMockRecorder) CapturePayment(ctx, id, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CapturePayment", reflect.TypeOf((*MockPaymentService)(nil).CapturePayment), ctx, id, amount)
+}
+
+// ConfirmPaymentIntent mocks base method.
+func (m *MockPaymentService) ConfirmPaymentIntent(ctx context.Context, id string, request *models.ConfirmPaymentIntentRequest) (*models.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmPaymentIntent", ctx, id, request)
+	ret0, _ := ret[0].(*models.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmPaymentIntent indicates an expected call of ConfirmPaymentIntent.
+func (mr *MockPaymentServiceMockRecorder) ConfirmPaymentIntent(ctx, id, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmPaymentIntent", reflect.TypeOf((*MockPaymentService)(nil).ConfirmPaymentIntent), ctx, id, request)
+}
+
 // Create mocks base method.
-func (m *MockPaymentService) Create(request *models.PostPaymentHandlerRequest) (*models.PostPaymentResponse, error) {
+func (m *MockPaymentService) Create(ctx context.Context, request *models.PostPaymentHandlerRequest) (*models.PostPaymentResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", request)
+	ret := m.ctrl.Call(m, "Create", ctx, request)
 	ret0, _ := ret[0].(*models.PostPaymentResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockPaymentServiceMockRecorder) Create(request any) *gomock.Call {
+func (mr *MockPaymentServiceMockRecorder) Create(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPaymentService)(nil).Create), ctx, request)
+}
+
+// CreateInstallmentPlan mocks base method.
+func (m *MockPaymentService) CreateInstallmentPlan(ctx context.Context, request *models.CreateInstallmentPlanRequest) (*models.InstallmentPlan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInstallmentPlan", ctx, request)
+	ret0, _ := ret[0].(*models.InstallmentPlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInstallmentPlan indicates an expected call of CreateInstallmentPlan.
+func (mr *MockPaymentServiceMockRecorder) CreateInstallmentPlan(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInstallmentPlan", reflect.TypeOf((*MockPaymentService)(nil).CreateInstallmentPlan), ctx, request)
+}
+
+// CreatePaymentIntent mocks base method.
+func (m *MockPaymentService) CreatePaymentIntent(ctx context.Context, request *models.CreatePaymentIntentRequest) (*models.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePaymentIntent", ctx, request)
+	ret0, _ := ret[0].(*models.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePaymentIntent indicates an expected call of CreatePaymentIntent.
+func (mr *MockPaymentServiceMockRecorder) CreatePaymentIntent(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePaymentIntent", reflect.TypeOf((*MockPaymentService)(nil).CreatePaymentIntent), ctx, request)
+}
+
+// CreatePaymentLink mocks base method.
+func (m *MockPaymentService) CreatePaymentLink(ctx context.Context, request *models.CreatePaymentLinkRequest) (*models.PaymentLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePaymentLink", ctx, request)
+	ret0, _ := ret[0].(*models.PaymentLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePaymentLink indicates an expected call of CreatePaymentLink.
+func (mr *MockPaymentServiceMockRecorder) CreatePaymentLink(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePaymentLink", reflect.TypeOf((*MockPaymentService)(nil).CreatePaymentLink), ctx, request)
+}
+
+// GetDisputeEvidenceBundle mocks base method.
+func (m *MockPaymentService) GetDisputeEvidenceBundle(ctx context.Context, id string) (*models.EvidenceBundle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDisputeEvidenceBundle", ctx, id)
+	ret0, _ := ret[0].(*models.EvidenceBundle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDisputeEvidenceBundle indicates an expected call of GetDisputeEvidenceBundle.
+func (mr *MockPaymentServiceMockRecorder) GetDisputeEvidenceBundle(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDisputeEvidenceBundle", reflect.TypeOf((*MockPaymentService)(nil).GetDisputeEvidenceBundle), ctx, id)
+}
+
+// GetInstallmentPlan mocks base method.
+func (m *MockPaymentService) GetInstallmentPlan(ctx context.Context, id string) (*models.InstallmentPlan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInstallmentPlan", ctx, id)
+	ret0, _ := ret[0].(*models.InstallmentPlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInstallmentPlan indicates an expected call of GetInstallmentPlan.
+func (mr *MockPaymentServiceMockRecorder) GetInstallmentPlan(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstallmentPlan", reflect.TypeOf((*MockPaymentService)(nil).GetInstallmentPlan), ctx, id)
+}
+
+// GetPaymentIntent mocks base method.
+func (m *MockPaymentService) GetPaymentIntent(ctx context.Context, id string) (*models.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentIntent", ctx, id)
+	ret0, _ := ret[0].(*models.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentIntent indicates an expected call of GetPaymentIntent.
+func (mr *MockPaymentServiceMockRecorder) GetPaymentIntent(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentIntent", reflect.TypeOf((*MockPaymentService)(nil).GetPaymentIntent), ctx, id)
+}
+
+// GetPaymentLink mocks base method.
+func (m *MockPaymentService) GetPaymentLink(ctx context.Context, id string) (*models.PaymentLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentLink", ctx, id)
+	ret0, _ := ret[0].(*models.PaymentLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentLink indicates an expected call of GetPaymentLink.
+func (mr *MockPaymentServiceMockRecorder) GetPaymentLink(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentLink", reflect.TypeOf((*MockPaymentService)(nil).GetPaymentLink), ctx, id)
+}
+
+// ListDisputes mocks base method.
+func (m *MockPaymentService) ListDisputes(ctx context.Context, paymentID string) ([]models.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDisputes", ctx, paymentID)
+	ret0, _ := ret[0].([]models.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDisputes indicates an expected call of ListDisputes.
+func (mr *MockPaymentServiceMockRecorder) ListDisputes(ctx, paymentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDisputes", reflect.TypeOf((*MockPaymentService)(nil).ListDisputes), ctx, paymentID)
+}
+
+// OpenDispute mocks base method.
+func (m *MockPaymentService) OpenDispute(ctx context.Context, request *models.OpenDisputeRequest) (*models.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenDispute", ctx, request)
+	ret0, _ := ret[0].(*models.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenDispute indicates an expected call of OpenDispute.
+func (mr *MockPaymentServiceMockRecorder) OpenDispute(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenDispute", reflect.TypeOf((*MockPaymentService)(nil).OpenDispute), ctx, request)
+}
+
+// PayPaymentLink mocks base method.
+func (m *MockPaymentService) PayPaymentLink(ctx context.Context, id string, request *models.PayPaymentLinkRequest) (*models.PostPaymentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PayPaymentLink", ctx, id, request)
+	ret0, _ := ret[0].(*models.PostPaymentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PayPaymentLink indicates an expected call of PayPaymentLink.
+func (mr *MockPaymentServiceMockRecorder) PayPaymentLink(ctx, id, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PayPaymentLink", reflect.TypeOf((*MockPaymentService)(nil).PayPaymentLink), ctx, id, request)
+}
+
+// RefundPayment mocks base method.
+func (m *MockPaymentService) RefundPayment(ctx context.Context, id string, amount int) (*models.PostPaymentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundPayment", ctx, id, amount)
+	ret0, _ := ret[0].(*models.PostPaymentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefundPayment indicates an expected call of RefundPayment.
+func (mr *MockPaymentServiceMockRecorder) RefundPayment(ctx, id, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundPayment", reflect.TypeOf((*MockPaymentService)(nil).RefundPayment), ctx, id, amount)
+}
+
+// ResolveDispute mocks base method.
+func (m *MockPaymentService) ResolveDispute(ctx context.Context, id, outcome string) (*models.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveDispute", ctx, id, outcome)
+	ret0, _ := ret[0].(*models.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveDispute indicates an expected call of ResolveDispute.
+func (mr *MockPaymentServiceMockRecorder) ResolveDispute(ctx, id, outcome any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveDispute", reflect.TypeOf((*MockPaymentService)(nil).ResolveDispute), ctx, id, outcome)
+}
+
+// RetryPayment mocks base method.
+func (m *MockPaymentService) RetryPayment(ctx context.Context, id string, cvv int) (*models.PostPaymentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryPayment", ctx, id, cvv)
+	ret0, _ := ret[0].(*models.PostPaymentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetryPayment indicates an expected call of RetryPayment.
+func (mr *MockPaymentServiceMockRecorder) RetryPayment(ctx, id, cvv any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryPayment", reflect.TypeOf((*MockPaymentService)(nil).RetryPayment), ctx, id, cvv)
+}
+
+// ReversePayment mocks base method.
+func (m *MockPaymentService) ReversePayment(ctx context.Context, id string) (*models.PostPaymentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReversePayment", ctx, id)
+	ret0, _ := ret[0].(*models.PostPaymentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReversePayment indicates an expected call of ReversePayment.
+func (mr *MockPaymentServiceMockRecorder) ReversePayment(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReversePayment", reflect.TypeOf((*MockPaymentService)(nil).ReversePayment), ctx, id)
+}
+
+// StoreCard mocks base method.
+func (m *MockPaymentService) StoreCard(ctx context.Context, customerID string, request *models.StoreCardRequest) (*models.StoredCard, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreCard", ctx, customerID, request)
+	ret0, _ := ret[0].(*models.StoredCard)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreCard indicates an expected call of StoreCard.
+func (mr *MockPaymentServiceMockRecorder) StoreCard(ctx, customerID, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreCard", reflect.TypeOf((*MockPaymentService)(nil).StoreCard), ctx, customerID, request)
+}
+
+// Submit mocks base method.
+func (m *MockPaymentService) Submit(ctx context.Context, request *models.PostPaymentHandlerRequest) (*models.PostPaymentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Submit", ctx, request)
+	ret0, _ := ret[0].(*models.PostPaymentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Submit indicates an expected call of Submit.
+func (mr *MockPaymentServiceMockRecorder) Submit(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submit", reflect.TypeOf((*MockPaymentService)(nil).Submit), ctx, request)
+}
+
+// SubmitDisputeEvidence mocks base method.
+func (m *MockPaymentService) SubmitDisputeEvidence(ctx context.Context, id, evidence string) (*models.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitDisputeEvidence", ctx, id, evidence)
+	ret0, _ := ret[0].(*models.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitDisputeEvidence indicates an expected call of SubmitDisputeEvidence.
+func (mr *MockPaymentServiceMockRecorder) SubmitDisputeEvidence(ctx, id, evidence any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitDisputeEvidence", reflect.TypeOf((*MockPaymentService)(nil).SubmitDisputeEvidence), ctx, id, evidence)
+}
+
+// VerifyCard mocks base method.
+func (m *MockPaymentService) VerifyCard(ctx context.Context, request *models.CardVerificationRequest) (*models.CardVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyCard", ctx, request)
+	ret0, _ := ret[0].(*models.CardVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyCard indicates an expected call of VerifyCard.
+func (mr *MockPaymentServiceMockRecorder) VerifyCard(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyCard", reflect.TypeOf((*MockPaymentService)(nil).VerifyCard), ctx, request)
+}
+
+// VoidPayment mocks base method.
+func (m *MockPaymentService) VoidPayment(ctx context.Context, id string) (*models.PostPaymentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidPayment", ctx, id)
+	ret0, _ := ret[0].(*models.PostPaymentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VoidPayment indicates an expected call of VoidPayment.
+func (mr *MockPaymentServiceMockRecorder) VoidPayment(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPaymentService)(nil).Create), request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidPayment", reflect.TypeOf((*MockPaymentService)(nil).VoidPayment), ctx, id)
 }
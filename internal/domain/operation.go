@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+/*
+operation describes something done to a payment that already exists, such as a capture or a void.  Every operation follows the same steps: lock the payment, check its status allows the operation, ask the acquiring bank, and only once the bank has agreed record the change and announce it.
+*/
+
+type operation struct {
+	// name is what the operation is called in errors, such as capture.
+	name string
+	// check returns a StateError if the payment cannot go through the operation.
+	check func(payment *models.PostPaymentResponse) error
+	// bankCall asks the acquiring bank to perform the operation.
+	bankCall func(ctx context.Context, payment *models.PostPaymentResponse) error
+	// apply records the outcome on the payment.
+	apply func(payment *models.PostPaymentResponse)
+	event events.Type
+}
+
+func (p *PaymentServiceImpl) run(ctx context.Context, id string, op operation) (*models.PostPaymentResponse, error) {
+	unlock := p.locks.lock(id)
+	defer unlock()
+
+	payment, err := p.repo.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, gatewayerrors.ErrPaymentNotFound
+	}
+
+	if err := op.check(payment); err != nil {
+		return nil, err
+	}
+
+	// no point calling the bank if the caller has already given up
+	if err := ctx.Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, gatewayerrors.NewTimeoutError(err, false)
+		}
+		return nil, err
+	}
+
+	if err := op.bankCall(ctx, payment); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, gatewayerrors.NewTimeoutError(err, true)
+		}
+		return nil, err
+	}
+
+	from := Status(payment.PaymentStatus)
+	op.apply(payment)
+
+	// check has already ruled this out, an operation that gets here has moved the payment somewhere it cannot go
+	if to := Status(payment.PaymentStatus); !from.CanTransitionTo(to) {
+		return nil, gatewayerrors.NewStateError(
+			gatewayerrors.NewTransitionError(op.name, string(from), string(to)),
+			id,
+			string(from),
+		)
+	}
+
+	// the bank has acted by now so the record must be kept even if the caller's budget is spent
+	if err := p.repo.UpdatePayment(context.WithoutCancel(ctx), *payment); err != nil {
+		return nil, err
+	}
+	p.bus.Publish(events.NewEvent(op.event, *payment))
+
+	return payment, nil
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/google/uuid"
+)
+
+// RefundPayment returns amount to the card of a captured payment and records the refund against the payment.  An
+// amount of 0 refunds whatever has not been refunded yet, the payment is refunded once nothing is left.
+func (p *PaymentServiceImpl) RefundPayment(ctx context.Context, id string, amount int) (*models.PostPaymentResponse, error) {
+	if amount < 0 {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("invalid refund amount"),
+			id,
+			"amount",
+		)
+	}
+
+	return p.run(ctx, id, operation{
+		name: "refund",
+		check: func(payment *models.PostPaymentResponse) error {
+			if err := transition(id, "refund", Status(payment.PaymentStatus), StatusPartiallyRefunded, StatusRefunded); err != nil {
+				return err
+			}
+			if amount > refundable(payment) {
+				return gatewayerrors.NewStateError(
+					fmt.Errorf("cannot refund %d, only %d is left to refund", amount, refundable(payment)),
+					id,
+					payment.PaymentStatus,
+				)
+			}
+			// resolved here, under the payment lock, so it cannot race another refund
+			if amount == 0 {
+				amount = refundable(payment)
+			}
+			return nil
+		},
+		bankCall: func(ctx context.Context, payment *models.PostPaymentResponse) error {
+			bankResponse, err := p.client.RefundBankPayment(ctx, &models.RefundBankRequest{
+				AuthorizationCode: payment.AuthorizationCode,
+				Amount:            amount,
+			})
+			if err != nil {
+				return err
+			}
+			if !bankResponse.Refunded {
+				return gatewayerrors.NewBankError(
+					errors.New("acquiring bank refused the refund"),
+					http.StatusBadGateway,
+				)
+			}
+			return nil
+		},
+		apply: func(payment *models.PostPaymentResponse) {
+			payment.Refunds = append(payment.Refunds, models.Refund{
+				Id:        uuid.New().String(),
+				Amount:    amount,
+				CreatedAt: time.Now().UTC(),
+			})
+			payment.PaymentStatus = string(StatusPartiallyRefunded)
+			if refundable(payment) == 0 {
+				payment.PaymentStatus = string(StatusRefunded)
+			}
+		},
+		event: events.PaymentRefunded,
+	})
+}
+
+// refundable is how much of the captured amount has not been refunded yet.
+func refundable(payment *models.PostPaymentResponse) int {
+	left := captured(payment)
+	for _, refund := range payment.Refunds {
+		left -= refund.Amount
+	}
+	return left
+}
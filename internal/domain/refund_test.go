@@ -0,0 +1,133 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func capturedPayment() models.PostPaymentResponse {
+	payment := authorizedPayment()
+	payment.PaymentStatus = "captured"
+	payment.ExpiresAt = nil
+	return payment
+}
+
+func TestRefundPayment_Captured(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), capturedPayment()))
+
+	mockClient.EXPECT().RefundBankPayment(gomock.Any(), &models.RefundBankRequest{
+		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+		Amount:            100,
+	}).Return(&models.RefundBankResponse{Refunded: true}, nil)
+
+	bus := events.NewInMemoryBus()
+	var published []events.Event
+	bus.Subscribe(events.PaymentRefunded, func(event events.Event) {
+		published = append(published, event)
+	})
+
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, bus)
+
+	response, err := domain.RefundPayment(context.Background(), "test-id", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "refunded", response.PaymentStatus)
+	require.Len(t, response.Refunds, 1)
+	_, err = uuid.Parse(response.Refunds[0].Id)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, response.Refunds[0].Amount)
+	assert.False(t, response.Refunds[0].CreatedAt.IsZero())
+
+	dbPayment, err := repo.GetPayment(context.Background(), "test-id")
+	require.NoError(t, err)
+	assert.Equal(t, response, dbPayment)
+	require.Len(t, published, 1)
+}
+
+func TestRefundPayment_RejectedStates(t *testing.T) {
+	for _, status := range []string{"authorized", "declined", "voided", "refunded"} {
+		t.Run(status, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			// no bank call is expected
+			mockClient := mocks.NewMockClient(ctrl)
+
+			payment := capturedPayment()
+			payment.PaymentStatus = status
+			repo := repository.NewPaymentsRepository()
+			require.NoError(t, repo.AddPayment(context.Background(), payment))
+
+			domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+			response, err := domain.RefundPayment(context.Background(), "test-id", 0)
+			require.Nil(t, response)
+			var stateErr *gatewayerrors.StateError
+			require.ErrorAs(t, err, &stateErr)
+			assert.Equal(t, status, stateErr.Status)
+		})
+	}
+}
+
+func TestRefundPayment_PartialRefundsUntilExhausted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), capturedPayment()))
+
+	gomock.InOrder(
+		mockClient.EXPECT().RefundBankPayment(gomock.Any(), &models.RefundBankRequest{
+			AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+			Amount:            30,
+		}).Return(&models.RefundBankResponse{Refunded: true}, nil),
+		mockClient.EXPECT().RefundBankPayment(gomock.Any(), &models.RefundBankRequest{
+			AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+			Amount:            70,
+		}).Return(&models.RefundBankResponse{Refunded: true}, nil),
+	)
+
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	response, err := domain.RefundPayment(context.Background(), "test-id", 30)
+	require.NoError(t, err)
+	assert.Equal(t, "partially_refunded", response.PaymentStatus)
+
+	_, err = domain.RefundPayment(context.Background(), "test-id", 71)
+	var stateErr *gatewayerrors.StateError
+	require.ErrorAs(t, err, &stateErr)
+	assert.Equal(t, "cannot refund 71, only 70 is left to refund", stateErr.Error())
+
+	response, err = domain.RefundPayment(context.Background(), "test-id", 70)
+	require.NoError(t, err)
+	assert.Equal(t, "refunded", response.PaymentStatus)
+	require.Len(t, response.Refunds, 2)
+	assert.Equal(t, 30, response.Refunds[0].Amount)
+	assert.Equal(t, 70, response.Refunds[1].Amount)
+	assert.NotEqual(t, response.Refunds[0].Id, response.Refunds[1].Id)
+}
+
+func TestRefundPayment_InvalidAmount(t *testing.T) {
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, nil)
+
+	_, err := domain.RefundPayment(context.Background(), "test-id", -1)
+
+	var validationError *gatewayerrors.ValidationError
+	require.ErrorAs(t, err, &validationError)
+	assert.Equal(t, "amount", validationError.GetFieldError())
+}
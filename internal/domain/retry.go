@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/redact"
+)
+
+/*
+A declined payment can be sent to the bank again without the customer re-entering their card, for example once they have asked their bank to allow it.  The card details of a declined payment are held in memory for retryWindow and never stored with the payment; after that the merchant has to submit a new payment.
+
+The CVV is not held, as it may not be kept once the bank has answered, so a retry of a payment made with card details needs it again.  A stored card is charged without one.  Network token and wallet payments carry a cryptogram that is only good once and cannot be retried at all.  The held card number is masked wherever the held requests are printed or encoded.
+
+Each retry is a new payment whose retry_of names the attempt it retried, and the retried attempt lists it under retries, so following the links gives the whole chain.  An attempt can only be retried once, later retries go through the latest attempt.
+*/
+
+// retryWindow is how long the card details of a declined payment are held for a retry.
+const retryWindow = 15 * time.Minute
+
+// RetryPayment sends a declined payment to the bank again with the same card details and returns the new attempt.  cvv
+// is required for payments made with card details and optional for stored cards.
+func (p *PaymentServiceImpl) RetryPayment(ctx context.Context, id string, cvv int) (*models.PostPaymentResponse, error) {
+	unlock := p.locks.lock(id)
+	defer unlock()
+
+	payment, err := p.repo.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, gatewayerrors.ErrPaymentNotFound
+	}
+
+	if Status(payment.PaymentStatus) != StatusDeclined {
+		return nil, gatewayerrors.NewStateError(
+			fmt.Errorf("cannot retry a %s payment", payment.PaymentStatus),
+			id,
+			payment.PaymentStatus,
+		)
+	}
+	if len(payment.Retries) > 0 {
+		return nil, gatewayerrors.NewStateError(
+			fmt.Errorf("payment was already retried by %s", payment.Retries[len(payment.Retries)-1]),
+			id,
+			payment.PaymentStatus,
+		)
+	}
+
+	if payment.PaymentMethod != "" {
+		return nil, gatewayerrors.NewStateError(
+			fmt.Errorf("%s payments cannot be retried, submit a new payment", payment.PaymentMethod),
+			id,
+			payment.PaymentStatus,
+		)
+	}
+
+	request, ok := p.held.get(id, time.Now())
+	if !ok {
+		return nil, gatewayerrors.NewStateError(
+			errors.New("card details are no longer held, submit a new payment"),
+			id,
+			payment.PaymentStatus,
+		)
+	}
+
+	if request.CardToken == "" && cvv == 0 {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("the cvv must be sent again to retry a card payment"),
+			id,
+			"cvv",
+		)
+	}
+	withCVV(&request, cvv)
+
+	// a retry is made now, even if the declined payment was scheduled
+	request.ExecuteAt = nil
+	retry, err := p.create(ctx, &request, id)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.Retries = append(payment.Retries, retry.Id)
+	// the retry has been made so the link must be kept even if the caller's budget is spent
+	if err := p.repo.UpdatePayment(context.WithoutCancel(ctx), *payment); err != nil {
+		return nil, err
+	}
+	p.held.forget(id)
+
+	return retry, nil
+}
+
+// heldRequests keeps the requests of declined payments, card details but not the CVV, until they can no longer be retried.
+type heldRequests struct {
+	mu       sync.Mutex
+	requests map[string]heldRequest
+}
+
+type heldRequest struct {
+	request models.PostPaymentHandlerRequest
+	until   time.Time
+}
+
+// hold keeps a card payment's request for a retry, without its CVV.
+func (h *heldRequests) hold(id string, request models.PostPaymentHandlerRequest, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.requests == nil {
+		h.requests = map[string]heldRequest{}
+	}
+	// drop what can no longer be retried so card details are not kept longer than they need to be
+	for heldID, held := range h.requests {
+		if !now.Before(held.until) {
+			delete(h.requests, heldID)
+		}
+	}
+	withCVV(&request, 0)
+	h.requests[id] = heldRequest{request: request, until: now.Add(retryWindow)}
+}
+
+func (h *heldRequests) get(id string, now time.Time) (models.PostPaymentHandlerRequest, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	held, ok := h.requests[id]
+	if !ok || !now.Before(held.until) {
+		return models.PostPaymentHandlerRequest{}, false
+	}
+	return held.request, true
+}
+
+func (h *heldRequests) forget(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.requests, id)
+}
+
+// Format prints the held requests with their card numbers masked.
+func (h *heldRequests) Format(f fmt.State, verb rune) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(f, "%v", h.requests)
+}
+
+// Format prints the held request with its card number masked.
+func (h heldRequest) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, "{request:%v until:%s}", redact.Value(h.request, redact.Log), h.until.Format(time.RFC3339))
+}
+
+// MarshalJSON encodes the held request with its card number masked.
+func (h heldRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"request": redact.Value(h.request, redact.Log),
+		"until":   h.until,
+	})
+}
+
+// withCVV sets the CVV wherever the request carries its card details, the payment_method's card is copied rather than
+// changed in place as it is shared with the caller's request.
+func withCVV(request *models.PostPaymentHandlerRequest, cvv int) {
+	if request.PaymentMethod != nil && request.PaymentMethod.Card != nil {
+		method := *request.PaymentMethod
+		card := *method.Card
+		card.Cvv = cvv
+		method.Card = &card
+		request.PaymentMethod = &method
+		return
+	}
+	request.Cvv = cvv
+}
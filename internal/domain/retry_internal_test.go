@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeldRequests_NoCVVAndMasked(t *testing.T) {
+	held := &heldRequests{}
+	now := time.Now()
+
+	submitted := models.PostPaymentHandlerRequest{
+		PaymentMethod: &models.PaymentMethod{
+			Type: paymentMethodCard,
+			Card: &models.CardDetails{Number: 2222405343248877, ExpiryMonth: 4, ExpiryYear: 2035, Cvv: 123},
+		},
+		Currency: "GBP",
+		Amount:   100,
+	}
+	held.hold("declined", submitted, now)
+
+	request, ok := held.get("declined", now)
+	require.True(t, ok)
+	assert.Zero(t, request.PaymentMethod.Card.Cvv, "the cvv is not kept after the decline")
+	assert.Equal(t, 2222405343248877, request.PaymentMethod.Card.Number, "the card number is kept for the retry")
+	assert.Equal(t, 123, submitted.PaymentMethod.Card.Cvv, "the caller's request is left alone")
+
+	printed := fmt.Sprintf("%v %+v %#v", held, held.requests["declined"], held.requests)
+	assert.NotContains(t, printed, "2222405343248877")
+
+	encoded, err := json.Marshal(held.requests)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), "2222405343248877")
+	assert.Contains(t, string(encoded), "8877")
+}
@@ -0,0 +1,107 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRetryPayment_DeclinedThenAuthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	bankRequest := &models.PostPaymentBankRequest{
+		CardNumber: "2222405343248877",
+		ExpiryDate: "4/2035",
+		Currency:   "GBP",
+		Amount:     100,
+		CVV:        "123",
+	}
+	gomock.InOrder(
+		mockClient.EXPECT().PostBankPayment(gomock.Any(), bankRequest).Return(&models.PostPaymentBankResponse{Authorised: false}, nil),
+		mockClient.EXPECT().PostBankPayment(gomock.Any(), bankRequest).Return(&models.PostPaymentBankResponse{
+			Authorised:        true,
+			AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+		}, nil),
+	)
+
+	repo := repository.NewPaymentsRepository()
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	declined, err := domain.Create(context.Background(), &models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "declined", declined.PaymentStatus)
+
+	_, err = domain.RetryPayment(context.Background(), declined.Id, 0)
+	var validationErr *gatewayerrors.ValidationError
+	require.ErrorAs(t, err, &validationErr, "the cvv is not held so it must be sent again")
+	assert.Equal(t, "cvv", validationErr.GetFieldError())
+
+	retry, err := domain.RetryPayment(context.Background(), declined.Id, 123)
+	require.NoError(t, err)
+	assert.Equal(t, "authorized", retry.PaymentStatus)
+	assert.Equal(t, declined.Id, retry.RetryOf)
+	assert.NotEqual(t, declined.Id, retry.Id)
+
+	original, err := repo.GetPayment(context.Background(), declined.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{retry.Id}, original.Retries)
+
+	_, err = domain.RetryPayment(context.Background(), declined.Id, 123)
+	var stateErr *gatewayerrors.StateError
+	require.ErrorAs(t, err, &stateErr, "an attempt can only be retried once")
+
+	_, err = domain.RetryPayment(context.Background(), retry.Id, 123)
+	require.ErrorAs(t, err, &stateErr, "authorized payments cannot be retried")
+}
+
+func TestRetryPayment_CardDetailsNotHeld(t *testing.T) {
+	declined := authorizedPayment()
+	declined.PaymentStatus = "declined"
+
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), declined))
+
+	domain := domain.NewPaymentServiceImpl(repo, nil, events.NewInMemoryBus())
+
+	_, err := domain.RetryPayment(context.Background(), declined.Id, 123)
+	var stateErr *gatewayerrors.StateError
+	require.ErrorAs(t, err, &stateErr)
+	assert.Contains(t, err.Error(), "no longer held")
+
+	_, err = domain.RetryPayment(context.Background(), "missing", 123)
+	assert.ErrorIs(t, err, gatewayerrors.ErrPaymentNotFound)
+}
+
+func TestRetryPayment_NetworkToken(t *testing.T) {
+	declined := authorizedPayment()
+	declined.PaymentStatus = "declined"
+	declined.PaymentMethod = "network_token"
+
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), declined))
+
+	domain := domain.NewPaymentServiceImpl(repo, nil, events.NewInMemoryBus())
+
+	_, err := domain.RetryPayment(context.Background(), declined.Id, 123)
+	var stateErr *gatewayerrors.StateError
+	require.ErrorAs(t, err, &stateErr)
+	assert.Contains(t, err.Error(), "cannot be retried")
+}
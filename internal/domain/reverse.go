@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+/*
+A reversal cancels a capture before the acquiring bank has settled it, so the money never leaves the cardholder's account, where a refund pays it back after it has been settled.  The bank settles each day's captures at midnight UTC, so a payment can only be reversed on the day it was first captured.  After that ReversePayment refunds whatever has not been refunded instead, the payment's status says which one happened.
+*/
+
+// ReversePayment undoes a captured payment, reversing it if it has not been settled yet and refunding it otherwise.
+func (p *PaymentServiceImpl) ReversePayment(ctx context.Context, id string) (*models.PostPaymentResponse, error) {
+	payment, err := p.repo.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, gatewayerrors.ErrPaymentNotFound
+	}
+
+	if !reversible(payment, time.Now()) {
+		if err := transition(id, "reverse", Status(payment.PaymentStatus), StatusReversed, StatusRefunded); err != nil {
+			return nil, err
+		}
+		return p.RefundPayment(ctx, id, 0)
+	}
+
+	return p.run(ctx, id, operation{
+		name: "reverse",
+		check: func(payment *models.PostPaymentResponse) error {
+			if err := transition(id, "reverse", Status(payment.PaymentStatus), StatusReversed); err != nil {
+				return err
+			}
+			// decided again under the payment lock, the day may have ended since
+			if !reversible(payment, time.Now()) {
+				return gatewayerrors.NewStateError(
+					errors.New("payment was settled before it could be reversed, refund it instead"),
+					id,
+					payment.PaymentStatus,
+				)
+			}
+			return nil
+		},
+		bankCall: func(ctx context.Context, payment *models.PostPaymentResponse) error {
+			bankResponse, err := p.client.ReverseBankPayment(ctx, &models.ReverseBankRequest{
+				AuthorizationCode: payment.AuthorizationCode,
+				Amount:            captured(payment),
+			})
+			if err != nil {
+				return err
+			}
+			if !bankResponse.Reversed {
+				return gatewayerrors.NewBankError(
+					errors.New("acquiring bank refused the reversal"),
+					http.StatusBadGateway,
+				)
+			}
+			return nil
+		},
+		apply: func(payment *models.PostPaymentResponse) {
+			payment.PaymentStatus = string(StatusReversed)
+		},
+		event: events.PaymentReversed,
+	})
+}
+
+// reversible reports whether the payment is captured and the bank has not settled it by now.  Payments captured
+// before the capture time was recorded are treated as settled.
+func reversible(payment *models.PostPaymentResponse, now time.Time) bool {
+	if !Status(payment.PaymentStatus).CanTransitionTo(StatusReversed) || payment.CapturedAt == nil {
+		return false
+	}
+	capturedOn := payment.CapturedAt.UTC().Truncate(24 * time.Hour)
+	return capturedOn.Equal(now.UTC().Truncate(24 * time.Hour))
+}
@@ -0,0 +1,123 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReversePayment_CapturedToday(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	payment := capturedPayment()
+	capturedAt := time.Now().UTC()
+	payment.CapturedAt = &capturedAt
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), payment))
+
+	mockClient.EXPECT().ReverseBankPayment(gomock.Any(), &models.ReverseBankRequest{
+		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+		Amount:            100,
+	}).Return(&models.ReverseBankResponse{Reversed: true}, nil)
+
+	bus := events.NewInMemoryBus()
+	var published []events.Event
+	bus.Subscribe(events.PaymentReversed, func(event events.Event) {
+		published = append(published, event)
+	})
+
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, bus)
+
+	response, err := domain.ReversePayment(context.Background(), "test-id")
+	require.NoError(t, err)
+	assert.Equal(t, "reversed", response.PaymentStatus)
+	assert.Empty(t, response.Refunds)
+
+	dbPayment, err := repo.GetPayment(context.Background(), "test-id")
+	require.NoError(t, err)
+	assert.Equal(t, "reversed", dbPayment.PaymentStatus)
+	require.Len(t, published, 1)
+}
+
+func TestReversePayment_SettledIsRefunded(t *testing.T) {
+	yesterday := time.Now().UTC().Add(-24 * time.Hour)
+
+	tests := []struct {
+		name       string
+		capturedAt *time.Time
+	}{
+		{name: "CapturedYesterday", capturedAt: &yesterday},
+		{name: "CaptureTimeUnknown", capturedAt: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockClient := mocks.NewMockClient(ctrl)
+
+			payment := capturedPayment()
+			payment.CapturedAt = tt.capturedAt
+			repo := repository.NewPaymentsRepository()
+			require.NoError(t, repo.AddPayment(context.Background(), payment))
+
+			mockClient.EXPECT().RefundBankPayment(gomock.Any(), &models.RefundBankRequest{
+				AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+				Amount:            100,
+			}).Return(&models.RefundBankResponse{Refunded: true}, nil)
+
+			domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+			response, err := domain.ReversePayment(context.Background(), "test-id")
+			require.NoError(t, err)
+			assert.Equal(t, "refunded", response.PaymentStatus)
+			require.Len(t, response.Refunds, 1)
+		})
+	}
+}
+
+func TestReversePayment_RejectedStates(t *testing.T) {
+	for _, status := range []string{"authorized", "declined", "voided", "refunded", "reversed"} {
+		t.Run(status, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			// no bank call is expected
+			mockClient := mocks.NewMockClient(ctrl)
+
+			payment := capturedPayment()
+			payment.PaymentStatus = status
+			capturedAt := time.Now().UTC()
+			payment.CapturedAt = &capturedAt
+			repo := repository.NewPaymentsRepository()
+			require.NoError(t, repo.AddPayment(context.Background(), payment))
+
+			domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+			response, err := domain.ReversePayment(context.Background(), "test-id")
+			require.Nil(t, response)
+			var transitionErr *gatewayerrors.TransitionError
+			require.ErrorAs(t, err, &transitionErr)
+			assert.Equal(t, "cannot reverse a "+status+" payment", transitionErr.Error())
+		})
+	}
+}
+
+func TestReversePayment_NotFound(t *testing.T) {
+	domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+
+	response, err := domain.ReversePayment(context.Background(), "missing")
+	require.Nil(t, response)
+	assert.ErrorIs(t, err, gatewayerrors.ErrPaymentNotFound)
+}
@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+/*
+A payment with an execute_at is validated straight away and stored as scheduled, and the scheduler sends it to the bank once it falls due.  Until then it can be cancelled with DELETE /api/payments/{id}.  A scheduled payment the bank could not take ends up failed, as an async one does.
+
+The card details of a scheduled payment are held in memory until it is sent, never stored with the payment, so scheduled payments do not survive a restart.  A payment can be scheduled up to maxScheduleAhead in advance.
+*/
+
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+// schedule stores a validated payment as scheduled and holds it until executeAt.
+func (p *PaymentServiceImpl) schedule(ctx context.Context, pending pendingPayment, executeAt time.Time) (*models.PostPaymentResponse, error) {
+	request := pending.request
+	executeAt = executeAt.UTC()
+	payment := &models.PostPaymentResponse{
+		Id:                 pending.id,
+		PaymentStatus:      string(StatusScheduled),
+		CardNumberLastFour: lastFour(request),
+		ExpiryMonth:        request.ExpiryMonth,
+		ExpiryYear:         request.ExpiryYear,
+		Currency:           request.Currency,
+		Amount:             request.Amount,
+		StoredCredential:   request.StoredCredential,
+		CardToken:          request.CardToken,
+		CaptureMethod:      captureMethodDelayed,
+		PaymentMethod:      recordedPaymentMethod(request),
+		ExecuteAt:          &executeAt,
+	}
+	if request.Capture {
+		payment.CaptureMethod = captureMethodImmediate
+	}
+
+	if err := p.repo.AddPayment(ctx, *payment); err != nil {
+		return nil, err
+	}
+	p.scheduled.add(pending, executeAt)
+	p.bus.Publish(events.NewEvent(events.PaymentScheduled, *payment))
+
+	return payment, nil
+}
+
+// RunScheduler sends scheduled payments to the bank as they fall due, checking every interval until ctx is done.
+func (p *PaymentServiceImpl) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			p.ExecuteScheduled(ctx, now)
+		}
+	}
+}
+
+// ExecuteScheduled sends every scheduled payment due by now to the bank and returns how many it sent.
+func (p *PaymentServiceImpl) ExecuteScheduled(ctx context.Context, now time.Time) int {
+	due := p.scheduled.due(now)
+	for _, pending := range due {
+		p.process(ctx, pending, StatusScheduled)
+	}
+	return len(due)
+}
+
+// CancelPayment cancels a scheduled payment before it is sent to the bank.
+func (p *PaymentServiceImpl) CancelPayment(ctx context.Context, id string) (*models.PostPaymentResponse, error) {
+	return p.run(ctx, id, operation{
+		name: "cancel",
+		check: func(payment *models.PostPaymentResponse) error {
+			return transition(id, "cancel", Status(payment.PaymentStatus), StatusCancelled)
+		},
+		// the bank has never seen a scheduled payment
+		bankCall: func(ctx context.Context, payment *models.PostPaymentResponse) error {
+			return nil
+		},
+		apply: func(payment *models.PostPaymentResponse) {
+			payment.PaymentStatus = string(StatusCancelled)
+			p.scheduled.remove(id)
+		},
+		event: events.PaymentCancelled,
+	})
+}
+
+func validateExecuteAt(executeAt *time.Time, id string, now time.Time) error {
+	if executeAt == nil {
+		return nil
+	}
+
+	if !executeAt.After(now) {
+		return gatewayerrors.NewValidationError(
+			errors.New("execute_at must be in the future"),
+			id,
+			"execute_at",
+		)
+	}
+
+	if executeAt.After(now.Add(maxScheduleAhead)) {
+		return gatewayerrors.NewValidationError(
+			errors.New("execute_at is too far in the future"),
+			id,
+			"execute_at",
+		)
+	}
+
+	return nil
+}
+
+// scheduledPayments keeps scheduled payments, card details included, until they are due or cancelled.
+type scheduledPayments struct {
+	mu       sync.Mutex
+	payments map[string]scheduledPayment
+}
+
+type scheduledPayment struct {
+	pending   pendingPayment
+	executeAt time.Time
+}
+
+func (s *scheduledPayments) add(pending pendingPayment, executeAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.payments == nil {
+		s.payments = map[string]scheduledPayment{}
+	}
+	s.payments[pending.id] = scheduledPayment{pending: pending, executeAt: executeAt}
+}
+
+// due takes the payments due by now out of the schedule, in no particular order.
+func (s *scheduledPayments) due(now time.Time) []pendingPayment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []pendingPayment
+	for id, scheduled := range s.payments {
+		if !now.Before(scheduled.executeAt) {
+			due = append(due, scheduled.pending)
+			delete(s.payments, id)
+		}
+	}
+	return due
+}
+
+func (s *scheduledPayments) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.payments, id)
+}
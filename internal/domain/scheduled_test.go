@@ -0,0 +1,132 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func scheduledRequest(executeAt time.Time) models.PostPaymentHandlerRequest {
+	request := asyncRequest()
+	request.ExecuteAt = &executeAt
+	return request
+}
+
+func TestScheduledPayment_ExecutedWhenDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	repo := repository.NewPaymentsRepository()
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	executeAt := time.Now().Add(time.Hour)
+	request := scheduledRequest(executeAt)
+	response, err := domain.Create(context.Background(), &request)
+	require.NoError(t, err)
+	assert.Equal(t, "scheduled", response.PaymentStatus)
+	require.NotNil(t, response.ExecuteAt)
+	assert.True(t, executeAt.Equal(*response.ExecuteAt))
+
+	// not due yet, no bank call is expected
+	assert.Equal(t, 0, domain.ExecuteScheduled(context.Background(), time.Now()))
+
+	mockClient.EXPECT().PostBankPayment(gomock.Any(), &models.PostPaymentBankRequest{
+		CardNumber: "2222405343248877",
+		ExpiryDate: "4/2035",
+		Currency:   "GBP",
+		Amount:     100,
+		CVV:        "123",
+	}).Return(&models.PostPaymentBankResponse{Authorised: true, AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2"}, nil)
+
+	assert.Equal(t, 1, domain.ExecuteScheduled(context.Background(), executeAt))
+
+	payment, err := repo.GetPayment(context.Background(), response.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "authorized", payment.PaymentStatus)
+	assert.Equal(t, "abb53d1a-42dd-4ecc-9a25-dca064d35eb2", payment.AuthorizationCode)
+	require.NotNil(t, payment.ExecuteAt, "the authorized payment still says when it was scheduled for")
+	assert.True(t, executeAt.Equal(*payment.ExecuteAt))
+
+	// executed once only
+	assert.Equal(t, 0, domain.ExecuteScheduled(context.Background(), executeAt.Add(time.Hour)))
+}
+
+func TestCancelPayment_Scheduled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	// no bank call is expected
+	mockClient := mocks.NewMockClient(ctrl)
+
+	bus := events.NewInMemoryBus()
+	var published []events.Event
+	bus.Subscribe(events.PaymentCancelled, func(event events.Event) {
+		published = append(published, event)
+	})
+
+	repo := repository.NewPaymentsRepository()
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, bus)
+
+	executeAt := time.Now().Add(time.Hour)
+	request := scheduledRequest(executeAt)
+	response, err := domain.Create(context.Background(), &request)
+	require.NoError(t, err)
+
+	cancelled, err := domain.CancelPayment(context.Background(), response.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", cancelled.PaymentStatus)
+	require.Len(t, published, 1)
+
+	assert.Equal(t, 0, domain.ExecuteScheduled(context.Background(), executeAt))
+
+	payment, err := repo.GetPayment(context.Background(), response.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", payment.PaymentStatus)
+}
+
+func TestCancelPayment_NotScheduled(t *testing.T) {
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), authorizedPayment()))
+
+	domain := domain.NewPaymentServiceImpl(repo, nil, events.NewInMemoryBus())
+
+	response, err := domain.CancelPayment(context.Background(), "test-id")
+	require.Nil(t, response)
+	var transitionErr *gatewayerrors.TransitionError
+	require.ErrorAs(t, err, &transitionErr)
+	assert.Equal(t, "authorized", transitionErr.From)
+	assert.Equal(t, "cancelled", transitionErr.To)
+}
+
+func TestScheduledPayment_InvalidExecuteAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		executeAt time.Time
+	}{
+		{name: "Past", executeAt: time.Now().Add(-time.Minute)},
+		{name: "TooFarAhead", executeAt: time.Now().Add(2 * 365 * 24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+
+			request := scheduledRequest(tt.executeAt)
+			response, err := domain.Create(context.Background(), &request)
+			require.Nil(t, response)
+			var validationErr *gatewayerrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, "execute_at", validationErr.Field)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"slices"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+)
+
+/*
+Status is where a payment is in its lifecycle.  A payment starts in one of the statuses the acquiring bank's answer gives it, processing while an async payment waits for the bank or scheduled until it is due, and can only move along the transitions below, every operation on an existing payment is checked against them before the bank is called and again before the change is stored.
+
+	scheduled          -> authorized, declined, verified, captured, failed, cancelled
+	processing         -> authorized, declined, verified, captured, failed
+	authorized         -> partially_captured, captured, voided, expired
+	partially_captured -> partially_captured, captured
+	captured           -> partially_refunded, refunded, reversed
+	partially_refunded -> partially_refunded, refunded
+
+declined, verified, failed, cancelled, voided, expired, refunded and reversed are final.
+*/
+
+type Status string
+
+const (
+	StatusScheduled         Status = "scheduled"
+	StatusProcessing        Status = "processing"
+	StatusAuthorized        Status = "authorized"
+	StatusDeclined          Status = "declined"
+	StatusVerified          Status = "verified"
+	StatusFailed            Status = "failed"
+	StatusCancelled         Status = "cancelled"
+	StatusPartiallyCaptured Status = "partially_captured"
+	StatusCaptured          Status = "captured"
+	StatusVoided            Status = "voided"
+	StatusExpired           Status = "expired"
+	StatusPartiallyRefunded Status = "partially_refunded"
+	StatusRefunded          Status = "refunded"
+	StatusReversed          Status = "reversed"
+)
+
+var transitions = map[Status][]Status{
+	StatusScheduled:         {StatusAuthorized, StatusDeclined, StatusVerified, StatusCaptured, StatusFailed, StatusCancelled},
+	StatusProcessing:        {StatusAuthorized, StatusDeclined, StatusVerified, StatusCaptured, StatusFailed},
+	StatusAuthorized:        {StatusPartiallyCaptured, StatusCaptured, StatusVoided, StatusExpired},
+	StatusPartiallyCaptured: {StatusPartiallyCaptured, StatusCaptured},
+	StatusCaptured:          {StatusPartiallyRefunded, StatusRefunded, StatusReversed},
+	StatusPartiallyRefunded: {StatusPartiallyRefunded, StatusRefunded},
+}
+
+// CanTransitionTo reports whether a payment in status s may move to next.
+func (s Status) CanTransitionTo(next Status) bool {
+	return slices.Contains(transitions[s], next)
+}
+
+// Final reports whether no operation can move a payment out of status s.
+func (s Status) Final() bool {
+	return len(transitions[s]) == 0
+}
+
+// held reports whether the payment still holds authorized funds that have not been captured.
+func (s Status) held() bool {
+	return s == StatusAuthorized || s == StatusPartiallyCaptured
+}
+
+// settled reports whether money has moved for the payment, so it can be disputed.
+func (s Status) settled() bool {
+	switch s {
+	case StatusCaptured, StatusPartiallyCaptured, StatusPartiallyRefunded, StatusRefunded:
+		return true
+	}
+	return false
+}
+
+// transition returns a StateError wrapping a TransitionError unless the payment with the given id may move from its
+// status to one of next.  operation names what was asked of the payment, for the error message.
+func transition(id, operation string, from Status, next ...Status) error {
+	for _, status := range next {
+		if from.CanTransitionTo(status) {
+			return nil
+		}
+	}
+	return gatewayerrors.NewStateError(
+		gatewayerrors.NewTransitionError(operation, string(from), string(next[len(next)-1])),
+		id,
+		string(from),
+	)
+}
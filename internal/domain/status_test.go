@@ -0,0 +1,45 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from    domain.Status
+		to      domain.Status
+		allowed bool
+	}{
+		{domain.StatusAuthorized, domain.StatusCaptured, true},
+		{domain.StatusAuthorized, domain.StatusPartiallyCaptured, true},
+		{domain.StatusAuthorized, domain.StatusVoided, true},
+		{domain.StatusAuthorized, domain.StatusExpired, true},
+		{domain.StatusAuthorized, domain.StatusRefunded, false},
+		{domain.StatusPartiallyCaptured, domain.StatusCaptured, true},
+		{domain.StatusPartiallyCaptured, domain.StatusVoided, false},
+		{domain.StatusCaptured, domain.StatusRefunded, true},
+		{domain.StatusCaptured, domain.StatusVoided, false},
+		{domain.StatusPartiallyRefunded, domain.StatusRefunded, true},
+		{domain.StatusDeclined, domain.StatusCaptured, false},
+		{domain.StatusVerified, domain.StatusCaptured, false},
+		{domain.StatusRefunded, domain.StatusPartiallyRefunded, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.allowed, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestStatus_Final(t *testing.T) {
+	for _, status := range []domain.Status{domain.StatusDeclined, domain.StatusVerified, domain.StatusVoided, domain.StatusExpired, domain.StatusRefunded} {
+		assert.True(t, status.Final(), status)
+	}
+	for _, status := range []domain.Status{domain.StatusAuthorized, domain.StatusPartiallyCaptured, domain.StatusCaptured, domain.StatusPartiallyRefunded} {
+		assert.False(t, status.Final(), status)
+	}
+}
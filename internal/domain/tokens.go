@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/wallet"
+)
+
+/*
+A payment can be made with a network token instead of a card number.  The scheme's token service issues the token in place of the card and a single use cryptogram with each payment, so the merchant never holds the card number and no CVV is sent.  A network token payment is sent down the bank client's own token path.  Everything after the authorization, captures, refunds and so on, works on the authorization code and does not care how the payment was made.
+
+An Apple Pay or Google Pay payment is a network token payment too.  The wallet's encrypted payload is unwrapped by the Decrypter set for its wallet type into the token, its expiry and a cryptogram, and only those go any further.  The payload itself is never stored with the payment, nor sent to the bank.
+
+The payment records the last four digits of the token, as that is all the gateway sees.
+*/
+
+// SetWalletDecrypter makes the gateway accept payments from the wallet type, unwrapping them with decrypter.
+func (p *PaymentServiceImpl) SetWalletDecrypter(walletType string, decrypter wallet.Decrypter) {
+	if p.wallets == nil {
+		p.wallets = map[string]wallet.Decrypter{}
+	}
+	p.wallets[walletType] = decrypter
+}
+
+// networkTokenMethod pays with a network token and cryptogram the merchant got from the scheme's token service.
+type networkTokenMethod struct{}
+
+func (networkTokenMethod) resolve(ctx context.Context, request *models.PostPaymentHandlerRequest, id string) (*models.PostPaymentHandlerRequest, error) {
+	return request, nil
+}
+
+func (networkTokenMethod) authorization(request *models.PostPaymentHandlerRequest, expiryDate, id string) (authorization, error) {
+	if request.CardNumber != 0 || request.Cvv != 0 || request.CardToken != "" {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("pay with either card details or a network token"),
+			id,
+			"payment_method",
+		)
+	}
+
+	token := request.PaymentMethod.NetworkToken
+	if token == nil || len(token.Token) < 13 || len(token.Token) > 19 || strings.Trim(token.Token, "0123456789") != "" {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("invalid network token"),
+			id,
+			"payment_method.network_token.token",
+		)
+	}
+
+	if token.Cryptogram == "" {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("network token payments need a cryptogram"),
+			id,
+			"payment_method.network_token.cryptogram",
+		)
+	}
+
+	return tokenAuthorization{&models.PostTokenBankRequest{
+		Token:            token.Token,
+		ExpiryDate:       expiryDate,
+		Cryptogram:       token.Cryptogram,
+		ECI:              token.ECI,
+		Currency:         request.Currency,
+		Amount:           request.Amount,
+		StoredCredential: request.StoredCredential,
+		Capture:          request.Capture,
+		BillingAddress:   request.BillingAddress,
+	}}, nil
+}
+
+type tokenAuthorization struct {
+	request *models.PostTokenBankRequest
+}
+
+func (a tokenAuthorization) send(ctx context.Context, bank client.Client) (*models.PostPaymentBankResponse, error) {
+	return bank.PostBankTokenPayment(ctx, a.request)
+}
+
+func (a tokenAuthorization) number() string {
+	return a.request.Token
+}
+
+// walletMethod pays with an Apple Pay or Google Pay payment token, which carries a network token.
+type walletMethod struct {
+	p *PaymentServiceImpl
+}
+
+// resolve decrypts the wallet payload into the network token it carries, and drops the payload.
+func (m walletMethod) resolve(ctx context.Context, request *models.PostPaymentHandlerRequest, id string) (*models.PostPaymentHandlerRequest, error) {
+	method := request.PaymentMethod
+	if method.Wallet == nil || m.p.wallets[method.Wallet.Type] == nil {
+		return nil, gatewayerrors.NewValidationError(
+			errors.New("unsupported wallet"),
+			id,
+			"payment_method.wallet.type",
+		)
+	}
+
+	decrypted, err := m.p.wallets[method.Wallet.Type].Decrypt(ctx, method.Wallet.Payload)
+	if errors.Is(err, wallet.ErrInvalidPayload) {
+		return nil, gatewayerrors.NewValidationError(
+			err,
+			id,
+			"payment_method.wallet.payload",
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := *request
+	resolved.ExpiryMonth = decrypted.ExpiryMonth
+	resolved.ExpiryYear = decrypted.ExpiryYear
+	resolved.PaymentMethod = &models.PaymentMethod{
+		Type:   paymentMethodWallet,
+		Wallet: &models.Wallet{Type: method.Wallet.Type},
+		NetworkToken: &models.NetworkToken{
+			Token:      decrypted.Token,
+			Cryptogram: decrypted.Cryptogram,
+			ECI:        decrypted.ECI,
+		},
+	}
+	return &resolved, nil
+}
+
+func (walletMethod) authorization(request *models.PostPaymentHandlerRequest, expiryDate, id string) (authorization, error) {
+	return networkTokenMethod{}.authorization(request, expiryDate, id)
+}
@@ -0,0 +1,173 @@
+package domain_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func tokenRequest() models.PostPaymentHandlerRequest {
+	return models.PostPaymentHandlerRequest{
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      100,
+		PaymentMethod: &models.PaymentMethod{
+			Type: "network_token",
+			NetworkToken: &models.NetworkToken{
+				Token:      "4895370012003478",
+				Cryptogram: "AgAAAAAABk4DWZ4C28yUQAAAAAA=",
+				ECI:        "05",
+			},
+		},
+	}
+}
+
+func TestPostPayment_NetworkToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	// the card path is never used
+	mockClient.EXPECT().PostBankTokenPayment(gomock.Any(), &models.PostTokenBankRequest{
+		Token:      "4895370012003478",
+		ExpiryDate: "4/2035",
+		Cryptogram: "AgAAAAAABk4DWZ4C28yUQAAAAAA=",
+		ECI:        "05",
+		Currency:   "GBP",
+		Amount:     100,
+	}).Return(&models.PostPaymentBankResponse{Authorised: true, AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2"}, nil)
+
+	repo := repository.NewPaymentsRepository()
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+	request := tokenRequest()
+	response, err := domain.Create(context.Background(), &request)
+	require.NoError(t, err)
+	assert.Equal(t, "authorized", response.PaymentStatus)
+	assert.Equal(t, "network_token", response.PaymentMethod)
+	assert.Equal(t, 3478, response.CardNumberLastFour)
+
+	dbPayment, err := repo.GetPayment(context.Background(), response.Id)
+	require.NoError(t, err)
+	assert.Equal(t, response, dbPayment)
+}
+
+func TestPostPayment_InvalidNetworkToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*models.PostPaymentHandlerRequest)
+		field  string
+	}{
+		{name: "UnknownType", modify: func(r *models.PostPaymentHandlerRequest) { r.PaymentMethod.Type = "cheque" }, field: "payment_method.type"},
+		{name: "CardDetailsToo", modify: func(r *models.PostPaymentHandlerRequest) { r.CardNumber = 2222405343248877 }, field: "payment_method"},
+		{name: "MissingToken", modify: func(r *models.PostPaymentHandlerRequest) { r.PaymentMethod.NetworkToken = nil }, field: "payment_method.network_token.token"},
+		{name: "ShortToken", modify: func(r *models.PostPaymentHandlerRequest) { r.PaymentMethod.NetworkToken.Token = "489537" }, field: "payment_method.network_token.token"},
+		{name: "NonNumericToken", modify: func(r *models.PostPaymentHandlerRequest) { r.PaymentMethod.NetworkToken.Token = "48953700120034ab" }, field: "payment_method.network_token.token"},
+		{name: "MissingCryptogram", modify: func(r *models.PostPaymentHandlerRequest) { r.PaymentMethod.NetworkToken.Cryptogram = "" }, field: "payment_method.network_token.cryptogram"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+
+			request := tokenRequest()
+			tt.modify(&request)
+			_, err := domain.Create(context.Background(), &request)
+
+			var validationErr *gatewayerrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.field, validationErr.Field)
+		})
+	}
+}
+
+func walletRequest(walletType, decrypted string) models.PostPaymentHandlerRequest {
+	return models.PostPaymentHandlerRequest{
+		Currency: "GBP",
+		Amount:   100,
+		PaymentMethod: &models.PaymentMethod{
+			Type: "wallet",
+			Wallet: &models.Wallet{
+				Type:    walletType,
+				Payload: base64.StdEncoding.EncodeToString([]byte(decrypted)),
+			},
+		},
+	}
+}
+
+func TestPostPayment_Wallet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	mockClient.EXPECT().PostBankTokenPayment(gomock.Any(), &models.PostTokenBankRequest{
+		Token:      "4895370012003477",
+		ExpiryDate: "4/2035",
+		Cryptogram: "AgAAAAAABk4DWZ4C28yUQAAAAAA=",
+		ECI:        "07",
+		Currency:   "GBP",
+		Amount:     100,
+	}).Return(&models.PostPaymentBankResponse{Authorised: true, AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2"}, nil)
+
+	repo := repository.NewPaymentsRepository()
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+	domain.SetWalletDecrypter(wallet.ApplePay, wallet.NewFakeDecrypter())
+
+	request := walletRequest(wallet.ApplePay, `{"token": "4895370012003477", "expiry_month": 4, "expiry_year": 2035, "cryptogram": "AgAAAAAABk4DWZ4C28yUQAAAAAA=", "eci": "07"}`)
+	response, err := domain.Create(context.Background(), &request)
+	require.NoError(t, err)
+	assert.Equal(t, "authorized", response.PaymentStatus)
+	assert.Equal(t, "apple_pay", response.PaymentMethod)
+	assert.Equal(t, 3477, response.CardNumberLastFour)
+	assert.Equal(t, 4, response.ExpiryMonth)
+	assert.Equal(t, 2035, response.ExpiryYear)
+}
+
+func TestPostPayment_InvalidWallet(t *testing.T) {
+	tests := []struct {
+		name    string
+		request models.PostPaymentHandlerRequest
+		field   string
+	}{
+		{
+			name:    "NoDecrypter",
+			request: walletRequest(wallet.GooglePay, `{}`),
+			field:   "payment_method.wallet.type",
+		},
+		{
+			name:    "UndecryptablePayload",
+			request: walletRequest(wallet.ApplePay, `not json`),
+			field:   "payment_method.wallet.payload",
+		},
+		{
+			name:    "NoCryptogram",
+			request: walletRequest(wallet.ApplePay, `{"token": "4895370012003477", "expiry_month": 4, "expiry_year": 2035}`),
+			field:   "payment_method.network_token.cryptogram",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domain := domain.NewPaymentServiceImpl(repository.NewPaymentsRepository(), nil, events.NewInMemoryBus())
+			domain.SetWalletDecrypter(wallet.ApplePay, wallet.NewFakeDecrypter())
+
+			_, err := domain.Create(context.Background(), &tt.request)
+
+			var validationErr *gatewayerrors.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.field, validationErr.Field)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/scheme"
+)
+
+// defaultVerificationCurrency is used when a card verification does not name a currency, nothing is charged so it only matters to the issuer.
+const defaultVerificationCurrency = "USD"
+
+// VerifyCard asks the issuer to approve a zero amount authorization, checking the card is good without charging it.
+// The verification is recorded like any other payment, with status verified or declined.
+func (p *PaymentServiceImpl) VerifyCard(ctx context.Context, request *models.CardVerificationRequest) (*models.CardVerification, error) {
+	currency := request.Currency
+	if currency == "" {
+		currency = defaultVerificationCurrency
+	}
+
+	payment, err := p.Create(ctx, &models.PostPaymentHandlerRequest{
+		CardNumber:     request.CardNumber,
+		ExpiryMonth:    request.ExpiryMonth,
+		ExpiryYear:     request.ExpiryYear,
+		Currency:       currency,
+		Amount:         0,
+		Cvv:            request.Cvv,
+		BillingAddress: request.BillingAddress,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CardVerification{
+		PaymentId:          payment.Id,
+		Valid:              Status(payment.PaymentStatus) == StatusVerified,
+		Scheme:             string(scheme.Detect(strconv.Itoa(request.CardNumber))),
+		CardNumberLastFour: payment.CardNumberLastFour,
+		ExpiryMonth:        payment.ExpiryMonth,
+		ExpiryYear:         payment.ExpiryYear,
+		AVSResult:          payment.AVSResult,
+	}, nil
+}
@@ -0,0 +1,61 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVerifyCard(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorised    bool
+		expectedValid bool
+	}{
+		{name: "Valid", authorised: true, expectedValid: true},
+		{name: "Declined", authorised: false, expectedValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockClient := mocks.NewMockClient(ctrl)
+
+			mockClient.EXPECT().PostBankPayment(gomock.Any(), &models.PostPaymentBankRequest{
+				CardNumber: "4242424242424241",
+				ExpiryDate: "4/2035",
+				Currency:   "USD",
+				Amount:     0,
+				CVV:        "123",
+			}).Return(&models.PostPaymentBankResponse{Authorised: tt.authorised}, nil)
+
+			repo := repository.NewPaymentsRepository()
+			domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+			verification, err := domain.VerifyCard(context.Background(), &models.CardVerificationRequest{
+				CardNumber:  4242424242424241,
+				ExpiryMonth: 4,
+				ExpiryYear:  2035,
+				Cvv:         123,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedValid, verification.Valid)
+			assert.Equal(t, "visa", verification.Scheme)
+			assert.Equal(t, 4241, verification.CardNumberLastFour)
+
+			payment, err := repo.GetPayment(context.Background(), verification.PaymentId)
+			require.NoError(t, err)
+			require.NotNil(t, payment)
+			assert.Equal(t, 0, payment.Amount)
+		})
+	}
+}
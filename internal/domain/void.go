@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+// VoidPayment cancels an authorization before it is captured, asking the acquiring bank to release the held funds.
+// Voiding a partially captured payment releases what is still held and leaves it captured for the amount taken so far.
+func (p *PaymentServiceImpl) VoidPayment(ctx context.Context, id string) (*models.PostPaymentResponse, error) {
+	return p.run(ctx, id, operation{
+		name: "void",
+		check: func(payment *models.PostPaymentResponse) error {
+			return transition(id, "void", Status(payment.PaymentStatus), StatusVoided, StatusCaptured)
+		},
+		bankCall: func(ctx context.Context, payment *models.PostPaymentResponse) error {
+			bankResponse, err := p.client.VoidBankPayment(ctx, &models.VoidBankRequest{
+				AuthorizationCode: payment.AuthorizationCode,
+			})
+			if err != nil {
+				return err
+			}
+			if !bankResponse.Voided {
+				return gatewayerrors.NewBankError(
+					errors.New("acquiring bank refused the void"),
+					http.StatusBadGateway,
+				)
+			}
+			return nil
+		},
+		apply: func(payment *models.PostPaymentResponse) {
+			if Status(payment.PaymentStatus) == StatusPartiallyCaptured {
+				payment.PaymentStatus = string(StatusCaptured)
+			} else {
+				payment.PaymentStatus = string(StatusVoided)
+			}
+			payment.ExpiresAt = nil
+		},
+		event: events.PaymentVoided,
+	})
+}
@@ -0,0 +1,73 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client/mocks"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVoidPayment_Authorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mocks.NewMockClient(ctrl)
+
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, repo.AddPayment(context.Background(), authorizedPayment()))
+
+	mockClient.EXPECT().VoidBankPayment(gomock.Any(), &models.VoidBankRequest{
+		AuthorizationCode: "abb53d1a-42dd-4ecc-9a25-dca064d35eb2",
+	}).Return(&models.VoidBankResponse{Voided: true}, nil)
+
+	bus := events.NewInMemoryBus()
+	var published []events.Event
+	bus.Subscribe(events.PaymentVoided, func(event events.Event) {
+		published = append(published, event)
+	})
+
+	domain := domain.NewPaymentServiceImpl(repo, mockClient, bus)
+
+	response, err := domain.VoidPayment(context.Background(), "test-id")
+	require.NoError(t, err)
+	assert.Equal(t, "voided", response.PaymentStatus)
+
+	dbPayment, err := repo.GetPayment(context.Background(), "test-id")
+	require.NoError(t, err)
+	assert.Equal(t, "voided", dbPayment.PaymentStatus)
+	require.Len(t, published, 1)
+}
+
+func TestVoidPayment_RejectedStates(t *testing.T) {
+	for _, status := range []string{"captured", "refunded", "declined", "voided"} {
+		t.Run(status, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			// no bank call is expected
+			mockClient := mocks.NewMockClient(ctrl)
+
+			payment := authorizedPayment()
+			payment.PaymentStatus = status
+			repo := repository.NewPaymentsRepository()
+			require.NoError(t, repo.AddPayment(context.Background(), payment))
+
+			domain := domain.NewPaymentServiceImpl(repo, mockClient, events.NewInMemoryBus())
+
+			response, err := domain.VoidPayment(context.Background(), "test-id")
+			require.Nil(t, response)
+			var stateErr *gatewayerrors.StateError
+			require.ErrorAs(t, err, &stateErr)
+			assert.Equal(t, status, stateErr.Status)
+			var transitionErr *gatewayerrors.TransitionError
+			require.ErrorAs(t, err, &transitionErr)
+			assert.Equal(t, status, transitionErr.From)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+/*
+The bus lets the domain announce what happened to a payment without knowing who cares.  Subsystems such as risk, webhooks, analytics or a ledger subscribe to the event types they need rather than being called inline from the payment service.
+
+The in-memory implementation delivers synchronously on the publishing goroutine, which keeps ordering deterministic and makes tests trivial.  Subscribers that do slow work (network calls etc.) should hand off to their own goroutine so they do not add latency to the payment path.
+
+Events are published after the payment has been stored, so a failing subscriber must not fail the payment.  A panic in a handler is recovered and logged, and delivery carries on with the next handler.
+*/
+
+type Type string
+
+const (
+	PaymentAuthorized Type = "payment.authorized"
+	PaymentDeclined   Type = "payment.declined"
+	PaymentVerified   Type = "payment.verified"
+	PaymentCaptured   Type = "payment.captured"
+	PaymentVoided     Type = "payment.voided"
+	PaymentRefunded   Type = "payment.refunded"
+	PaymentExpired    Type = "payment.expired"
+	PaymentDisputed   Type = "payment.disputed"
+	PaymentReversed   Type = "payment.reversed"
+	PaymentFailed     Type = "payment.failed"
+	PaymentScheduled  Type = "payment.scheduled"
+	PaymentCancelled  Type = "payment.cancelled"
+)
+
+type Event struct {
+	Type       Type
+	Payment    models.PostPaymentResponse
+	OccurredAt time.Time
+}
+
+type Handler func(event Event)
+
+type Bus interface {
+	Publish(event Event)
+	Subscribe(eventType Type, handler Handler)
+}
+
+type InMemoryBus struct {
+	mu       sync.RWMutex
+	handlers map[Type][]Handler
+}
+
+func NewInMemoryBus() *InMemoryBus {
+	return &InMemoryBus{
+		handlers: map[Type][]Handler{},
+	}
+}
+
+func NewEvent(eventType Type, payment models.PostPaymentResponse) Event {
+	return Event{
+		Type:       eventType,
+		Payment:    payment,
+		OccurredAt: time.Now(),
+	}
+}
+
+func (b *InMemoryBus) Subscribe(eventType Type, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+func (b *InMemoryBus) Publish(event Event) {
+	b.mu.RLock()
+	handlers := b.handlers[event.Type]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		deliver(handler, event)
+	}
+}
+
+func deliver(handler Handler, event Event) {
+	defer func() {
+		if x := recover(); x != nil {
+			log.Printf("event handler for %s on payment %s panicked: %v", event.Type, event.Payment.Id, x)
+		}
+	}()
+
+	handler(event)
+}
+
+// Log is a Handler that records payment lifecycle events in the application log.
+func Log(event Event) {
+	log.Printf("payment %s: %s", event.Payment.Id, event.Type)
+}
@@ -0,0 +1,56 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/events"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInMemoryBus_PublishDeliversToSubscribers(t *testing.T) {
+	bus := events.NewInMemoryBus()
+
+	var received []events.Event
+	bus.Subscribe(events.PaymentAuthorized, func(event events.Event) {
+		received = append(received, event)
+	})
+
+	payment := models.PostPaymentResponse{Id: "test-id", PaymentStatus: "authorized"}
+	bus.Publish(events.NewEvent(events.PaymentAuthorized, payment))
+
+	assert.Len(t, received, 1)
+	assert.Equal(t, events.PaymentAuthorized, received[0].Type)
+	assert.Equal(t, payment, received[0].Payment)
+	assert.False(t, received[0].OccurredAt.IsZero())
+}
+
+func TestInMemoryBus_PublishRecoversFromPanickingHandler(t *testing.T) {
+	bus := events.NewInMemoryBus()
+
+	bus.Subscribe(events.PaymentAuthorized, func(event events.Event) {
+		panic("boom")
+	})
+	called := false
+	bus.Subscribe(events.PaymentAuthorized, func(event events.Event) {
+		called = true
+	})
+
+	assert.NotPanics(t, func() {
+		bus.Publish(events.NewEvent(events.PaymentAuthorized, models.PostPaymentResponse{Id: "test-id"}))
+	})
+	assert.True(t, called)
+}
+
+func TestInMemoryBus_PublishIgnoresOtherTypes(t *testing.T) {
+	bus := events.NewInMemoryBus()
+
+	called := false
+	bus.Subscribe(events.PaymentDeclined, func(event events.Event) {
+		called = true
+	})
+
+	bus.Publish(events.NewEvent(events.PaymentAuthorized, models.PostPaymentResponse{Id: "test-id"}))
+
+	assert.False(t, called)
+}
@@ -0,0 +1,113 @@
+package forwarded
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+/*
+The rate limiter and the abuse guard tell clients apart by IP address until the gateway has API keys.  Behind a reverse proxy every request comes from the proxy, and over a Unix socket there is no address at all, so every client would share one key and a single card tester's block would shut out every merchant.
+
+Proxies lists the reverse proxies trusted to say who the client is.  A request from one of them has its RemoteAddr replaced by the client's address from X-Forwarded-For, read from the right and skipping trusted proxies, so a client cannot choose its own address by sending the header itself.  A request that still has no IP address after that has no key: ClientIP returns "" for it and the rate limiter and guard let it through untracked rather than lump it in with everyone else.
+*/
+
+// Header is the header proxies add the address they received a request from to.
+const Header = "X-Forwarded-For"
+
+// Unix stands for peers connected over a Unix socket in a list of trusted proxies.
+const Unix = "unix"
+
+// Proxies are the reverse proxies trusted to set X-Forwarded-For.  A nil *Proxies trusts none.
+type Proxies struct {
+	prefixes []netip.Prefix
+	unix     bool
+}
+
+// ParseProxies parses a list of trusted proxies, each an IP address, a CIDR range or Unix for peers on a Unix socket.
+func ParseProxies(proxies []string) (*Proxies, error) {
+	p := &Proxies{}
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		switch {
+		case proxy == Unix:
+			p.unix = true
+		case strings.Contains(proxy, "/"):
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+			}
+			p.prefixes = append(p.prefixes, prefix.Masked())
+		default:
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+			}
+			addr = addr.Unmap()
+			p.prefixes = append(p.prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	if len(p.prefixes) == 0 && !p.unix {
+		return nil, errors.New("no trusted proxies given")
+	}
+	return p, nil
+}
+
+// Rewrite replaces the RemoteAddr of a request from a trusted proxy with the address of the client the proxy forwarded it for.
+func (p *Proxies) Rewrite(r *http.Request) {
+	if p == nil || !p.trusted(ClientIP(r)) {
+		return
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values(Header) {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// what is left of the header cannot be trusted, the last proxy that was is the closest to the client known
+			return
+		}
+		r.RemoteAddr = addr.Unmap().String()
+		if !p.trusted(r.RemoteAddr) {
+			return
+		}
+	}
+}
+
+// trusted reports whether ip, as returned by ClientIP, is a trusted proxy.
+func (p *Proxies) trusted(ip string) bool {
+	if ip == "" {
+		return p.unix
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP address of the client that sent r, or "" when its RemoteAddr holds none, as for a peer on a
+// Unix socket.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return ""
+	}
+	return addr.Unmap().String()
+}
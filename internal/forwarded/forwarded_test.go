@@ -0,0 +1,63 @@
+package forwarded_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/forwarded"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxies_Rewrite(t *testing.T) {
+	proxies, err := forwarded.ParseProxies([]string{"10.0.0.0/8", "192.168.1.1", forwarded.Unix})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		expected   string
+	}{
+		{name: "Direct", remoteAddr: "203.0.113.7:1234", expected: "203.0.113.7"},
+		{name: "UntrustedPeerIgnored", remoteAddr: "203.0.113.7:1234", forwarded: []string{"198.51.100.1"}, expected: "203.0.113.7"},
+		{name: "TrustedProxy", remoteAddr: "10.0.0.1:1234", forwarded: []string{"198.51.100.1"}, expected: "198.51.100.1"},
+		{name: "ChainOfProxies", remoteAddr: "10.0.0.1:1234", forwarded: []string{"198.51.100.1, 192.168.1.1", "10.0.0.2"}, expected: "198.51.100.1"},
+		{name: "SpoofedHopsIgnored", remoteAddr: "10.0.0.1:1234", forwarded: []string{"1.1.1.1, 198.51.100.1"}, expected: "198.51.100.1"},
+		{name: "GarbageStopsAtLastProxy", remoteAddr: "10.0.0.1:1234", forwarded: []string{"nonsense, 10.0.0.2"}, expected: "10.0.0.2"},
+		{name: "UnixSocket", remoteAddr: "@", forwarded: []string{"198.51.100.1"}, expected: "198.51.100.1"},
+		{name: "UnixSocketWithoutHeader", remoteAddr: "@", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add(forwarded.Header, value)
+			}
+
+			proxies.Rewrite(req)
+
+			assert.Equal(t, tt.expected, forwarded.ClientIP(req))
+		})
+	}
+}
+
+func TestProxies_NilTrustsNone(t *testing.T) {
+	var proxies *forwarded.Proxies
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set(forwarded.Header, "198.51.100.1")
+	proxies.Rewrite(req)
+
+	assert.Equal(t, "10.0.0.1", forwarded.ClientIP(req))
+}
+
+func TestParseProxies_Invalid(t *testing.T) {
+	for _, list := range [][]string{{"not-an-ip"}, {"10.0.0.0/99"}, {}} {
+		_, err := forwarded.ParseProxies(list)
+		assert.Error(t, err, list)
+	}
+}
@@ -1,5 +1,10 @@
 package gatewayerrors
 
+import (
+	"errors"
+	"fmt"
+)
+
 /*
 Pretty much what it says on the tin, here I created some custom errors for our service so that we could create specific types that we could check against in the handler and also keep some additional info.
 
@@ -48,3 +53,85 @@ func NewValidationError(err error, id, field string) *ValidationError {
 		ID:    id,
 	}
 }
+
+// TimeoutError is returned when a request's deadline runs out.  BankCalled records whether the acquiring bank had already been sent the payment, in which case its outcome is unknown to us.
+type TimeoutError struct {
+	Err        error
+	BankCalled bool
+}
+
+func (te *TimeoutError) Error() string {
+	return te.Err.Error()
+}
+
+func (te *TimeoutError) Unwrap() error {
+	return te.Err
+}
+
+func NewTimeoutError(err error, bankCalled bool) *TimeoutError {
+	return &TimeoutError{
+		Err:        err,
+		BankCalled: bankCalled,
+	}
+}
+
+// ErrPaymentNotFound is returned when an operation names a payment that does not exist.
+var ErrPaymentNotFound = errors.New("payment not found")
+
+// ErrPaymentExists is returned when a payment is added with the id of one that is already stored.
+var ErrPaymentExists = errors.New("payment already exists")
+
+// ErrPaymentIntentNotFound is returned when an operation names a payment intent that does not exist.
+var ErrPaymentIntentNotFound = errors.New("payment intent not found")
+
+// ErrPaymentLinkNotFound is returned when an operation names a payment link that does not exist.
+var ErrPaymentLinkNotFound = errors.New("payment link not found")
+
+// ErrInstallmentPlanNotFound is returned when an operation names an installment plan that does not exist.
+var ErrInstallmentPlanNotFound = errors.New("installment plan not found")
+
+// ErrDisputeNotFound is returned when an operation names a dispute that does not exist.
+var ErrDisputeNotFound = errors.New("dispute not found")
+
+// StateError is returned when a payment's status does not allow the requested operation, for example capturing a declined payment.
+type StateError struct {
+	Err    error
+	ID     string
+	Status string
+}
+
+func (se *StateError) Error() string {
+	return se.Err.Error()
+}
+
+func (se *StateError) Unwrap() error {
+	return se.Err
+}
+
+func NewStateError(err error, id, status string) *StateError {
+	return &StateError{
+		Err:    err,
+		ID:     id,
+		Status: status,
+	}
+}
+
+// TransitionError is wrapped in a StateError when an operation would move a payment between two statuses the payment
+// lifecycle does not connect, for example from declined to captured.
+type TransitionError struct {
+	Operation string
+	From      string
+	To        string
+}
+
+func (te *TransitionError) Error() string {
+	return fmt.Sprintf("cannot %s a %s payment", te.Operation, te.From)
+}
+
+func NewTransitionError(operation, from, to string) *TransitionError {
+	return &TransitionError{
+		Operation: operation,
+		From:      from,
+		To:        to,
+	}
+}
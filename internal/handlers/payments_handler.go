@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
@@ -21,6 +27,9 @@ type HandlerErrorResponse struct {
 const (
 	contentTypeHeader = "Content-Type"
 	jsonContentType   = "application/json"
+
+	// postPaymentTimeout is the overall budget for creating a payment, shared by validation, storage and the bank call.
+	postPaymentTimeout = 10 * time.Second
 )
 
 type PaymentsHandler struct {
@@ -38,6 +47,7 @@ func NewPaymentsHandler(storage *repository.PaymentsRepository, domain *domain.D
 // GetHandler returns an http.HandlerFunc that handles HTTP GET requests.
 // It retrieves a payment record by its ID from the storage.
 // The ID is expected to be part of the URL.
+// An optional as_of query parameter (RFC 3339) returns the payment as it was at that time instead.
 func (h *PaymentsHandler) GetHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
@@ -47,22 +57,36 @@ func (h *PaymentsHandler) GetHandler() http.HandlerFunc {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		payment := h.storage.GetPayment(id)
+
+		var payment *models.PostPaymentResponse
+		var err error
+		if asOf := r.URL.Query().Get("as_of"); asOf != "" {
+			at, parseErr := time.Parse(time.RFC3339, asOf)
+			if parseErr != nil {
+				writeError(w, http.StatusBadRequest, "as_of must be an RFC 3339 timestamp, e.g. 2026-01-02T15:04:05Z.")
+				return
+			}
+			payment, err = h.storage.GetPaymentAsOf(r.Context(), id, at)
+		} else {
+			payment, err = h.storage.GetPayment(r.Context(), id)
+		}
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				log.Printf("Timed out retrieving payment: %v", err)
+				writeTimeout(w, TimeoutMessage)
+				return
+			}
+			log.Printf("Error retrieving payment: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 
 		if payment == nil {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
 
-		paymentResponse := models.GetPaymentHandlerResponse{
-			Id:                 payment.Id,
-			Status:             payment.PaymentStatus,
-			LastFourCardDigits: payment.CardNumberLastFour,
-			ExpiryMonth:        payment.ExpiryMonth,
-			ExpiryYear:         payment.ExpiryYear,
-			Currency:           payment.Currency,
-			Amount:             payment.Amount,
-		}
+		paymentResponse := getPaymentResponse(payment)
 
 		w.Header().Set(contentTypeHeader, jsonContentType)
 		w.WriteHeader(http.StatusOK)
@@ -72,6 +96,114 @@ func (h *PaymentsHandler) GetHandler() http.HandlerFunc {
 	}
 }
 
+// ListHandler returns an http.HandlerFunc that lists payments, oldest first.
+// The optional query parameters status, currency, last_four, min_amount, max_amount, created_after and created_before
+// (RFC 3339) narrow the list, bounds are inclusive.
+func (h *PaymentsHandler) ListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, message := paymentFilter(r.URL.Query())
+		if message != "" {
+			writeError(w, http.StatusBadRequest, message)
+			return
+		}
+
+		payments, err := h.storage.ListPayments(r.Context(), filter)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				log.Printf("Timed out listing payments: %v", err)
+				writeTimeout(w, TimeoutMessage)
+				return
+			}
+			log.Printf("Error listing payments: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]models.GetPaymentHandlerResponse, len(payments))
+		for i := range payments {
+			response[i] = getPaymentResponse(&payments[i])
+		}
+
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
+
+// paymentFilter reads a payments list filter from query parameters, the message says what is wrong with them if anything.
+func paymentFilter(query url.Values) (models.PaymentFilter, string) {
+	filter := models.PaymentFilter{
+		Status:   query.Get("status"),
+		Currency: query.Get("currency"),
+	}
+
+	if value := query.Get("last_four"); value != "" {
+		if len(value) != 4 || strings.Trim(value, "0123456789") != "" {
+			return filter, "last_four must be four digits."
+		}
+		lastFour, _ := strconv.Atoi(value)
+		filter.LastFour = &lastFour
+	}
+
+	amounts := []struct {
+		name  string
+		bound **int
+	}{{"min_amount", &filter.MinAmount}, {"max_amount", &filter.MaxAmount}}
+	for _, param := range amounts {
+		if value := query.Get(param.name); value != "" {
+			amount, err := strconv.Atoi(value)
+			if err != nil {
+				return filter, param.name + " must be a whole number of minor units."
+			}
+			*param.bound = &amount
+		}
+	}
+
+	times := []struct {
+		name  string
+		bound **time.Time
+	}{{"created_after", &filter.CreatedAfter}, {"created_before", &filter.CreatedBefore}}
+	for _, param := range times {
+		if value := query.Get(param.name); value != "" {
+			at, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, param.name + " must be an RFC 3339 timestamp, e.g. 2026-01-02T15:04:05Z."
+			}
+			*param.bound = &at
+		}
+	}
+
+	return filter, ""
+}
+
+func getPaymentResponse(payment *models.PostPaymentResponse) models.GetPaymentHandlerResponse {
+	return models.GetPaymentHandlerResponse{
+		Id:                 payment.Id,
+		Status:             payment.PaymentStatus,
+		LastFourCardDigits: payment.CardNumberLastFour,
+		ExpiryMonth:        payment.ExpiryMonth,
+		ExpiryYear:         payment.ExpiryYear,
+		Currency:           payment.Currency,
+		Amount:             payment.Amount,
+		ExpiresAt:          payment.ExpiresAt,
+		StoredCredential:   payment.StoredCredential,
+		Captures:           payment.Captures,
+		Refunds:            payment.Refunds,
+		CaptureMethod:      payment.CaptureMethod,
+		CapturedAt:         payment.CapturedAt,
+		CardToken:          payment.CardToken,
+		AVSResult:          payment.AVSResult,
+		RetryOf:            payment.RetryOf,
+		Retries:            payment.Retries,
+		DeclineReason:      payment.DeclineReason,
+		FailureReason:      payment.FailureReason,
+		ExecuteAt:          payment.ExecuteAt,
+		PaymentMethod:      payment.PaymentMethod,
+	}
+}
+
 func (ph *PaymentsHandler) PostHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Body == nil {
@@ -86,23 +218,16 @@ func (ph *PaymentsHandler) PostHandler() http.HandlerFunc {
 			return
 		}
 
-		domainResponse, err := ph.domain.PaymentService.Create(&paymentRequest)
-		if err != nil {
-			var bankErr *gatewayerrors.BankError
-			if errors.As(err, &bankErr) && bankErr.StatusCode == http.StatusServiceUnavailable {
-				log.Printf("Error processing payment: %v", err)
-				errorResponse := HandlerErrorResponse{
-					Message: "The acquiring bank is currently unavailable. Please try again later.",
-				}
-				w.Header().Set(contentTypeHeader, jsonContentType)
-				w.WriteHeader(http.StatusServiceUnavailable)
-				if err := json.NewEncoder(w).Encode(errorResponse); err != nil {
-					log.Printf("Failed to encode error response: %v", err)
-					w.WriteHeader(http.StatusInternalServerError)
-				}
-				return
+		ctx, cancel := context.WithTimeout(r.Context(), postPaymentTimeout)
+		defer cancel()
 
-			}
+		create := ph.domain.PaymentService.Create
+		if respondAsync(r) {
+			create = ph.domain.PaymentService.Submit
+		}
+
+		domainResponse, err := create(ctx, &paymentRequest)
+		if err != nil {
 			var validationErr *gatewayerrors.ValidationError
 			if errors.As(err, &validationErr) {
 				log.Printf("validation error on field: %v", validationErr.GetFieldError())
@@ -118,16 +243,619 @@ func (ph *PaymentsHandler) PostHandler() http.HandlerFunc {
 				}
 				return
 			}
-			log.Printf("Unsupported error: %v", err)
-			w.Header().Set(contentTypeHeader, jsonContentType)
+			// everything else answers the same as it does on the other writes, a bank failure is a 502 wherever it happens
+			writeOperationError(w, "payment", err)
+			return
+		}
+
+		status := http.StatusOK
+		switch domain.Status(domainResponse.PaymentStatus) {
+		case domain.StatusProcessing:
+			// the bank is asked in the background, the client polls the payment for the outcome
+			w.Header().Set("Location", "/api/v1/payments/"+domainResponse.Id)
+			w.Header().Set("Preference-Applied", respondAsyncPreference)
+			status = http.StatusAccepted
+		case domain.StatusScheduled:
+			w.Header().Set("Location", "/api/v1/payments/"+domainResponse.Id)
+			status = http.StatusAccepted
+		}
+
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(domainResponse); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
+
+const respondAsyncPreference = "respond-async"
+
+// respondAsync reports whether the client asked for the payment to be processed in the background with
+// "Prefer: respond-async" (RFC 7240).
+func respondAsync(r *http.Request) bool {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, preference := range strings.Split(header, ",") {
+			if strings.EqualFold(strings.TrimSpace(preference), respondAsyncPreference) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CaptureHandler returns an http.HandlerFunc that captures the funds held by an authorized payment, in full or for the amount in the body.
+// The ID is expected to be part of the URL.
+func (ph *PaymentsHandler) CaptureHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var captureRequest models.CaptureRequest
+		if err := decodeOptionalBody(r, &captureRequest); err != nil {
+			log.Printf("Error decoding capture body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		capture := func(ctx context.Context, id string) (*models.PostPaymentResponse, error) {
+			return ph.domain.PaymentService.CapturePayment(ctx, id, captureRequest.Amount)
+		}
+		ph.operationHandler("capture", capture)(w, r)
+	}
+}
+
+// VoidHandler returns an http.HandlerFunc that cancels an authorization before it is captured.
+// The ID is expected to be part of the URL.
+func (ph *PaymentsHandler) VoidHandler() http.HandlerFunc {
+	return ph.operationHandler("void", ph.domain.PaymentService.VoidPayment)
+}
+
+// CancelHandler returns an http.HandlerFunc that cancels a scheduled payment before it is sent to the bank.
+// The ID is expected to be part of the URL.
+func (ph *PaymentsHandler) CancelHandler() http.HandlerFunc {
+	return ph.operationHandler("cancellation", ph.domain.PaymentService.CancelPayment)
+}
+
+// ReverseHandler returns an http.HandlerFunc that undoes a captured payment, reversing it before settlement and refunding it after.
+// The ID is expected to be part of the URL.
+func (ph *PaymentsHandler) ReverseHandler() http.HandlerFunc {
+	return ph.operationHandler("reversal", ph.domain.PaymentService.ReversePayment)
+}
+
+// RetryHandler returns an http.HandlerFunc that sends a declined payment to the bank again and answers with the new attempt.
+// The ID is expected to be part of the URL, and the CVV in the body for payments made with card details.
+func (ph *PaymentsHandler) RetryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var retryRequest models.RetryRequest
+		if err := decodeOptionalBody(r, &retryRequest); err != nil {
+			log.Printf("Error decoding retry body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		retry := func(ctx context.Context, id string) (*models.PostPaymentResponse, error) {
+			return ph.domain.PaymentService.RetryPayment(ctx, id, retryRequest.Cvv)
+		}
+		ph.operationHandler("retry", retry)(w, r)
+	}
+}
+
+// RefundHandler returns an http.HandlerFunc that refunds a captured payment, in full or for the amount in the body.
+// The ID is expected to be part of the URL.
+func (ph *PaymentsHandler) RefundHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var refundRequest models.RefundRequest
+		if err := decodeOptionalBody(r, &refundRequest); err != nil {
+			log.Printf("Error decoding refund body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		refund := func(ctx context.Context, id string) (*models.PostPaymentResponse, error) {
+			return ph.domain.PaymentService.RefundPayment(ctx, id, refundRequest.Amount)
+		}
+		ph.operationHandler("refund", refund)(w, r)
+	}
+}
+
+// RefundsHandler returns an http.HandlerFunc that lists the refunds made on a payment, oldest first.
+// The ID is expected to be part of the URL.
+func (h *PaymentsHandler) RefundsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		payment, err := h.storage.GetPayment(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				log.Printf("Timed out retrieving payment: %v", err)
+				writeTimeout(w, TimeoutMessage)
+				return
+			}
+			log.Printf("Error retrieving payment: %v", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
+		if payment == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		refunds := payment.Refunds
+		if refunds == nil {
+			refunds = []models.Refund{}
+		}
+
 		w.Header().Set(contentTypeHeader, jsonContentType)
 		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(domainResponse); err != nil {
+		if err := json.NewEncoder(w).Encode(refunds); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
+
+// StoreCardHandler returns an http.HandlerFunc that keeps a card on file for a customer and answers with its token.
+// The customer ID is expected to be part of the URL.
+func (ph *PaymentsHandler) StoreCardHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		customerID := chi.URLParam(r, "id")
+		if customerID == "" || r.Body == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var cardRequest models.StoreCardRequest
+		if err := json.NewDecoder(r.Body).Decode(&cardRequest); err != nil {
+			log.Printf("Error decoding card body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		card, err := ph.domain.PaymentService.StoreCard(r.Context(), customerID, &cardRequest)
+		if err != nil {
+			var validationErr *gatewayerrors.ValidationError
+			switch {
+			case errors.As(err, &validationErr):
+				log.Printf("validation error storing card field: %v", validationErr.GetFieldError())
+				writeError(w, http.StatusBadRequest, sentence(validationErr.Error()))
+			case errors.Is(err, context.DeadlineExceeded):
+				log.Printf("Timed out storing card: %v", err)
+				writeTimeout(w, TimeoutMessage)
+			default:
+				log.Printf("Error storing card: %v", err)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(card); err != nil {
+			log.Printf("Failed to encode card: %v", err)
+		}
+	}
+}
+
+// CreateIntentHandler returns an http.HandlerFunc that creates a payment intent for the amount and currency in the body.
+func (ph *PaymentsHandler) CreateIntentHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var intentRequest models.CreatePaymentIntentRequest
+		if err := json.NewDecoder(r.Body).Decode(&intentRequest); err != nil {
+			log.Printf("Error decoding payment intent body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		intent, err := ph.domain.PaymentService.CreatePaymentIntent(r.Context(), &intentRequest)
+		if err != nil {
+			writeOperationError(w, "payment intent", err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, intent)
+	}
+}
+
+// GetIntentHandler returns an http.HandlerFunc that retrieves a payment intent.
+// The ID is expected to be part of the URL.
+func (ph *PaymentsHandler) GetIntentHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		intent, err := ph.domain.PaymentService.GetPaymentIntent(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			writeOperationError(w, "payment intent lookup", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, intent)
+	}
+}
+
+// ConfirmIntentHandler returns an http.HandlerFunc that pays a payment intent with the card in the body.
+// The ID is expected to be part of the URL.
+func (ph *PaymentsHandler) ConfirmIntentHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var confirmRequest models.ConfirmPaymentIntentRequest
+		if err := json.NewDecoder(r.Body).Decode(&confirmRequest); err != nil {
+			log.Printf("Error decoding confirmation body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), postPaymentTimeout)
+		defer cancel()
+
+		intent, err := ph.domain.PaymentService.ConfirmPaymentIntent(ctx, chi.URLParam(r, "id"), &confirmRequest)
+		if err != nil {
+			writeOperationError(w, "confirmation", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, intent)
+	}
+}
+
+// CreateLinkHandler returns an http.HandlerFunc that creates a payment link for the amount in the body.
+func (ph *PaymentsHandler) CreateLinkHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var linkRequest models.CreatePaymentLinkRequest
+		if err := json.NewDecoder(r.Body).Decode(&linkRequest); err != nil {
+			log.Printf("Error decoding payment link body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		link, err := ph.domain.PaymentService.CreatePaymentLink(r.Context(), &linkRequest)
+		if err != nil {
+			writeOperationError(w, "payment link", err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, link)
+	}
+}
+
+// GetLinkHandler returns an http.HandlerFunc that retrieves a payment link.
+// The ID is expected to be part of the URL.
+func (ph *PaymentsHandler) GetLinkHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		link, err := ph.domain.PaymentService.GetPaymentLink(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			writeOperationError(w, "payment link lookup", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, link)
+	}
+}
+
+// PayLinkHandler returns an http.HandlerFunc that pays a payment link with the card in the body and answers with the payment.
+// The ID is expected to be part of the URL.
+func (ph *PaymentsHandler) PayLinkHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var payRequest models.PayPaymentLinkRequest
+		if err := json.NewDecoder(r.Body).Decode(&payRequest); err != nil {
+			log.Printf("Error decoding payment link payment body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), postPaymentTimeout)
+		defer cancel()
+
+		payment, err := ph.domain.PaymentService.PayPaymentLink(ctx, chi.URLParam(r, "id"), &payRequest)
+		if err != nil {
+			writeOperationError(w, "payment", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, payment)
+	}
+}
+
+// CreatePlanHandler returns an http.HandlerFunc that creates an installment plan, taking the first installment from the card in the body.
+func (ph *PaymentsHandler) CreatePlanHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var planRequest models.CreateInstallmentPlanRequest
+		if err := json.NewDecoder(r.Body).Decode(&planRequest); err != nil {
+			log.Printf("Error decoding installment plan body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), postPaymentTimeout)
+		defer cancel()
+
+		plan, err := ph.domain.PaymentService.CreateInstallmentPlan(ctx, &planRequest)
+		if err != nil {
+			writeOperationError(w, "installment plan", err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, plan)
+	}
+}
+
+// GetPlanHandler returns an http.HandlerFunc that retrieves an installment plan and the progress of its installments.
+// The ID is expected to be part of the URL.
+func (ph *PaymentsHandler) GetPlanHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		plan, err := ph.domain.PaymentService.GetInstallmentPlan(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			writeOperationError(w, "installment plan lookup", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, plan)
+	}
+}
+
+// CancelPlanHandler returns an http.HandlerFunc that cancels the installments of a plan that are still scheduled.
+// The ID is expected to be part of the URL.
+func (ph *PaymentsHandler) CancelPlanHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		plan, err := ph.domain.PaymentService.CancelInstallmentPlan(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			writeOperationError(w, "installment plan cancellation", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, plan)
+	}
+}
+
+// CardVerificationHandler returns an http.HandlerFunc that checks the card in the body with a zero amount authorization.
+func (ph *PaymentsHandler) CardVerificationHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var verificationRequest models.CardVerificationRequest
+		if err := json.NewDecoder(r.Body).Decode(&verificationRequest); err != nil {
+			log.Printf("Error decoding card verification body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), postPaymentTimeout)
+		defer cancel()
+
+		verification, err := ph.domain.PaymentService.VerifyCard(ctx, &verificationRequest)
+		if err != nil {
+			writeOperationError(w, "card verification", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, verification)
+	}
+}
+
+// OpenDisputeHandler returns an http.HandlerFunc that records an issuer's chargeback against the payment named in the body.
+func (ph *PaymentsHandler) OpenDisputeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var disputeRequest models.OpenDisputeRequest
+		if err := json.NewDecoder(r.Body).Decode(&disputeRequest); err != nil {
+			log.Printf("Error decoding dispute body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		dispute, err := ph.domain.PaymentService.OpenDispute(r.Context(), &disputeRequest)
+		if err != nil {
+			writeOperationError(w, "dispute", err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, dispute)
+	}
+}
+
+// DisputeEvidenceHandler returns an http.HandlerFunc that records the merchant's evidence for an open dispute.
+// The dispute ID is expected to be part of the URL.
+func (ph *PaymentsHandler) DisputeEvidenceHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var evidenceRequest models.DisputeEvidenceRequest
+		if err := decodeOptionalBody(r, &evidenceRequest); err != nil {
+			log.Printf("Error decoding evidence body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		dispute, err := ph.domain.PaymentService.SubmitDisputeEvidence(r.Context(), chi.URLParam(r, "id"), evidenceRequest.Evidence)
+		if err != nil {
+			writeOperationError(w, "dispute evidence", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, dispute)
+	}
+}
+
+// ResolveDisputeHandler returns an http.HandlerFunc that records the issuer's decision on a dispute.
+// The dispute ID is expected to be part of the URL.
+func (ph *PaymentsHandler) ResolveDisputeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var resolveRequest models.ResolveDisputeRequest
+		if err := decodeOptionalBody(r, &resolveRequest); err != nil {
+			log.Printf("Error decoding resolution body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		dispute, err := ph.domain.PaymentService.ResolveDispute(r.Context(), chi.URLParam(r, "id"), resolveRequest.Outcome)
+		if err != nil {
+			writeOperationError(w, "dispute resolution", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, dispute)
+	}
+}
+
+// DisputesHandler returns an http.HandlerFunc that lists the disputes raised against a payment, oldest first.
+// The ID is expected to be part of the URL.
+func (ph *PaymentsHandler) DisputesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		disputes, err := ph.domain.PaymentService.ListDisputes(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			writeOperationError(w, "dispute lookup", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, disputes)
+	}
+}
+
+// EvidenceBundleHandler returns an http.HandlerFunc that answers with the evidence the gateway holds for a dispute as a JSON download.
+// The ID is expected to be part of the URL.
+func (ph *PaymentsHandler) EvidenceBundleHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		bundle, err := ph.domain.PaymentService.GetDisputeEvidenceBundle(r.Context(), id)
+		if err != nil {
+			writeOperationError(w, "evidence bundle", err)
+			return
+		}
+
+		w.Header().Set("Content-Disposition", `attachment; filename="dispute-`+bundle.DisputeId+`-evidence.json"`)
+		writeJSON(w, http.StatusOK, bundle)
+	}
+}
+
+// writeJSON answers with v encoded as JSON.
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set(contentTypeHeader, jsonContentType)
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// decodeOptionalBody decodes the JSON request body into v, leaving v as it is when there is no body.
+func decodeOptionalBody(r *http.Request, v any) error {
+	if r.Body == nil {
+		return nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// operationHandler runs an operation on the payment named in the URL and answers with the updated payment.
+func (ph *PaymentsHandler) operationHandler(operation string, run func(ctx context.Context, id string) (*models.PostPaymentResponse, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		if id == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), postPaymentTimeout)
+		defer cancel()
+
+		payment, err := run(ctx, id)
+		if err != nil {
+			writeOperationError(w, operation, err)
+			return
+		}
+
+		w.Header().Set(contentTypeHeader, jsonContentType)
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(payment); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 	}
 }
+
+// writeOperationError answers for a failed operation on an existing payment, such as a capture.
+func writeOperationError(w http.ResponseWriter, operation string, err error) {
+	var timeoutErr *gatewayerrors.TimeoutError
+	var validationErr *gatewayerrors.ValidationError
+	var stateErr *gatewayerrors.StateError
+	var bankErr *gatewayerrors.BankError
+
+	switch {
+	case errors.As(err, &timeoutErr):
+		log.Printf("Timed out on payment %s (bank called: %t): %v", operation, timeoutErr.BankCalled, err)
+		message := "The " + operation + " could not be completed in time and was not sent to the acquiring bank. It is safe to retry."
+		if timeoutErr.BankCalled {
+			message = "The " + operation + " could not be completed in time, its outcome is unknown. Please check the payment status before retrying."
+		}
+		writeTimeout(w, message)
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("Timed out on payment %s: %v", operation, err)
+		writeTimeout(w, TimeoutMessage)
+	case errors.Is(err, gatewayerrors.ErrPaymentNotFound),
+		errors.Is(err, gatewayerrors.ErrPaymentIntentNotFound),
+		errors.Is(err, gatewayerrors.ErrDisputeNotFound),
+		errors.Is(err, gatewayerrors.ErrPaymentLinkNotFound),
+		errors.Is(err, gatewayerrors.ErrInstallmentPlanNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.As(err, &validationErr):
+		log.Printf("validation error on payment %s field: %v", operation, validationErr.GetFieldError())
+		writeError(w, http.StatusBadRequest, sentence(validationErr.Error()))
+	case errors.As(err, &stateErr):
+		log.Printf("Rejected payment %s on %s payment %s: %v", operation, stateErr.Status, stateErr.ID, err)
+		writeError(w, http.StatusConflict, sentence(stateErr.Error()))
+	case errors.As(err, &bankErr) && bankErr.StatusCode == http.StatusServiceUnavailable:
+		log.Printf("Error on payment %s: %v", operation, err)
+		writeError(w, http.StatusServiceUnavailable, "The acquiring bank is currently unavailable. Please try again later.")
+	case errors.As(err, &bankErr):
+		log.Printf("Acquiring bank failed payment %s: %v", operation, err)
+		writeError(w, http.StatusBadGateway, "The acquiring bank did not complete the "+operation+".")
+	default:
+		log.Printf("Unsupported error on payment %s: %v", operation, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// sentence turns an error message into a sentence for the response body.
+func sentence(message string) string {
+	if message == "" {
+		return message
+	}
+	return strings.ToUpper(message[:1]) + message[1:] + "."
+}
+
+// TimeoutMessage is returned when a request runs out of time before anything with side effects happened.
+const TimeoutMessage = "The request could not be completed in time. Please try again later."
+
+func writeTimeout(w http.ResponseWriter, message string) {
+	writeError(w, http.StatusGatewayTimeout, message)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set(contentTypeHeader, jsonContentType)
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(HandlerErrorResponse{Message: message}); err != nil {
+		log.Printf("Failed to encode error response: %v", err)
+	}
+}
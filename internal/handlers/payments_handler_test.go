@@ -2,12 +2,14 @@ package handlers_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/domain/mocks"
@@ -33,7 +35,7 @@ func TestGetPaymentHandler(t *testing.T) {
 		Amount:             100,
 	}
 	ps := repository.NewPaymentsRepository()
-	ps.AddPayment(savedPayment)
+	require.NoError(t, ps.AddPayment(context.Background(), savedPayment))
 
 	expectedPayment := models.GetPaymentHandlerResponse{
 		Id:                 "test-id",
@@ -107,7 +109,7 @@ func TestPostPaymentHandler(t *testing.T) {
 		Amount:             100,
 	}
 	ps := repository.NewPaymentsRepository()
-	ps.AddPayment(expectedPayment)
+	require.NoError(t, ps.AddPayment(context.Background(), expectedPayment))
 	ctrl := gomock.NewController(t)
 	mockPaymentService := mocks.NewMockPaymentService(ctrl)
 	defer ctrl.Finish()
@@ -145,7 +147,7 @@ func TestPostPaymentHandler(t *testing.T) {
 	require.NoError(t, err)
 
 	postPaymentResponseID := uuid.New().String()
-	mockDomain.PaymentService.(*mocks.MockPaymentService).EXPECT().Create(postPayment).Return(&models.PostPaymentResponse{
+	mockDomain.PaymentService.(*mocks.MockPaymentService).EXPECT().Create(gomock.Any(), postPayment).Return(&models.PostPaymentResponse{
 		Id:                 postPaymentResponseID,
 		PaymentStatus:      "authorized",
 		CardNumberLastFour: 8877,
@@ -186,6 +188,89 @@ func TestPostPaymentHandler(t *testing.T) {
 
 }
 
+func TestPostPaymentHandler_RespondAsync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+	r := chi.NewRouter()
+	r.Post("/api/payments", payments.PostHandler())
+
+	postPayment := &models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+	}
+	mockPaymentService.EXPECT().Submit(gomock.Any(), postPayment).Return(&models.PostPaymentResponse{
+		Id:            "test-id",
+		PaymentStatus: "processing",
+		Amount:        100,
+	}, nil)
+
+	body, err := json.Marshal(postPayment)
+	require.NoError(t, err)
+	req, err := http.NewRequest("POST", "/api/payments", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	req.Header.Set("Prefer", "wait=10, respond-async")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	var response models.PostPaymentResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "/api/v1/payments/test-id", w.Header().Get("Location"))
+	assert.Equal(t, "respond-async", w.Header().Get("Preference-Applied"))
+	assert.Equal(t, "processing", response.PaymentStatus)
+}
+
+func TestPostPaymentHandler_Scheduled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+	r := chi.NewRouter()
+	r.Post("/api/payments", payments.PostHandler())
+	r.Delete("/api/payments/{id}", payments.CancelHandler())
+
+	executeAt := time.Date(2035, 1, 2, 9, 0, 0, 0, time.UTC)
+	mockPaymentService.EXPECT().Create(gomock.Any(), gomock.Any()).Return(&models.PostPaymentResponse{
+		Id:            "test-id",
+		PaymentStatus: "scheduled",
+		ExecuteAt:     &executeAt,
+	}, nil)
+	mockPaymentService.EXPECT().CancelPayment(gomock.Any(), "test-id").Return(&models.PostPaymentResponse{
+		Id:            "test-id",
+		PaymentStatus: "cancelled",
+	}, nil)
+
+	req, err := http.NewRequest("POST", "/api/payments", bytes.NewBufferString(`{"card_number": 2222405343248877, "execute_at": "2035-01-02T09:00:00Z"}`))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "/api/v1/payments/test-id", w.Header().Get("Location"))
+	assert.Empty(t, w.Header().Get("Preference-Applied"))
+
+	req, err = http.NewRequest("DELETE", "/api/payments/test-id", nil)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response models.PostPaymentResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "cancelled", response.PaymentStatus)
+}
+
 func TestPostPaymentHandler_NoBody(t *testing.T) {
 
 	payments := handlers.NewPaymentsHandler(nil, nil)
@@ -258,7 +343,7 @@ func TestBankError_DomainError(t *testing.T) {
 		Amount:             100,
 	}
 	ps := repository.NewPaymentsRepository()
-	ps.AddPayment(expectedPayment)
+	require.NoError(t, ps.AddPayment(context.Background(), expectedPayment))
 	ctrl := gomock.NewController(t)
 	mockPaymentService := mocks.NewMockPaymentService(ctrl)
 	defer ctrl.Finish()
@@ -295,7 +380,7 @@ func TestBankError_DomainError(t *testing.T) {
 	body, err := json.Marshal(postPayment)
 	require.NoError(t, err)
 
-	mockDomain.PaymentService.(*mocks.MockPaymentService).EXPECT().Create(postPayment).Return(nil, errors.New("boom"))
+	mockDomain.PaymentService.(*mocks.MockPaymentService).EXPECT().Create(gomock.Any(), postPayment).Return(nil, errors.New("boom"))
 
 	// Act
 	req, err := http.NewRequest("POST", "/api/payments", bytes.NewBuffer(body))
@@ -322,7 +407,7 @@ func TestBankError_ServiceUnavailable(t *testing.T) {
 		Amount:             100,
 	}
 	ps := repository.NewPaymentsRepository()
-	ps.AddPayment(expectedPayment)
+	require.NoError(t, ps.AddPayment(context.Background(), expectedPayment))
 	ctrl := gomock.NewController(t)
 	mockPaymentService := mocks.NewMockPaymentService(ctrl)
 	defer ctrl.Finish()
@@ -363,7 +448,7 @@ func TestBankError_ServiceUnavailable(t *testing.T) {
 		errors.New("acquiring bank unavailble"),
 		http.StatusServiceUnavailable,
 	)
-	mockDomain.PaymentService.(*mocks.MockPaymentService).EXPECT().Create(postPayment).Return(nil, mockedError)
+	mockDomain.PaymentService.(*mocks.MockPaymentService).EXPECT().Create(gomock.Any(), postPayment).Return(nil, mockedError)
 
 	// Act
 	req, err := http.NewRequest("POST", "/api/payments", bytes.NewBuffer(body))
@@ -383,6 +468,56 @@ func TestBankError_ServiceUnavailable(t *testing.T) {
 	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
 }
 
+func TestBankError_BadGateway(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	mockDomain := &domain.Domain{
+		PaymentService: mockPaymentService,
+	}
+
+	payments := handlers.NewPaymentsHandler(repository.NewPaymentsRepository(), mockDomain)
+
+	r := chi.NewRouter()
+	r.Post("/api/payments", payments.PostHandler())
+
+	// Arrange
+	postPayment := &models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+	}
+
+	body, err := json.Marshal(postPayment)
+	require.NoError(t, err)
+
+	mockedError := gatewayerrors.NewBankError(
+		errors.New("acquiring bank failed"),
+		http.StatusInternalServerError,
+	)
+	mockPaymentService.EXPECT().Create(gomock.Any(), postPayment).Return(nil, mockedError)
+
+	// Act
+	req, err := http.NewRequest("POST", "/api/payments", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	var response handlers.HandlerErrorResponse
+	err = json.NewDecoder(w.Body).Decode(&response)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, "The acquiring bank did not complete the payment.", response.Message)
+}
+
 func TestBankError_ValidationError(t *testing.T) {
 
 	id := uuid.NewString()
@@ -428,7 +563,7 @@ func TestBankError_ValidationError(t *testing.T) {
 		"card_number",
 	)
 
-	mockDomain.PaymentService.(*mocks.MockPaymentService).EXPECT().Create(postPayment).Return(nil, mockedError)
+	mockDomain.PaymentService.(*mocks.MockPaymentService).EXPECT().Create(gomock.Any(), postPayment).Return(nil, mockedError)
 
 	// Act
 	req, err := http.NewRequest("POST", "/api/payments", bytes.NewBuffer(body))
@@ -456,3 +591,757 @@ func getLastFourCharacters(t *testing.T, i int) string {
 	require.Equal(t, 16, len(s))
 	return s[len(s)-4:]
 }
+
+func TestPostPaymentHandler_Timeout(t *testing.T) {
+	tests := []struct {
+		name            string
+		bankCalled      bool
+		expectedMessage string
+	}{
+		{
+			name:            "BankCalled",
+			bankCalled:      true,
+			expectedMessage: "The payment could not be completed in time, its outcome is unknown. Please check the payment status before retrying.",
+		},
+		{
+			name:            "BankNotCalled",
+			bankCalled:      false,
+			expectedMessage: "The payment could not be completed in time and was not sent to the acquiring bank. It is safe to retry.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockPaymentService := mocks.NewMockPaymentService(ctrl)
+			defer ctrl.Finish()
+
+			mockDomain := &domain.Domain{
+				PaymentService: mockPaymentService,
+			}
+
+			payments := handlers.NewPaymentsHandler(nil, mockDomain)
+
+			r := chi.NewRouter()
+			r.Post("/api/payments", payments.PostHandler())
+
+			// Arrange
+			postPayment := &models.PostPaymentHandlerRequest{
+				CardNumber:  2222405343248877,
+				ExpiryMonth: 4,
+				ExpiryYear:  2035,
+				Currency:    "GBP",
+				Amount:      100,
+				Cvv:         123,
+			}
+
+			body, err := json.Marshal(postPayment)
+			require.NoError(t, err)
+
+			mockPaymentService.EXPECT().Create(gomock.Any(), postPayment).DoAndReturn(
+				func(ctx context.Context, _ *models.PostPaymentHandlerRequest) (*models.PostPaymentResponse, error) {
+					// the handler must hand the domain a deadline
+					_, ok := ctx.Deadline()
+					assert.True(t, ok)
+					return nil, gatewayerrors.NewTimeoutError(context.DeadlineExceeded, tt.bankCalled)
+				})
+
+			// Act
+			req, err := http.NewRequest("POST", "/api/payments", bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			var response handlers.HandlerErrorResponse
+			err = json.NewDecoder(w.Body).Decode(&response)
+			require.NoError(t, err)
+
+			// Assert
+			assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+			assert.Equal(t, tt.expectedMessage, response.Message)
+		})
+	}
+}
+
+func TestGetPaymentHandler_Timeout(t *testing.T) {
+	payments := handlers.NewPaymentsHandler(repository.NewPaymentsRepository(), nil)
+
+	r := chi.NewRouter()
+	r.Get("/api/payments/{id}", payments.GetHandler())
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "/api/payments/test-id", nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+}
+
+func TestCapturePaymentHandler(t *testing.T) {
+	tests := []struct {
+		name            string
+		domainErr       error
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{
+			name:           "Captured",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "NotFound",
+			domainErr:      gatewayerrors.ErrPaymentNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:            "NotAuthorized",
+			domainErr:       gatewayerrors.NewStateError(errors.New("cannot capture a declined payment"), "test-id", "declined"),
+			expectedStatus:  http.StatusConflict,
+			expectedMessage: "Cannot capture a declined payment.",
+		},
+		{
+			name:            "BankUnavailable",
+			domainErr:       gatewayerrors.NewBankError(errors.New("acquiring bank unavailble"), http.StatusServiceUnavailable),
+			expectedStatus:  http.StatusServiceUnavailable,
+			expectedMessage: "The acquiring bank is currently unavailable. Please try again later.",
+		},
+		{
+			name:            "TimeoutAfterBankCall",
+			domainErr:       gatewayerrors.NewTimeoutError(context.DeadlineExceeded, true),
+			expectedStatus:  http.StatusGatewayTimeout,
+			expectedMessage: "The capture could not be completed in time, its outcome is unknown. Please check the payment status before retrying.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockPaymentService := mocks.NewMockPaymentService(ctrl)
+			defer ctrl.Finish()
+
+			payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+			r := chi.NewRouter()
+			r.Post("/api/payments/{id}/capture", payments.CaptureHandler())
+
+			captured := &models.PostPaymentResponse{Id: "test-id", PaymentStatus: "captured", Amount: 100}
+			if tt.domainErr != nil {
+				captured = nil
+			}
+			mockPaymentService.EXPECT().CapturePayment(gomock.Any(), "test-id", 0).Return(captured, tt.domainErr)
+
+			req, err := http.NewRequest("POST", "/api/payments/test-id/capture", nil)
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response models.PostPaymentResponse
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+				assert.Equal(t, *captured, response)
+			}
+			if tt.expectedMessage != "" {
+				var response handlers.HandlerErrorResponse
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+				assert.Equal(t, tt.expectedMessage, response.Message)
+			}
+		})
+	}
+}
+
+func TestVoidPaymentHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+	r := chi.NewRouter()
+	r.Post("/api/payments/{id}/void", payments.VoidHandler())
+
+	mockPaymentService.EXPECT().VoidPayment(gomock.Any(), "test-id").Return(nil,
+		gatewayerrors.NewStateError(gatewayerrors.NewTransitionError("void", "captured", "voided"), "test-id", "captured"))
+
+	req, err := http.NewRequest("POST", "/api/payments/test-id/void", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	var response handlers.HandlerErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "Cannot void a captured payment.", response.Message)
+}
+
+func TestReversePaymentHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+	r := chi.NewRouter()
+	r.Post("/api/payments/{id}/reverse", payments.ReverseHandler())
+
+	mockPaymentService.EXPECT().ReversePayment(gomock.Any(), "test-id").Return(&models.PostPaymentResponse{
+		Id:            "test-id",
+		PaymentStatus: "reversed",
+		Amount:        100,
+	}, nil)
+
+	req, err := http.NewRequest("POST", "/api/payments/test-id/reverse", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	var response models.PostPaymentResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "reversed", response.PaymentStatus)
+}
+
+func TestGetPaymentHandler_ShowsRefunds(t *testing.T) {
+	refund := models.Refund{Id: "refund-id", Amount: 100, CreatedAt: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)}
+	ps := repository.NewPaymentsRepository()
+	require.NoError(t, ps.AddPayment(context.Background(), models.PostPaymentResponse{
+		Id:            "test-id",
+		PaymentStatus: "refunded",
+		Amount:        100,
+		Refunds:       []models.Refund{refund},
+	}))
+
+	payments := handlers.NewPaymentsHandler(ps, nil)
+
+	r := chi.NewRouter()
+	r.Get("/api/payments/{id}", payments.GetHandler())
+
+	req, err := http.NewRequest("GET", "/api/payments/test-id", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	var response models.GetPaymentHandlerResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "refunded", response.Status)
+	assert.Equal(t, []models.Refund{refund}, response.Refunds)
+}
+
+func TestGetPaymentHandler_ShowsDeclineReason(t *testing.T) {
+	reason := &models.DeclineReason{Code: "51", Message: "Insufficient funds"}
+	ps := repository.NewPaymentsRepository()
+	require.NoError(t, ps.AddPayment(context.Background(), models.PostPaymentResponse{
+		Id:            "test-id",
+		PaymentStatus: "declined",
+		Amount:        100,
+		DeclineReason: reason,
+	}))
+
+	payments := handlers.NewPaymentsHandler(ps, nil)
+
+	r := chi.NewRouter()
+	r.Get("/api/payments/{id}", payments.GetHandler())
+
+	req, err := http.NewRequest("GET", "/api/payments/test-id", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	var response models.GetPaymentHandlerResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "declined", response.Status)
+	assert.Equal(t, reason, response.DeclineReason)
+}
+
+func TestRefundPaymentHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedAmount int
+	}{
+		{name: "Full", body: "", expectedAmount: 0},
+		{name: "Partial", body: `{"amount": 30}`, expectedAmount: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockPaymentService := mocks.NewMockPaymentService(ctrl)
+			defer ctrl.Finish()
+
+			payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+			r := chi.NewRouter()
+			r.Post("/api/payments/{id}/refund", payments.RefundHandler())
+
+			refunded := &models.PostPaymentResponse{Id: "test-id", PaymentStatus: "partially_refunded", Amount: 100}
+			mockPaymentService.EXPECT().RefundPayment(gomock.Any(), "test-id", tt.expectedAmount).Return(refunded, nil)
+
+			req, err := http.NewRequest("POST", "/api/payments/test-id/refund", bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
+
+func TestGetRefundsHandler(t *testing.T) {
+	refunds := []models.Refund{
+		{Id: "refund-1", Amount: 30, CreatedAt: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)},
+		{Id: "refund-2", Amount: 70, CreatedAt: time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)},
+	}
+	ps := repository.NewPaymentsRepository()
+	require.NoError(t, ps.AddPayment(context.Background(), models.PostPaymentResponse{Id: "refunded-id", PaymentStatus: "refunded", Amount: 100, Refunds: refunds}))
+	require.NoError(t, ps.AddPayment(context.Background(), models.PostPaymentResponse{Id: "captured-id", PaymentStatus: "captured", Amount: 100}))
+
+	payments := handlers.NewPaymentsHandler(ps, nil)
+
+	r := chi.NewRouter()
+	r.Get("/api/payments/{id}/refunds", payments.RefundsHandler())
+
+	tests := []struct {
+		id              string
+		expectedStatus  int
+		expectedRefunds []models.Refund
+	}{
+		{id: "refunded-id", expectedStatus: http.StatusOK, expectedRefunds: refunds},
+		{id: "captured-id", expectedStatus: http.StatusOK, expectedRefunds: []models.Refund{}},
+		{id: "unknown-id", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/payments/"+tt.id+"/refunds", nil)
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedRefunds != nil {
+				var response []models.Refund
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+				assert.Equal(t, tt.expectedRefunds, response)
+			}
+		})
+	}
+}
+
+func TestGetPaymentHandler_AsOf(t *testing.T) {
+	ps := repository.NewPaymentsRepository()
+	require.NoError(t, ps.AddPayment(context.Background(), models.PostPaymentResponse{
+		Id:            "test-id",
+		PaymentStatus: "authorized",
+		Amount:        100,
+	}))
+
+	payments := handlers.NewPaymentsHandler(ps, nil)
+
+	r := chi.NewRouter()
+	r.Get("/api/payments/{id}", payments.GetHandler())
+
+	tests := []struct {
+		name         string
+		asOf         string
+		expectedCode int
+	}{
+		{name: "BeforePaymentExisted", asOf: "2000-01-01T00:00:00Z", expectedCode: http.StatusNotFound},
+		{name: "AfterPaymentExisted", asOf: time.Now().Add(time.Hour).Format(time.RFC3339), expectedCode: http.StatusOK},
+		{name: "InvalidTimestamp", asOf: "yesterday", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/payments/test-id?as_of="+tt.asOf, nil)
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestListPaymentsHandler(t *testing.T) {
+	ps := repository.NewPaymentsRepository()
+	require.NoError(t, ps.AddPayment(context.Background(), models.PostPaymentResponse{Id: "gbp", PaymentStatus: "authorized", Currency: "GBP", Amount: 100}))
+	require.NoError(t, ps.AddPayment(context.Background(), models.PostPaymentResponse{Id: "eur", PaymentStatus: "authorized", Currency: "EUR", Amount: 200}))
+
+	payments := handlers.NewPaymentsHandler(ps, nil)
+
+	r := chi.NewRouter()
+	r.Get("/api/payments", payments.ListHandler())
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expectedIds  []string
+	}{
+		{name: "Everything", query: "", expectedCode: http.StatusOK, expectedIds: []string{"gbp", "eur"}},
+		{name: "Filtered", query: "?currency=EUR&min_amount=150&created_before=" + time.Now().Add(time.Hour).Format(time.RFC3339), expectedCode: http.StatusOK, expectedIds: []string{"eur"}},
+		{name: "NoMatch", query: "?status=refunded", expectedCode: http.StatusOK, expectedIds: []string{}},
+		{name: "InvalidAmount", query: "?max_amount=ten", expectedCode: http.StatusBadRequest},
+		{name: "InvalidTimestamp", query: "?created_after=yesterday", expectedCode: http.StatusBadRequest},
+		{name: "LastFour", query: "?last_four=0000", expectedCode: http.StatusOK, expectedIds: []string{"gbp", "eur"}},
+		{name: "InvalidLastFour", query: "?last_four=877", expectedCode: http.StatusBadRequest},
+		{name: "SignedLastFour", query: "?last_four=%2B877", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/payments"+tt.query, nil)
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+			var response []models.GetPaymentHandlerResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			ids := []string{}
+			for _, payment := range response {
+				ids = append(ids, payment.Id)
+			}
+			assert.Equal(t, tt.expectedIds, ids)
+		})
+	}
+}
+
+func TestStoreCardHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+	r := chi.NewRouter()
+	r.Post("/api/customers/{id}/cards", payments.StoreCardHandler())
+
+	cardRequest := &models.StoreCardRequest{CardNumber: 2222405343248877, ExpiryMonth: 4, ExpiryYear: 2035}
+	mockPaymentService.EXPECT().StoreCard(gomock.Any(), "customer-id", cardRequest).Return(&models.StoredCard{
+		Token:              "token",
+		CustomerId:         "customer-id",
+		CardNumberLastFour: 8877,
+		ExpiryMonth:        4,
+		ExpiryYear:         2035,
+		CardNumber:         2222405343248877,
+	}, nil)
+
+	body, err := json.Marshal(cardRequest)
+	require.NoError(t, err)
+	req, err := http.NewRequest("POST", "/api/customers/customer-id/cards", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotContains(t, w.Body.String(), "2222405343248877")
+
+	var response models.StoredCard
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "token", response.Token)
+	assert.Equal(t, 8877, response.CardNumberLastFour)
+}
+
+func TestPaymentIntentHandlers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+	r := chi.NewRouter()
+	r.Post("/api/payment-intents", payments.CreateIntentHandler())
+	r.Get("/api/payment-intents/{id}", payments.GetIntentHandler())
+	r.Post("/api/payment-intents/{id}/confirm", payments.ConfirmIntentHandler())
+
+	created := &models.PaymentIntent{Id: "intent-id", Status: "requires_confirmation", Amount: 100, Currency: "GBP"}
+	mockPaymentService.EXPECT().CreatePaymentIntent(gomock.Any(), &models.CreatePaymentIntentRequest{Amount: 100, Currency: "GBP"}).Return(created, nil)
+	mockPaymentService.EXPECT().GetPaymentIntent(gomock.Any(), "missing").Return(nil, gatewayerrors.ErrPaymentIntentNotFound)
+	succeeded := &models.PaymentIntent{Id: "intent-id", Status: "succeeded", Amount: 100, Currency: "GBP", PaymentId: "payment-id"}
+	mockPaymentService.EXPECT().ConfirmPaymentIntent(gomock.Any(), "intent-id", &models.ConfirmPaymentIntentRequest{CardToken: "token"}).Return(succeeded, nil)
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+	}{
+		{name: "Create", method: "POST", path: "/api/payment-intents", body: `{"amount": 100, "currency": "GBP"}`, expectedCode: http.StatusCreated},
+		{name: "GetMissing", method: "GET", path: "/api/payment-intents/missing", expectedCode: http.StatusNotFound},
+		{name: "Confirm", method: "POST", path: "/api/payment-intents/intent-id/confirm", body: `{"card_token": "token"}`, expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestPaymentLinkHandlers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+	r := chi.NewRouter()
+	r.Post("/api/payment-links", payments.CreateLinkHandler())
+	r.Get("/api/payment-links/{id}", payments.GetLinkHandler())
+	r.Post("/api/payment-links/{id}/pay", payments.PayLinkHandler())
+
+	created := &models.PaymentLink{Id: "link-id", Status: "active", Amount: 100, Currency: "GBP"}
+	mockPaymentService.EXPECT().CreatePaymentLink(gomock.Any(), &models.CreatePaymentLinkRequest{Amount: 100, Currency: "GBP"}).Return(created, nil)
+	mockPaymentService.EXPECT().GetPaymentLink(gomock.Any(), "missing").Return(nil, gatewayerrors.ErrPaymentLinkNotFound)
+	payment := &models.PostPaymentResponse{Id: "payment-id", PaymentStatus: "authorized", Amount: 100}
+	mockPaymentService.EXPECT().PayPaymentLink(gomock.Any(), "link-id", &models.PayPaymentLinkRequest{CardNumber: 2222405343248877, ExpiryMonth: 4, ExpiryYear: 2035, Cvv: 123}).Return(payment, nil)
+	mockPaymentService.EXPECT().PayPaymentLink(gomock.Any(), "paid-id", gomock.Any()).
+		Return(nil, gatewayerrors.NewStateError(errors.New("cannot pay a paid payment link"), "paid-id", "paid"))
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+	}{
+		{name: "Create", method: "POST", path: "/api/payment-links", body: `{"amount": 100, "currency": "GBP"}`, expectedCode: http.StatusCreated},
+		{name: "GetMissing", method: "GET", path: "/api/payment-links/missing", expectedCode: http.StatusNotFound},
+		{name: "Pay", method: "POST", path: "/api/payment-links/link-id/pay", body: `{"card_number": 2222405343248877, "expiry_month": 4, "expiry_year": 2035, "cvv": 123}`, expectedCode: http.StatusOK},
+		{name: "PayAgain", method: "POST", path: "/api/payment-links/paid-id/pay", body: `{}`, expectedCode: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestEvidenceBundleHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+	r := chi.NewRouter()
+	r.Get("/api/disputes/{id}/evidence-bundle", payments.EvidenceBundleHandler())
+
+	mockPaymentService.EXPECT().GetDisputeEvidenceBundle(gomock.Any(), "dispute-id").
+		Return(&models.EvidenceBundle{DisputeId: "dispute-id", PaymentId: "payment-id", PriorPayments: []models.PriorPayment{}}, nil)
+	mockPaymentService.EXPECT().GetDisputeEvidenceBundle(gomock.Any(), "missing").Return(nil, gatewayerrors.ErrDisputeNotFound)
+
+	req, err := http.NewRequest("GET", "/api/disputes/dispute-id/evidence-bundle", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="dispute-dispute-id-evidence.json"`, w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), `"payment_id":"payment-id"`)
+
+	req, err = http.NewRequest("GET", "/api/disputes/missing/evidence-bundle", nil)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestInstallmentPlanHandlers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+	r := chi.NewRouter()
+	r.Post("/api/installment-plans", payments.CreatePlanHandler())
+	r.Get("/api/installment-plans/{id}", payments.GetPlanHandler())
+	r.Post("/api/installment-plans/{id}/cancel", payments.CancelPlanHandler())
+
+	plan := &models.InstallmentPlan{Id: "plan-id", Status: "active", Amount: 1000, Currency: "GBP", Interval: "monthly"}
+	mockPaymentService.EXPECT().CreateInstallmentPlan(gomock.Any(), &models.CreateInstallmentPlanRequest{
+		CardNumber: 2222405343248877, ExpiryMonth: 4, ExpiryYear: 2035, Cvv: 123, Currency: "GBP", Amount: 1000, Installments: 3, Interval: "monthly",
+	}).Return(plan, nil)
+	mockPaymentService.EXPECT().GetInstallmentPlan(gomock.Any(), "missing").Return(nil, gatewayerrors.ErrInstallmentPlanNotFound)
+	mockPaymentService.EXPECT().CancelInstallmentPlan(gomock.Any(), "plan-id").Return(&models.InstallmentPlan{Id: "plan-id", Status: "cancelled"}, nil)
+	mockPaymentService.EXPECT().CancelInstallmentPlan(gomock.Any(), "completed-id").
+		Return(nil, gatewayerrors.NewStateError(errors.New("cannot cancel a completed installment plan"), "completed-id", "completed"))
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+	}{
+		{name: "Create", method: "POST", path: "/api/installment-plans", body: `{"card_number": 2222405343248877, "expiry_month": 4, "expiry_year": 2035, "cvv": 123, "currency": "GBP", "amount": 1000, "installments": 3, "interval": "monthly"}`, expectedCode: http.StatusCreated},
+		{name: "GetMissing", method: "GET", path: "/api/installment-plans/missing", expectedCode: http.StatusNotFound},
+		{name: "Cancel", method: "POST", path: "/api/installment-plans/plan-id/cancel", expectedCode: http.StatusOK},
+		{name: "CancelCompleted", method: "POST", path: "/api/installment-plans/completed-id/cancel", expectedCode: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestCardVerificationHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+	r := chi.NewRouter()
+	r.Post("/api/card-verifications", payments.CardVerificationHandler())
+
+	mockPaymentService.EXPECT().VerifyCard(gomock.Any(), &models.CardVerificationRequest{CardNumber: 1234, ExpiryMonth: 4, ExpiryYear: 2035, Cvv: 123}).
+		Return(nil, gatewayerrors.NewValidationError(errors.New("incorrect card length"), "test-id", "card_number"))
+
+	req, err := http.NewRequest("POST", "/api/card-verifications", bytes.NewBufferString(`{"card_number": 1234, "expiry_month": 4, "expiry_year": 2035, "cvv": 123}`))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Incorrect card length.")
+}
+
+func TestCapturePaymentHandler_PartialAmount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+	r := chi.NewRouter()
+	r.Post("/api/payments/{id}/capture", payments.CaptureHandler())
+
+	captured := &models.PostPaymentResponse{Id: "test-id", PaymentStatus: "partially_captured", Amount: 100}
+	mockPaymentService.EXPECT().CapturePayment(gomock.Any(), "test-id", 30).Return(captured, nil)
+
+	req, err := http.NewRequest("POST", "/api/payments/test-id/capture", bytes.NewBufferString(`{"amount": 30}`))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRetryPaymentHandler_CVV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+	r := chi.NewRouter()
+	r.Post("/api/payments/{id}/retry", payments.RetryHandler())
+
+	retry := &models.PostPaymentResponse{Id: "retry-id", PaymentStatus: "authorized", Amount: 100, RetryOf: "test-id"}
+	mockPaymentService.EXPECT().RetryPayment(gomock.Any(), "test-id", 123).Return(retry, nil)
+
+	req, err := http.NewRequest("POST", "/api/payments/test-id/retry", bytes.NewBufferString(`{"cvv": 123}`))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestDisputeHandlers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPaymentService := mocks.NewMockPaymentService(ctrl)
+	defer ctrl.Finish()
+
+	payments := handlers.NewPaymentsHandler(nil, &domain.Domain{PaymentService: mockPaymentService})
+
+	r := chi.NewRouter()
+	r.Post("/api/disputes", payments.OpenDisputeHandler())
+	r.Post("/api/disputes/{id}/evidence", payments.DisputeEvidenceHandler())
+	r.Post("/api/disputes/{id}/resolve", payments.ResolveDisputeHandler())
+	r.Get("/api/payments/{id}/disputes", payments.DisputesHandler())
+
+	open := &models.Dispute{Id: "dispute-id", PaymentId: "test-id", Status: "open", Amount: 100, Reason: "fraud"}
+	mockPaymentService.EXPECT().OpenDispute(gomock.Any(), &models.OpenDisputeRequest{PaymentId: "test-id", Reason: "fraud"}).Return(open, nil)
+	mockPaymentService.EXPECT().SubmitDisputeEvidence(gomock.Any(), "dispute-id", "receipt").Return(open, nil)
+	mockPaymentService.EXPECT().ResolveDispute(gomock.Any(), "dispute-id", "won").
+		Return(nil, gatewayerrors.NewStateError(errors.New("cannot resolve a won dispute"), "dispute-id", "won"))
+	mockPaymentService.EXPECT().ListDisputes(gomock.Any(), "missing").Return(nil, gatewayerrors.ErrPaymentNotFound)
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+	}{
+		{name: "Open", method: "POST", path: "/api/disputes", body: `{"payment_id": "test-id", "reason": "fraud"}`, expectedCode: http.StatusCreated},
+		{name: "Evidence", method: "POST", path: "/api/disputes/dispute-id/evidence", body: `{"evidence": "receipt"}`, expectedCode: http.StatusOK},
+		{name: "ResolveTwice", method: "POST", path: "/api/disputes/dispute-id/resolve", body: `{"outcome": "won"}`, expectedCode: http.StatusConflict},
+		{name: "ListUnknownPayment", method: "GET", path: "/api/payments/missing/disputes", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_KeysExpire(t *testing.T) {
+	now := time.Now()
+	store := NewStore(time.Hour)
+	store.now = func() time.Time { return now }
+
+	hash := sha256.Sum256([]byte("POST /api/payments"))
+	_, claimed := store.begin("key-1", hash)
+	assert.True(t, claimed)
+	store.finish("key-1", http.StatusCreated, http.Header{}, nil)
+
+	now = now.Add(time.Hour - time.Second)
+	_, claimed = store.begin("key-1", hash)
+	assert.False(t, claimed)
+
+	// once the TTL is over the key is free again and the old response is dropped
+	now = now.Add(time.Second)
+	_, claimed = store.begin("key-1", hash)
+	assert.True(t, claimed)
+
+	now = now.Add(2 * time.Hour)
+	store.begin("key-2", hash)
+	assert.NotContains(t, store.entries, "key-1")
+}
@@ -0,0 +1,231 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*
+A client that sends an Idempotency-Key with a write can safely send it again when it does not know whether the first attempt got through, e.g. after a timeout.  The first request with a key is handled as usual and its response is kept for the key's TTL, a repeat gets that same response back, marked with Idempotent-Replayed, and never reaches the handler, so a card is charged once however many times the payment is sent.
+
+A key belongs to the request it was first used with, matched by a hash of its method, path and body.  Using it for a different request is a 422, and repeating it while the first request is still being handled is a 409.  Rate limited and blocked requests (429) never ran, so they are not kept and the key stays free, as does a request whose handler wrote no response at all.  So are 503 and 504 responses, which tell the client to try again later: replaying them would answer every retry with the same failure for the key's TTL.  A 504 can also mean the bank was asked and its outcome is unknown, the response then says to check the payment before retrying.
+
+A body larger than maxBodySize is refused with a 413 rather than cut short, as the hash would not cover all of it.
+*/
+
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+
+	DefaultTTL = 24 * time.Hour
+
+	maxKeyLength = 255
+	maxBodySize  = 1 << 20
+)
+
+// retryable are the statuses of responses that are not kept, the client is meant to send the request again.
+var retryable = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// replayedHeaders are the response headers kept with a response and sent again when it is replayed.
+var replayedHeaders = []string{"Content-Type", "Location", "Retry-After"}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+type entry struct {
+	requestHash [sha256.Size]byte
+	done        bool
+	status      int
+	header      http.Header
+	body        []byte
+	expiresAt   time.Time
+}
+
+// Store keeps the responses to requests made with an idempotency key, it is safe for concurrent use.
+type Store struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]*entry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		ttl:       ttl,
+		entries:   map[string]*entry{},
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// begin claims key for a request, unless it is already taken in which case the existing entry is returned.
+func (s *Store) begin(key string, requestHash [sha256.Size]byte) (*entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.evictExpired(now)
+
+	if existing, ok := s.entries[key]; ok && now.Before(existing.expiresAt) {
+		// copied so the caller can read it without the lock
+		found := *existing
+		return &found, false
+	}
+
+	s.entries[key] = &entry{requestHash: requestHash, expiresAt: now.Add(s.ttl)}
+	return nil, true
+}
+
+// finish keeps the response to the request that claimed key.
+func (s *Store) finish(key string, status int, header http.Header, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	e.done = true
+	e.status = status
+	e.header = header
+	e.body = body
+}
+
+// release frees key so it can be used again, for requests whose response is not kept.
+func (s *Store) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+}
+
+// evictExpired drops entries past their TTL, at most once per TTL.  The caller holds the lock.
+func (s *Store) evictExpired(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.lastSweep = now
+
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// Middleware makes every request passing through it that carries an Idempotency-Key safe to repeat.
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			writeError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters.")
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "The request body could not be read.")
+			return
+		}
+		if len(body) > maxBodySize {
+			writeError(w, http.StatusRequestEntityTooLarge, "The request body must be at most 1MB.")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+		hash.Write(body)
+		var requestHash [sha256.Size]byte
+		copy(requestHash[:], hash.Sum(nil))
+
+		existing, claimed := s.begin(key, requestHash)
+		switch {
+		case !claimed && existing.requestHash != requestHash:
+			writeError(w, http.StatusUnprocessableEntity, "Idempotency-Key has already been used for a different request.")
+			return
+		case !claimed && !existing.done:
+			writeError(w, http.StatusConflict, "A request with this Idempotency-Key is still being processed. Please try again later.")
+			return
+		case !claimed:
+			for name, values := range existing.header {
+				w.Header()[name] = values
+			}
+			w.Header().Set(ReplayedHeader, "true")
+			w.WriteHeader(existing.status)
+			if _, err := w.Write(existing.body); err != nil {
+				log.Printf("Failed to replay response: %v", err)
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		kept := false
+		// a handler that panicked or wrote nothing leaves no response to replay
+		defer func() {
+			if !kept {
+				s.release(key)
+			}
+		}()
+		next.ServeHTTP(recorder, r)
+
+		if !recorder.wroteHeader || retryable[recorder.status] {
+			return
+		}
+		kept = true
+		header := http.Header{}
+		for _, name := range replayedHeaders {
+			if values := w.Header().Values(name); len(values) > 0 {
+				header[name] = values
+			}
+		}
+		s.finish(key, recorder.status, header, recorder.body.Bytes())
+	})
+}
+
+// responseRecorder passes a response through while keeping a copy of its status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	// handlers sometimes set a second status after a failed encode, only the first reaches the client
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(errorResponse{Message: message}); err != nil {
+		log.Printf("Failed to encode error response: %v", err)
+	}
+}
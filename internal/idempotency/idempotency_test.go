@@ -0,0 +1,117 @@
+package idempotency_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/idempotency"
+	"github.com/stretchr/testify/assert"
+)
+
+func send(handler http.Handler, key, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotency.Header, key)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_Replays(t *testing.T) {
+	var charges atomic.Int32
+	store := idempotency.NewStore(idempotency.DefaultTTL)
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := charges.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/api/payments/"+strconv.Itoa(int(n)))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": "` + strconv.Itoa(int(n)) + `"}`))
+	}))
+
+	first := send(handler, "key-1", "/api/payments", `{"amount": 100}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(idempotency.ReplayedHeader))
+
+	// the repeat gets the first response and the card is charged once
+	repeat := send(handler, "key-1", "/api/payments", `{"amount": 100}`)
+	assert.Equal(t, http.StatusCreated, repeat.Code)
+	assert.Equal(t, first.Body.String(), repeat.Body.String())
+	assert.Equal(t, "/api/payments/1", repeat.Header().Get("Location"))
+	assert.Equal(t, "application/json", repeat.Header().Get("Content-Type"))
+	assert.Equal(t, "true", repeat.Header().Get(idempotency.ReplayedHeader))
+	assert.Equal(t, int32(1), charges.Load())
+
+	// other keys and requests without one are handled as usual
+	assert.Equal(t, `{"id": "2"}`, send(handler, "key-2", "/api/payments", `{"amount": 100}`).Body.String())
+	assert.Equal(t, `{"id": "3"}`, send(handler, "", "/api/payments", `{"amount": 100}`).Body.String())
+	assert.Equal(t, `{"id": "4"}`, send(handler, "", "/api/payments", `{"amount": 100}`).Body.String())
+}
+
+func TestMiddleware_KeyReusedForDifferentRequest(t *testing.T) {
+	store := idempotency.NewStore(idempotency.DefaultTTL)
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	assert.Equal(t, http.StatusCreated, send(handler, "key-1", "/api/payments", `{"amount": 100}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, send(handler, "key-1", "/api/payments", `{"amount": 200}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, send(handler, "key-1", "/api/payment-links", `{"amount": 100}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send(handler, strings.Repeat("k", 256), "/api/payments", `{}`).Code)
+}
+
+func TestMiddleware_InFlight(t *testing.T) {
+	store := idempotency.NewStore(idempotency.DefaultTTL)
+	var handler http.Handler
+	handler = store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the client gives up and sends the payment again before the first attempt is answered
+		assert.Equal(t, http.StatusConflict, send(handler, "key-1", "/api/payments", `{"amount": 100}`).Code)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	assert.Equal(t, http.StatusCreated, send(handler, "key-1", "/api/payments", `{"amount": 100}`).Code)
+}
+
+func TestMiddleware_NotKept(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{name: "RateLimited", handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTooManyRequests) }},
+		{name: "NothingWritten", handler: func(w http.ResponseWriter, r *http.Request) {}},
+		{name: "BankUnavailable", handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) }},
+		{name: "TimedOut", handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusGatewayTimeout) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			store := idempotency.NewStore(idempotency.DefaultTTL)
+			handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				tt.handler(w, r)
+			}))
+
+			send(handler, "key-1", "/api/payments", `{"amount": 100}`)
+			send(handler, "key-1", "/api/payments", `{"amount": 100}`)
+			assert.Equal(t, 2, calls)
+		})
+	}
+}
+
+func TestMiddleware_BodyTooLarge(t *testing.T) {
+	var calls int
+	store := idempotency.NewStore(idempotency.DefaultTTL)
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send(handler, "key-1", "/api/payments", strings.Repeat("a", 1<<20+1)).Code)
+	assert.Equal(t, http.StatusCreated, send(handler, "key-1", "/api/payments", strings.Repeat("a", 1<<20)).Code)
+	assert.Equal(t, 1, calls)
+}
@@ -6,29 +6,67 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/handlers"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/mountebank"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"gotest.tools/assert"
 )
 
+const (
+	bankURL = "http://localhost:8080"
+
+	// mountebankURLEnv overrides the Mountebank admin API, the same setting as main's -mountebank-url flag
+	mountebankURLEnv     = "MOUNTEBANK_URL"
+	defaultMountebankURL = "http://localhost:2525"
+)
+
+// provisionErr is set when the bank simulator could not be provisioned, the tests skip rather than fail in that case.
+var provisionErr error
+
+func TestMain(m *testing.M) {
+	mountebankURL := os.Getenv(mountebankURLEnv)
+	if mountebankURL == "" {
+		mountebankURL = defaultMountebankURL
+	}
+
+	// make sure the bank simulator matches the checked in fixtures rather than whatever was loaded by hand
+	provisioner := mountebank.NewProvisioner(mountebankURL, 5*time.Second)
+	provisionErr = provisioner.ProvisionDir("../../imposters")
+
+	os.Exit(m.Run())
+}
+
+func requireBankSimulator(t *testing.T) {
+	t.Helper()
+
+	if provisionErr != nil {
+		t.Skipf("bank simulator unavailable, run docker compose up or set %s: %v", mountebankURLEnv, provisionErr)
+	}
+}
+
 func TestPostGetPaymentHandler_Integration(t *testing.T) {
+	requireBankSimulator(t)
+
 	ctx := context.Background()
-	api := api.New()
+	gateway := api.New(client.NewClient(bankURL, 5*time.Second))
 
 	go func() {
-		api.Run(ctx, ":8090")
+		gateway.Run(ctx, ":8090", api.DefaultServerConfig)
 	}()
 
 	postPayment := &models.PostPaymentHandlerRequest{
 		CardNumber:  2222405343248877,
 		ExpiryMonth: 4,
-		ExpiryYear:  2025,
+		ExpiryYear:  2035,
 		Currency:    "GBP",
 		Amount:      100,
 		Cvv:         123,
@@ -76,23 +114,25 @@ func TestPostGetPaymentHandler_Integration(t *testing.T) {
 	assert.Equal(t, "authorized", response.PaymentStatus)
 	assert.Equal(t, 8877, response.CardNumberLastFour)
 	assert.Equal(t, 4, response.ExpiryMonth)
-	assert.Equal(t, 2025, response.ExpiryYear)
+	assert.Equal(t, 2035, response.ExpiryYear)
 	assert.Equal(t, "GBP", response.Currency)
 	assert.Equal(t, 100, response.Amount)
 }
 
 func TestPostPaymentHandler_IntegrationCardNumberValidationError(t *testing.T) {
+	requireBankSimulator(t)
+
 	ctx := context.Background()
-	api := api.New()
+	gateway := api.New(client.NewClient(bankURL, 5*time.Second))
 
 	go func() {
-		api.Run(ctx, ":8090")
+		gateway.Run(ctx, ":8090", api.DefaultServerConfig)
 	}()
 
 	postPayment := &models.PostPaymentHandlerRequest{
 		CardNumber:  1,
 		ExpiryMonth: 4,
-		ExpiryYear:  2025,
+		ExpiryYear:  2035,
 		Currency:    "GBP",
 		Amount:      100,
 		Cvv:         123,
@@ -118,17 +158,19 @@ func TestPostPaymentHandler_IntegrationCardNumberValidationError(t *testing.T) {
 }
 
 func TestPostPaymentHandler_IntegrationBankError(t *testing.T) {
+	requireBankSimulator(t)
+
 	ctx := context.Background()
-	api := api.New()
+	gateway := api.New(client.NewClient(bankURL, 5*time.Second))
 
 	go func() {
-		api.Run(ctx, ":8090")
+		gateway.Run(ctx, ":8090", api.DefaultServerConfig)
 	}()
 
 	postPayment := &models.PostPaymentHandlerRequest{
 		CardNumber:  2222405343248870,
 		ExpiryMonth: 4,
-		ExpiryYear:  2025,
+		ExpiryYear:  2035,
 		Currency:    "GBP",
 		Amount:      100,
 		Cvv:         123,
@@ -158,3 +200,97 @@ func getLastFourCharacters(t *testing.T, i int) string {
 	require.Equal(t, 16, len(s))
 	return s[len(s)-4:]
 }
+
+func TestPostCapturePaymentHandler_Integration(t *testing.T) {
+	requireBankSimulator(t)
+
+	ctx := context.Background()
+	gateway := api.New(client.NewClient(bankURL, 5*time.Second))
+
+	go func() {
+		gateway.Run(ctx, ":8090", api.DefaultServerConfig)
+	}()
+
+	postPayment := &models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+	}
+
+	body, err := json.Marshal(postPayment)
+	require.NoError(t, err)
+
+	resp, err := http.Post("http://localhost:8090/api/payments", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response models.PostPaymentResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	require.NoError(t, err)
+
+	respCapture, err := http.Post(fmt.Sprintf("http://localhost:8090/api/payments/%s/capture", response.Id), "application/json", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, respCapture.StatusCode)
+
+	var captureResponse models.PostPaymentResponse
+	err = json.NewDecoder(respCapture.Body).Decode(&captureResponse)
+	require.NoError(t, err)
+	assert.Equal(t, response.Id, captureResponse.Id)
+	assert.Equal(t, "captured", captureResponse.PaymentStatus)
+
+	respAgain, err := http.Post(fmt.Sprintf("http://localhost:8090/api/payments/%s/capture", response.Id), "application/json", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, respAgain.StatusCode)
+}
+
+func TestPostRefundPaymentHandler_Integration(t *testing.T) {
+	requireBankSimulator(t)
+
+	ctx := context.Background()
+	gateway := api.New(client.NewClient(bankURL, 5*time.Second))
+
+	go func() {
+		gateway.Run(ctx, ":8090", api.DefaultServerConfig)
+	}()
+
+	postPayment := &models.PostPaymentHandlerRequest{
+		CardNumber:  2222405343248877,
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Currency:    "GBP",
+		Amount:      100,
+		Cvv:         123,
+	}
+
+	body, err := json.Marshal(postPayment)
+	require.NoError(t, err)
+
+	resp, err := http.Post("http://localhost:8090/api/payments", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response models.PostPaymentResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	require.NoError(t, err)
+
+	respCapture, err := http.Post(fmt.Sprintf("http://localhost:8090/api/payments/%s/capture", response.Id), "application/json", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, respCapture.StatusCode)
+
+	respRefund, err := http.Post(fmt.Sprintf("http://localhost:8090/api/payments/%s/refund", response.Id), "application/json", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, respRefund.StatusCode)
+
+	respGet, err := http.Get(fmt.Sprintf("http://localhost:8090/api/payments/%s", response.Id))
+	require.NoError(t, err)
+
+	var getHandlerResponse models.GetPaymentHandlerResponse
+	err = json.NewDecoder(respGet.Body).Decode(&getHandlerResponse)
+	require.NoError(t, err)
+	assert.Equal(t, "refunded", getHandlerResponse.Status)
+	require.Len(t, getHandlerResponse.Refunds, 1)
+	assert.Equal(t, 100, getHandlerResponse.Refunds[0].Amount)
+}
@@ -0,0 +1,78 @@
+package listen
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+/*
+Listen opens the listener the gateway serves on.  The address is one of:
+
+  - host:port, or :port, for TCP
+  - unix:/path/to/socket for a Unix domain socket, for a reverse proxy on the same host
+  - systemd to take over the first socket passed in by systemd socket activation
+
+With socket activation systemd owns the socket, so the gateway can be restarted without dropping connections queued on it.
+*/
+
+const (
+	unixPrefix = "unix:"
+	systemd    = "systemd"
+
+	// listenFdsStart is the first file descriptor systemd passes, after stdin, stdout and stderr
+	listenFdsStart = 3
+)
+
+func Listen(address string) (net.Listener, error) {
+	switch {
+	case address == systemd:
+		return systemdListener(os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"))
+	case strings.HasPrefix(address, unixPrefix):
+		return unixListener(strings.TrimPrefix(address, unixPrefix))
+	default:
+		return net.Listen("tcp", address)
+	}
+}
+
+func unixListener(path string) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("unix socket path is empty")
+	}
+
+	// a socket file left behind by a previous run that did not shut down cleanly would make the bind fail
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+
+	return net.Listen("unix", path)
+}
+
+func systemdListener(pid, fds string) (net.Listener, error) {
+	if pid != strconv.Itoa(os.Getpid()) {
+		return nil, errors.New("no sockets passed by systemd for this process, is the service started through a .socket unit?")
+	}
+
+	count, err := strconv.Atoi(fds)
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", fds)
+	}
+
+	syscall.CloseOnExec(listenFdsStart)
+	file := os.NewFile(uintptr(listenFdsStart), "systemd socket")
+	defer file.Close()
+
+	// FileListener dups the descriptor, closing file leaves the listener open
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use systemd socket: %w", err)
+	}
+
+	return listener, nil
+}
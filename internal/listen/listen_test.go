@@ -0,0 +1,65 @@
+package listen
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen_TCP(t *testing.T) {
+	listener, err := Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	assert.Equal(t, "tcp", listener.Addr().Network())
+}
+
+func TestListen_Unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.sock")
+
+	listener, err := Listen("unix:" + path)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestListen_UnixReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.sock")
+
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	// leave the socket file behind the way a crashed process would
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := Listen("unix:" + path)
+	require.NoError(t, err)
+	listener.Close()
+}
+
+func TestListen_UnixKeepsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.sock")
+	require.NoError(t, os.WriteFile(path, []byte("not a socket"), 0o600))
+
+	_, err := Listen("unix:" + path)
+	require.Error(t, err)
+
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+}
+
+func TestSystemdListener_RequiresActivation(t *testing.T) {
+	_, err := systemdListener("", "")
+	assert.Error(t, err)
+
+	_, err = systemdListener(strconv.Itoa(os.Getpid()), "0")
+	assert.Error(t, err)
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 /*
 
 If I had more time I would completely split out the models used in the handlers from the models used throughout the program.  Because I dont like the presentation tier being tied to implementation, for example in the PostPayment handler I am just reusing PostPaymentResponse for the happy path and possible a new validation error.
@@ -7,22 +9,102 @@ If I had more time I would completely split out the models used in the handlers
 */
 
 type PostPaymentHandlerRequest struct {
-	CardNumber  int    `json:"card_number"`
-	ExpiryMonth int    `json:"expiry_month"`
-	ExpiryYear  int    `json:"expiry_year"`
-	Currency    string `json:"currency"`
-	Amount      int    `json:"amount"`
-	Cvv         int    `json:"cvv"`
+	CardNumber       int               `json:"card_number" pii:"pan"`
+	ExpiryMonth      int               `json:"expiry_month"`
+	ExpiryYear       int               `json:"expiry_year"`
+	Currency         string            `json:"currency"`
+	Amount           int               `json:"amount"`
+	Cvv              int               `json:"cvv" pii:"secret"`
+	StoredCredential *StoredCredential `json:"stored_credential,omitempty"`
+	BillingAddress   *BillingAddress   `json:"billing_address,omitempty"`
+	// CardToken charges a card stored with POST /api/customers/{id}/cards instead of the card details above.
+	CardToken string `json:"card_token,omitempty"`
+	// Capture takes the funds straight away, otherwise the payment is only authorized and must be captured explicitly.
+	Capture bool `json:"capture"`
+	// ExecuteAt schedules the payment to be sent to the bank at a later time rather than straight away.
+	ExecuteAt *time.Time `json:"execute_at,omitempty"`
+	// PaymentMethod says what the payment is made with, leaving it out pays with the card fields above.
+	PaymentMethod *PaymentMethod `json:"payment_method,omitempty"`
+}
+
+// PaymentMethod says what a payment is made with.  Type picks which of the other fields applies.
+type PaymentMethod struct {
+	// Type is "card", "network_token" or "wallet".
+	Type         string        `json:"type"`
+	Card         *CardDetails  `json:"card,omitempty"`
+	NetworkToken *NetworkToken `json:"network_token,omitempty"`
+	Wallet       *Wallet       `json:"wallet,omitempty"`
+}
+
+// CardDetails are a payment's card details sent in its payment_method, in place of the request's own card fields.
+type CardDetails struct {
+	Number      int `json:"number" pii:"pan"`
+	ExpiryMonth int `json:"expiry_month"`
+	ExpiryYear  int `json:"expiry_year"`
+	Cvv         int `json:"cvv" pii:"secret"`
+}
+
+// Wallet is the encrypted payment token an Apple Pay or Google Pay sheet gave the merchant.
+type Wallet struct {
+	// Type is "apple_pay" or "google_pay".
+	Type    string `json:"type"`
+	Payload string `json:"payload" pii:"secret"`
+}
+
+// NetworkToken is a scheme token standing in for the card number, with the single use cryptogram that authenticates
+// it.  The request's expiry_month and expiry_year are the token's.
+type NetworkToken struct {
+	Token      string `json:"token" pii:"pan"`
+	Cryptogram string `json:"cryptogram" pii:"secret"`
+	// ECI is the electronic commerce indicator the token service returned with the cryptogram.
+	ECI string `json:"eci,omitempty"`
+}
+
+// StoredCredential marks a payment made with card details kept on file, schemes require it to tell customer-initiated
+// payments from ones the merchant starts on its own (a subscription renewal, the next installment).
+type StoredCredential struct {
+	// Initiator is "customer" or "merchant".
+	Initiator string `json:"initiator"`
+	// Reason is "recurring", "installment" or "unscheduled", required when the merchant initiates.
+	Reason string `json:"reason,omitempty"`
+	// OriginalTransactionId references the customer-initiated transaction that set up the agreement, required when the merchant initiates.
+	OriginalTransactionId string `json:"original_transaction_id,omitempty"`
+}
+
+// PaymentFilter narrows a list of payments, fields left at their zero value match every payment.  The amount bounds
+// and created times are inclusive.
+type PaymentFilter struct {
+	Status        string
+	Currency      string
+	LastFour      *int
+	MinAmount     *int
+	MaxAmount     *int
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
 }
 
 type GetPaymentHandlerResponse struct {
-	Id                 string `json:"id"`
-	Status             string `json:"status"`
-	LastFourCardDigits int    `json:"last_four_card_digits"`
-	ExpiryMonth        int    `json:"expiry_month"`
-	ExpiryYear         int    `json:"expiry_year"`
-	Currency           string `json:"currency"`
-	Amount             int    `json:"amount"`
+	Id                 string            `json:"id"`
+	Status             string            `json:"status"`
+	LastFourCardDigits int               `json:"last_four_card_digits"`
+	ExpiryMonth        int               `json:"expiry_month"`
+	ExpiryYear         int               `json:"expiry_year"`
+	Currency           string            `json:"currency"`
+	Amount             int               `json:"amount"`
+	ExpiresAt          *time.Time        `json:"expires_at,omitempty"`
+	StoredCredential   *StoredCredential `json:"stored_credential,omitempty"`
+	Captures           []Capture         `json:"captures,omitempty"`
+	Refunds            []Refund          `json:"refunds,omitempty"`
+	CaptureMethod      string            `json:"capture_method,omitempty"`
+	CapturedAt         *time.Time        `json:"captured_at,omitempty"`
+	CardToken          string            `json:"card_token,omitempty"`
+	AVSResult          string            `json:"avs_result,omitempty"`
+	RetryOf            string            `json:"retry_of,omitempty"`
+	Retries            []string          `json:"retries,omitempty"`
+	DeclineReason      *DeclineReason    `json:"decline_reason,omitempty"`
+	FailureReason      string            `json:"failure_reason,omitempty"`
+	ExecuteAt          *time.Time        `json:"execute_at,omitempty"`
+	PaymentMethod      string            `json:"payment_method,omitempty"`
 }
 
 type PostPaymentRequest struct {
@@ -31,7 +113,7 @@ type PostPaymentRequest struct {
 	ExpiryYear         int    `json:"expiry_year"`
 	Currency           string `json:"currency"`
 	Amount             int    `json:"amount"`
-	Cvv                int    `json:"cvv"`
+	Cvv                int    `json:"cvv" pii:"secret"`
 }
 
 type PostPaymentResponse struct {
@@ -42,6 +124,65 @@ type PostPaymentResponse struct {
 	ExpiryYear         int    `json:"expiry_year"`
 	Currency           string `json:"currency"`
 	Amount             int    `json:"amount"`
+	// ExpiresAt is when the issuer may release the funds held by an authorization, it is only set on authorized payments
+	ExpiresAt         *time.Time        `json:"expires_at,omitempty"`
+	StoredCredential  *StoredCredential `json:"stored_credential,omitempty"`
+	AuthorizationCode string            `json:"authorization_code,omitempty"`
+	Captures          []Capture         `json:"captures,omitempty"`
+	Refunds           []Refund          `json:"refunds,omitempty"`
+	CardToken         string            `json:"card_token,omitempty"`
+	AVSResult         string            `json:"avs_result,omitempty"`
+	// RetryOf names the declined payment this one retried, Retries the payments that retried this one
+	RetryOf string   `json:"retry_of,omitempty"`
+	Retries []string `json:"retries,omitempty"`
+	// CaptureMethod is "immediate" when the payment was captured as it was authorized, or "delayed" when it waits for an explicit capture
+	CaptureMethod string `json:"capture_method,omitempty"`
+	// CapturedAt is when money was first captured, it decides whether the payment can still be reversed
+	CapturedAt    *time.Time     `json:"captured_at,omitempty"`
+	DeclineReason *DeclineReason `json:"decline_reason,omitempty"`
+	// FailureReason says why an async payment could not be sent to the bank, it is only set on failed payments
+	FailureReason string `json:"failure_reason,omitempty"`
+	// ExecuteAt is when a scheduled payment is sent to the bank
+	ExecuteAt *time.Time `json:"execute_at,omitempty"`
+	// PaymentMethod is the payment method type, or the wallet's, when it was not a card.  CardNumberLastFour is then the token's
+	PaymentMethod string `json:"payment_method,omitempty"`
+}
+
+// DeclineReason says why a payment was declined.  Code is the issuer's response code, or avs_mismatch when the gateway
+// declined an authorization because of its address verification result.
+type DeclineReason struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// RefundRequest is the optional body of a refund, leaving out the amount refunds everything not yet refunded.
+type RefundRequest struct {
+	Amount int `json:"amount"`
+}
+
+// Capture is one capture of an authorized payment, a payment shipped in parts is captured once per shipment.
+type Capture struct {
+	Id        string    `json:"id"`
+	Amount    int       `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RetryRequest is the body of a retry, the CVV is needed again for a payment made with card details as it is not kept
+// after a decline.  A payment made with a stored card can be retried without a body.
+type RetryRequest struct {
+	Cvv int `json:"cvv" pii:"secret"`
+}
+
+// CaptureRequest is the optional body of a capture, an Amount of 0 captures whatever is left of the authorization.
+type CaptureRequest struct {
+	Amount int `json:"amount"`
+}
+
+// Refund records money returned to the card on a captured payment.
+type Refund struct {
+	Id        string    `json:"id"`
+	Amount    int       `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type GetPaymentResponse struct {
@@ -55,19 +196,269 @@ type GetPaymentResponse struct {
 }
 
 type PostPaymentBankRequest struct {
-	CardNumber string `json:"card_number"`
-	ExpiryDate string `json:"expiry_date"`
-	Currency   string `json:"currency"`
-	Amount     int    `json:"amount"`
-	CVV        string `json:"cvv"`
+	CardNumber       string            `json:"card_number" pii:"pan"`
+	ExpiryDate       string            `json:"expiry_date"`
+	Currency         string            `json:"currency"`
+	Amount           int               `json:"amount"`
+	CVV              string            `json:"cvv,omitempty" pii:"secret"`
+	StoredCredential *StoredCredential `json:"stored_credential,omitempty"`
+	Capture          bool              `json:"capture,omitempty"`
+	BillingAddress   *BillingAddress   `json:"billing_address,omitempty"`
+}
+
+// PostTokenBankRequest authorizes a payment with a network token rather than a card number.
+type PostTokenBankRequest struct {
+	Token            string            `json:"token" pii:"pan"`
+	ExpiryDate       string            `json:"expiry_date"`
+	Cryptogram       string            `json:"cryptogram" pii:"secret"`
+	ECI              string            `json:"eci,omitempty"`
+	Currency         string            `json:"currency"`
+	Amount           int               `json:"amount"`
+	StoredCredential *StoredCredential `json:"stored_credential,omitempty"`
+	Capture          bool              `json:"capture,omitempty"`
+	BillingAddress   *BillingAddress   `json:"billing_address,omitempty"`
 }
 
 type PostPaymentBankResponse struct {
 	Authorised        bool   `json:"authorized"`
 	AuthorizationCode string `json:"authorization_code"`
+	// AVSResult is the issuer's address verification outcome, only set when a billing address was sent
+	AVSResult string `json:"avs_result,omitempty"`
+	// DeclineCode and DeclineMessage say why the issuer declined, they are only set when authorized is false
+	DeclineCode    string `json:"decline_code,omitempty"`
+	DeclineMessage string `json:"decline_message,omitempty"`
+}
+
+type CaptureBankRequest struct {
+	AuthorizationCode string `json:"authorization_code"`
+	Amount            int    `json:"amount"`
+}
+
+type CaptureBankResponse struct {
+	Captured bool `json:"captured"`
+}
+
+type RefundBankRequest struct {
+	AuthorizationCode string `json:"authorization_code"`
+	Amount            int    `json:"amount"`
+}
+
+type RefundBankResponse struct {
+	Refunded bool `json:"refunded"`
+}
+
+type ReverseBankRequest struct {
+	AuthorizationCode string `json:"authorization_code"`
+	Amount            int    `json:"amount"`
+}
+
+type ReverseBankResponse struct {
+	Reversed bool `json:"reversed"`
+}
+
+type VoidBankRequest struct {
+	AuthorizationCode string `json:"authorization_code"`
+}
+
+type VoidBankResponse struct {
+	Voided bool `json:"voided"`
 }
 
 type PostPayment400Response struct {
 	Id            string `json:"id"`
 	PaymentStatus string `json:"payment_status"`
 }
+
+// StoreCardRequest is the card a customer wants kept on file.  The CVV is not accepted, it must never be stored.
+type StoreCardRequest struct {
+	CardNumber  int `json:"card_number" pii:"pan"`
+	ExpiryMonth int `json:"expiry_month"`
+	ExpiryYear  int `json:"expiry_year"`
+}
+
+// StoredCard is a card kept on file for a customer, payments refer to it by Token.
+type StoredCard struct {
+	Token              string    `json:"token"`
+	CustomerId         string    `json:"customer_id"`
+	CardNumberLastFour int       `json:"card_number_last_four"`
+	ExpiryMonth        int       `json:"expiry_month"`
+	ExpiryYear         int       `json:"expiry_year"`
+	CreatedAt          time.Time `json:"created_at"`
+	// CardNumber is only ever sent to the acquiring bank, never returned.
+	CardNumber int `json:"-" pii:"pan"`
+}
+
+// PaymentIntent tracks a payment the merchant means to take before the card details are known.
+type PaymentIntent struct {
+	Id        string    `json:"id"`
+	Status    string    `json:"status"`
+	Amount    int       `json:"amount"`
+	Currency  string    `json:"currency"`
+	Capture   bool      `json:"capture"`
+	PaymentId string    `json:"payment_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreatePaymentIntentRequest struct {
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+	Capture  bool   `json:"capture"`
+}
+
+// ConfirmPaymentIntentRequest attaches the card to a payment intent, either its details or a stored card token.
+type ConfirmPaymentIntentRequest struct {
+	CardNumber       int               `json:"card_number" pii:"pan"`
+	ExpiryMonth      int               `json:"expiry_month"`
+	ExpiryYear       int               `json:"expiry_year"`
+	Cvv              int               `json:"cvv" pii:"secret"`
+	CardToken        string            `json:"card_token,omitempty"`
+	StoredCredential *StoredCredential `json:"stored_credential,omitempty"`
+}
+
+// PaymentLink is a shareable link a customer opens to pay a fixed amount, it makes one payment.
+type PaymentLink struct {
+	Id          string    `json:"id"`
+	Status      string    `json:"status"`
+	Amount      int       `json:"amount"`
+	Currency    string    `json:"currency"`
+	Description string    `json:"description,omitempty"`
+	Capture     bool      `json:"capture"`
+	PaymentId   string    `json:"payment_id,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreatePaymentLinkRequest describes a payment link, leaving out ExpiresAt gives the link the default validity.
+type CreatePaymentLinkRequest struct {
+	Amount      int        `json:"amount"`
+	Currency    string     `json:"currency"`
+	Description string     `json:"description,omitempty"`
+	Capture     bool       `json:"capture"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// PayPaymentLinkRequest is the card the customer pays a payment link with.
+type PayPaymentLinkRequest struct {
+	CardNumber     int             `json:"card_number" pii:"pan"`
+	ExpiryMonth    int             `json:"expiry_month"`
+	ExpiryYear     int             `json:"expiry_year"`
+	Cvv            int             `json:"cvv" pii:"secret"`
+	BillingAddress *BillingAddress `json:"billing_address,omitempty"`
+}
+
+// InstallmentPlan splits a payment into equal installments taken one interval apart.
+type InstallmentPlan struct {
+	Id           string        `json:"id"`
+	Status       string        `json:"status"`
+	Currency     string        `json:"currency"`
+	Amount       int           `json:"amount"`
+	Interval     string        `json:"interval"`
+	Installments []Installment `json:"installments"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// Installment is one payment of an installment plan, Status is its payment's status.
+type Installment struct {
+	PaymentId string     `json:"payment_id"`
+	Amount    int        `json:"amount"`
+	Status    string     `json:"status"`
+	ExecuteAt *time.Time `json:"execute_at,omitempty"`
+}
+
+// CreateInstallmentPlanRequest asks for Amount to be taken from the card in Installments payments, the first straight away.
+type CreateInstallmentPlanRequest struct {
+	CardNumber     int             `json:"card_number" pii:"pan"`
+	ExpiryMonth    int             `json:"expiry_month"`
+	ExpiryYear     int             `json:"expiry_year"`
+	Cvv            int             `json:"cvv" pii:"secret"`
+	BillingAddress *BillingAddress `json:"billing_address,omitempty"`
+	Currency       string          `json:"currency"`
+	Amount         int             `json:"amount"`
+	Installments   int             `json:"installments"`
+	// Interval is "weekly" or "monthly".
+	Interval string `json:"interval"`
+}
+
+// BillingAddress is the cardholder's address as the issuer knows it, checked by address verification (AVS).
+type BillingAddress struct {
+	Line1      string `json:"line1" pii:"personal"`
+	Line2      string `json:"line2,omitempty" pii:"personal"`
+	City       string `json:"city,omitempty" pii:"personal"`
+	PostalCode string `json:"postal_code" pii:"personal"`
+	Country    string `json:"country"`
+}
+
+// CardVerificationRequest is a card to check with the issuer without charging it.  Currency defaults to USD.
+type CardVerificationRequest struct {
+	CardNumber     int             `json:"card_number" pii:"pan"`
+	ExpiryMonth    int             `json:"expiry_month"`
+	ExpiryYear     int             `json:"expiry_year"`
+	Cvv            int             `json:"cvv" pii:"secret"`
+	Currency       string          `json:"currency,omitempty"`
+	BillingAddress *BillingAddress `json:"billing_address,omitempty"`
+}
+
+// CardVerification is the outcome of a zero amount authorization, PaymentId names the payment recorded for it.
+type CardVerification struct {
+	PaymentId          string `json:"payment_id"`
+	Valid              bool   `json:"valid"`
+	Scheme             string `json:"scheme"`
+	CardNumberLastFour int    `json:"card_number_last_four"`
+	ExpiryMonth        int    `json:"expiry_month"`
+	ExpiryYear         int    `json:"expiry_year"`
+	AVSResult          string `json:"avs_result,omitempty"`
+}
+
+// Dispute is a chargeback the issuer raised against a captured payment.  It goes open -> evidence_submitted -> won or lost.
+type Dispute struct {
+	Id        string    `json:"id"`
+	PaymentId string    `json:"payment_id"`
+	Status    string    `json:"status"`
+	Amount    int       `json:"amount"`
+	Reason    string    `json:"reason"`
+	Evidence  string    `json:"evidence,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EvidenceBundle is what the gateway knows about a disputed payment, for the merchant to answer the dispute with.
+type EvidenceBundle struct {
+	DisputeId          string            `json:"dispute_id"`
+	Reason             string            `json:"reason"`
+	Amount             int               `json:"amount"`
+	PaymentId          string            `json:"payment_id"`
+	Currency           string            `json:"currency"`
+	CardNumberLastFour int               `json:"card_number_last_four"`
+	AuthorizationCode  string            `json:"authorization_code,omitempty"`
+	AVSResult          string            `json:"avs_result,omitempty"`
+	StoredCredential   *StoredCredential `json:"stored_credential,omitempty"`
+	Captures           []Capture         `json:"captures,omitempty"`
+	Refunds            []Refund          `json:"refunds,omitempty"`
+	// PriorPayments are earlier settled payments on the same card that were not disputed
+	PriorPayments []PriorPayment `json:"prior_payments"`
+	GeneratedAt   time.Time      `json:"generated_at"`
+}
+
+type PriorPayment struct {
+	PaymentId  string     `json:"payment_id"`
+	Status     string     `json:"status"`
+	Amount     int        `json:"amount"`
+	Currency   string     `json:"currency"`
+	CapturedAt *time.Time `json:"captured_at,omitempty"`
+}
+
+// OpenDisputeRequest is the chargeback notification the issuer sends, an Amount of 0 disputes everything captured.
+type OpenDisputeRequest struct {
+	PaymentId string `json:"payment_id"`
+	Amount    int    `json:"amount"`
+	Reason    string `json:"reason"`
+}
+
+type DisputeEvidenceRequest struct {
+	Evidence string `json:"evidence"`
+}
+
+// ResolveDisputeRequest is the issuer's decision, Outcome is won or lost from the merchant's side.
+type ResolveDisputeRequest struct {
+	Outcome string `json:"outcome"`
+}
@@ -0,0 +1,119 @@
+package mountebank
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*
+The provisioner pushes imposter definitions to the Mountebank admin API so that nobody has to start the container with the right --configfile or curl the imposters in by hand before running the gateway or the integration tests.
+
+Fixture files use the same shape as imposters/bank_simulator.ejs, an object with an "imposters" array.  Each imposter is deleted by port before being created again, so provisioning is safe to run on every startup.
+*/
+
+type Provisioner struct {
+	httpClient *http.Client
+	adminURL   string
+}
+
+type fixture struct {
+	Imposters []json.RawMessage `json:"imposters"`
+}
+
+type imposterPort struct {
+	Port int `json:"port"`
+}
+
+func NewProvisioner(adminURL string, timeout time.Duration) *Provisioner {
+	return &Provisioner{
+		httpClient: &http.Client{Timeout: timeout},
+		adminURL:   adminURL,
+	}
+}
+
+// ProvisionDir provisions every .json and .ejs fixture file found in dir.
+func (p *Provisioner) ProvisionDir(dir string) error {
+	var files []string
+	for _, pattern := range []string{"*.json", "*.ejs"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return fmt.Errorf("failed to list fixtures: %w", err)
+		}
+		files = append(files, matches...)
+	}
+
+	for _, file := range files {
+		if err := p.ProvisionFile(file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ProvisionFile creates or replaces every imposter defined in a fixture file.
+func (p *Provisioner) ProvisionFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read fixture %s: %w", path, err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("failed to decode fixture %s: %w", path, err)
+	}
+
+	for _, imposter := range f.Imposters {
+		if err := p.Provision(imposter); err != nil {
+			return fmt.Errorf("failed to provision imposter from %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// Provision replaces the imposter listening on the same port as the given definition.
+func (p *Provisioner) Provision(imposter json.RawMessage) error {
+	var port imposterPort
+	if err := json.Unmarshal(imposter, &port); err != nil {
+		return fmt.Errorf("failed to decode imposter: %w", err)
+	}
+	if port.Port == 0 {
+		return fmt.Errorf("imposter has no port")
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/imposters/%d", p.adminURL, port.Port), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create DELETE request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make DELETE request: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received non-200 response deleting imposter: %d", resp.StatusCode)
+	}
+
+	resp, err = p.httpClient.Post(fmt.Sprintf("%s/imposters", p.adminURL), "application/json", bytes.NewReader(imposter))
+	if err != nil {
+		return fmt.Errorf("failed to make POST request: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("received non-201 response creating imposter: %d", resp.StatusCode)
+	}
+
+	log.Printf("Provisioned imposter on port %d", port.Port)
+
+	return nil
+}
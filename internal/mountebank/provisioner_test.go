@@ -0,0 +1,83 @@
+package mountebank_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/mountebank"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvisioner_ProvisionFile(t *testing.T) {
+	var requests []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	provisioner := mountebank.NewProvisioner(testServer.URL, 5*time.Second)
+
+	// the checked in bank simulator is the fixture we care most about
+	err := provisioner.ProvisionFile("../../imposters/bank_simulator.ejs")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"DELETE /imposters/8080", "POST /imposters"}, requests)
+}
+
+func TestProvisioner_ProvisionDir(t *testing.T) {
+	var posts int
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	dir := t.TempDir()
+	fixture := `{"imposters": [{"port": 9001, "protocol": "http"}, {"port": 9002, "protocol": "http"}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fixture.json"), []byte(fixture), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0o600))
+
+	provisioner := mountebank.NewProvisioner(testServer.URL, 5*time.Second)
+
+	err := provisioner.ProvisionDir(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, posts)
+}
+
+func TestProvisioner_ProvisionRejected(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	provisioner := mountebank.NewProvisioner(testServer.URL, 5*time.Second)
+
+	err := provisioner.Provision([]byte(`{"port": 9001}`))
+	require.Error(t, err)
+}
+
+func TestProvisioner_ProvisionMissingPort(t *testing.T) {
+	provisioner := mountebank.NewProvisioner("http://localhost:0", 5*time.Second)
+
+	err := provisioner.Provision([]byte(`{"protocol": "http"}`))
+	require.Error(t, err)
+}
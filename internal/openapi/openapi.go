@@ -0,0 +1,221 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+The OpenAPI 3 document is built from a list of operations and the Go types their bodies are decoded into and encoded from, so a field added to a model appears in the document without anyone having to remember to edit it.  Each type becomes a schema under components, named after the type, with a property per JSON field.  Fields tagged json:"-" are left out, as they never travel.
+
+Operations are described where the routes are registered, the document only knows what it is given.
+*/
+
+const Version = "3.0.3"
+
+type Info struct {
+	Title       string
+	Description string
+	Version     string
+}
+
+// Parameter is a query parameter.
+type Parameter struct {
+	Name        string
+	Description string
+	// Type is a JSON schema type, string when empty
+	Type string
+	// Format refines Type, e.g. date-time
+	Format string
+}
+
+type Operation struct {
+	Method string
+	// Path is the route's pattern, path parameters such as {id} are documented from it
+	Path        string
+	Summary     string
+	Description string
+	Tag         string
+	Query       []Parameter
+	// Request is a value of the request body's type, nil when there is no body
+	Request any
+	// Status is the status of a successful response, 200 when zero
+	Status int
+	// Response is a value of the successful response body's type, nil when there is no body
+	Response any
+	// Errors are the error statuses the operation can answer with, they share one schema
+	Errors []int
+	// ErrorBodies gives the body of error statuses that do not answer with the shared schema
+	ErrorBodies map[int]any
+}
+
+var pathParameter = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Document builds the OpenAPI document describing operations.
+func Document(info Info, operations []Operation) map[string]any {
+	schemas := map[string]any{
+		"Error": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"message": map[string]any{"type": "string"},
+			},
+		},
+	}
+
+	paths := map[string]any{}
+	for _, op := range operations {
+		item, ok := paths[op.Path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = operation(op, schemas)
+	}
+
+	return map[string]any{
+		"openapi": Version,
+		"info": map[string]any{
+			"title":       info.Title,
+			"description": info.Description,
+			"version":     info.Version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+		},
+	}
+}
+
+func operation(op Operation, schemas map[string]any) map[string]any {
+	parameters := []any{}
+	for _, match := range pathParameter.FindAllStringSubmatch(op.Path, -1) {
+		parameters = append(parameters, map[string]any{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		})
+	}
+	for _, param := range op.Query {
+		schema := map[string]any{"type": "string"}
+		if param.Type != "" {
+			schema["type"] = param.Type
+		}
+		if param.Format != "" {
+			schema["format"] = param.Format
+		}
+		parameters = append(parameters, map[string]any{
+			"name":        param.Name,
+			"in":          "query",
+			"description": param.Description,
+			"schema":      schema,
+		})
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]any{"description": http.StatusText(status)}
+	if op.Response != nil {
+		success["content"] = jsonContent(schema(reflect.TypeOf(op.Response), schemas))
+	}
+	responses := map[string]any{strconv.Itoa(status): success}
+	for _, code := range op.Errors {
+		body := map[string]any{"$ref": "#/components/schemas/Error"}
+		if errorBody, ok := op.ErrorBodies[code]; ok {
+			body = schema(reflect.TypeOf(errorBody), schemas)
+		}
+		responses[strconv.Itoa(code)] = map[string]any{
+			"description": http.StatusText(code),
+			"content":     jsonContent(body),
+		}
+	}
+
+	result := map[string]any{
+		"summary":    op.Summary,
+		"parameters": parameters,
+		"responses":  responses,
+	}
+	if op.Description != "" {
+		result["description"] = op.Description
+	}
+	if op.Tag != "" {
+		result["tags"] = []string{op.Tag}
+	}
+	if op.Request != nil {
+		result["requestBody"] = map[string]any{
+			"required": true,
+			"content":  jsonContent(schema(reflect.TypeOf(op.Request), schemas)),
+		}
+	}
+	return result
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{
+		"application/json": map[string]any{"schema": schema},
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the schema for t, adding the schemas of any named structs it uses to schemas.
+func schema(t reflect.Type, schemas map[string]any) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schema(t.Elem(), schemas)
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schema(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schema(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return object(t, schemas)
+		}
+		if _, ok := schemas[t.Name()]; !ok {
+			// registered before its fields so a type that refers to itself does not recurse forever
+			schemas[t.Name()] = map[string]any{}
+			schemas[t.Name()] = object(t, schemas)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]any{}
+	}
+}
+
+func object(t reflect.Type, schemas map[string]any) map[string]any {
+	properties := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schema(field.Type, schemas)
+	}
+	return map[string]any{"type": "object", "properties": properties}
+}
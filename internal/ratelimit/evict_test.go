@@ -0,0 +1,29 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_EvictsIdleBuckets(t *testing.T) {
+	now := time.Now()
+	limiter := NewLimiter(DefaultBudgets)
+	limiter.now = func() time.Time { return now }
+
+	for _, client := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		limiter.Allow(Read, client)
+	}
+	assert.Len(t, limiter.buckets, 3)
+
+	// one client keeps going, the others go quiet
+	now = now.Add(idleTimeout / 2)
+	limiter.Allow(Read, "10.0.0.1")
+
+	now = now.Add(idleTimeout)
+	limiter.Allow(Write, "10.0.0.4")
+
+	assert.Len(t, limiter.buckets, 1)
+	assert.Contains(t, limiter.buckets, bucketKey{class: Write, client: "10.0.0.4"})
+}
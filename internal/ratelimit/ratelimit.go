@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/forwarded"
+	"golang.org/x/time/rate"
+)
+
+/*
+Requests are throttled per endpoint class so that heavy read traffic (report polling, status checks) cannot use up the budget needed for live payment creation.  Admin operations have a class of their own.
+
+Budgets are tracked per client IP as the gateway has no notion of a merchant yet, once API keys exist the key should replace the IP.  Behind a reverse proxy the IP only tells clients apart when the proxy is trusted to forward it, see the forwarded package, and requests that arrive with no IP at all (over a Unix socket) are not limited.
+*/
+
+type Class string
+
+const (
+	Read  Class = "read"
+	Write Class = "write"
+	Admin Class = "admin"
+)
+
+// idleTimeout is how long a client's bucket is kept after its last request.  It is longer than any default budget
+// takes to refill, so an evicted bucket would have been full anyway.
+const idleTimeout = 10 * time.Minute
+
+type Budget struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+var DefaultBudgets = map[Class]Budget{
+	Read:  {RequestsPerSecond: 50, Burst: 100},
+	Write: {RequestsPerSecond: 10, Burst: 20},
+	Admin: {RequestsPerSecond: 1, Burst: 10},
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+type bucketKey struct {
+	class  Class
+	client string
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type Limiter struct {
+	mu        sync.Mutex
+	budgets   map[Class]Budget
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewLimiter(budgets map[Class]Budget) *Limiter {
+	return &Limiter{
+		budgets:   budgets,
+		buckets:   map[bucketKey]*bucket{},
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a client may make another request of the given class, and if not how long it should wait
+// before trying again.  Classes without a configured budget are never throttled.
+func (l *Limiter) Allow(class Class, client string) (bool, time.Duration) {
+	budget, ok := l.budgets[class]
+	if !ok {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.evictIdle(now)
+
+	key := bucketKey{class: class, client: client}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(budget.RequestsPerSecond), budget.Burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, idleTimeout
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// evictIdle drops the buckets of clients that have not been seen for idleTimeout, so callers rotating through source
+// addresses cannot grow the map without bound.  It sweeps at most once per idleTimeout to keep Allow cheap.
+func (l *Limiter) evictIdle(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTimeout {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Middleware throttles every request passing through it against the budget of class.
+func (l *Limiter) Middleware(class Class) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := forwarded.ClientIP(r)
+			if client == "" {
+				// a request without an address cannot be told apart from any other, limiting it would limit everyone
+				next.ServeHTTP(w, r)
+				return
+			}
+			if ok, retryAfter := l.Allow(class, client); !ok {
+				log.Printf("rate limited %s request from %s", class, client)
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				w.WriteHeader(http.StatusTooManyRequests)
+				if err := json.NewEncoder(w).Encode(errorResponse{
+					Message: fmt.Sprintf("Rate limit exceeded for %s requests. Please try again later.", class),
+				}); err != nil {
+					log.Printf("Failed to encode error response: %v", err)
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_SeparateBudgetsPerClass(t *testing.T) {
+	limiter := ratelimit.NewLimiter(map[ratelimit.Class]ratelimit.Budget{
+		ratelimit.Read:  {RequestsPerSecond: 0.1, Burst: 2},
+		ratelimit.Write: {RequestsPerSecond: 0.1, Burst: 1},
+	})
+
+	// exhaust the read budget
+	ok, _ := limiter.Allow(ratelimit.Read, "10.0.0.1")
+	assert.True(t, ok)
+	ok, _ = limiter.Allow(ratelimit.Read, "10.0.0.1")
+	assert.True(t, ok)
+	ok, retryAfter := limiter.Allow(ratelimit.Read, "10.0.0.1")
+	assert.False(t, ok)
+	assert.Greater(t, retryAfter.Seconds(), 9.0)
+
+	// writes and other clients are unaffected
+	ok, _ = limiter.Allow(ratelimit.Write, "10.0.0.1")
+	assert.True(t, ok)
+	ok, _ = limiter.Allow(ratelimit.Read, "10.0.0.2")
+	assert.True(t, ok)
+
+	// classes without a budget are not throttled
+	ok, _ = limiter.Allow(ratelimit.Class("unknown"), "10.0.0.1")
+	assert.True(t, ok)
+}
+
+func TestLimiter_Middleware(t *testing.T) {
+	limiter := ratelimit.NewLimiter(map[ratelimit.Class]ratelimit.Budget{
+		ratelimit.Write: {RequestsPerSecond: 0.5, Burst: 1},
+	})
+
+	handler := limiter.Middleware(ratelimit.Write)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("POST", "/api/payments", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	// one token every two seconds
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+}
+
+func TestLimiter_MiddlewareWithoutAddress(t *testing.T) {
+	limiter := ratelimit.NewLimiter(map[ratelimit.Class]ratelimit.Budget{
+		ratelimit.Write: {RequestsPerSecond: 0.5, Burst: 1},
+	})
+
+	handler := limiter.Middleware(ratelimit.Write)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// a peer on a Unix socket has no address, every client would share its budget
+	req := httptest.NewRequest("POST", "/api/payments", nil)
+	req.RemoteAddr = "@"
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
@@ -0,0 +1,145 @@
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/*
+Sensitive model fields carry a pii struct tag saying what kind of data they hold, and everything that sends models somewhere outside the request path goes through Value or JSON so the tag is enforced in one place rather than by each caller remembering:
+
+  - pii:"secret" is never written anywhere (CVV)
+  - pii:"pan" is masked down to its last four digits (card numbers)
+  - pii:"personal" identifies the cardholder (billing address), it is kept for Internal destinations, hashed for Export so records can still be joined, and replaced for Log
+
+The result mirrors the JSON encoding of the value, field names come from the json tags.
+*/
+
+// Destination is where redacted data is going, the less it is trusted the less it sees.
+type Destination int
+
+const (
+	// Internal is in-process code such as event subscribers.
+	Internal Destination = iota
+	// Export is data leaving the gateway for other systems, such as reports or a warehouse.
+	Export
+	// Log is the application log.
+	Log
+)
+
+const (
+	Secret   = "secret"
+	PAN      = "pan"
+	Personal = "personal"
+
+	redacted = "[redacted]"
+)
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// Value returns v as maps, slices and plain values in the shape of its JSON encoding, with tagged fields redacted for destination.
+func Value(v any, destination Destination) any {
+	return value(reflect.ValueOf(v), destination)
+}
+
+// JSON encodes v with tagged fields redacted for destination.
+func JSON(v any, destination Destination) ([]byte, error) {
+	return json.Marshal(Value(v, destination))
+}
+
+func value(v reflect.Value, destination Destination) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type().Implements(marshalerType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return value(v.Elem(), destination)
+	case reflect.Struct:
+		return structValue(v, destination)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		values := make([]any, v.Len())
+		for i := range values {
+			values[i] = value(v.Index(i), destination)
+		}
+		return values
+	default:
+		return v.Interface()
+	}
+}
+
+func structValue(v reflect.Value, destination Destination) map[string]any {
+	fields := map[string]any{}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name, omitEmpty, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+		fieldValue := v.Field(i)
+		if omitEmpty && fieldValue.IsZero() {
+			continue
+		}
+
+		switch field.Tag.Get("pii") {
+		case Secret:
+			continue
+		case PAN:
+			fields[name] = mask(fieldValue)
+		case Personal:
+			fields[name] = personal(fieldValue, destination)
+		default:
+			fields[name] = value(fieldValue, destination)
+		}
+	}
+	return fields
+}
+
+func jsonName(field reflect.StructField) (name string, omitEmpty bool, ok bool) {
+	if !field.IsExported() {
+		return "", false, false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(options, "omitempty"), true
+}
+
+// mask keeps the last four digits of a card number.
+func mask(v reflect.Value) string {
+	digits := fmt.Sprint(v.Interface())
+	if len(digits) <= 4 {
+		return strings.Repeat("*", len(digits))
+	}
+	return strings.Repeat("*", len(digits)-4) + digits[len(digits)-4:]
+}
+
+func personal(v reflect.Value, destination Destination) any {
+	switch destination {
+	case Internal:
+		return v.Interface()
+	case Export:
+		sum := sha256.Sum256([]byte(fmt.Sprint(v.Interface())))
+		return hex.EncodeToString(sum[:])
+	default:
+		return redacted
+	}
+}
@@ -0,0 +1,106 @@
+package redact_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON_Destinations(t *testing.T) {
+	request := models.PostPaymentBankRequest{
+		CardNumber: "2222405343248877",
+		ExpiryDate: "4/2035",
+		Currency:   "GBP",
+		Amount:     100,
+		CVV:        "123",
+		BillingAddress: &models.BillingAddress{
+			Line1:      "1 Main Street",
+			PostalCode: "SW1A 1AA",
+			Country:    "GB",
+		},
+	}
+
+	tests := []struct {
+		destination   redact.Destination
+		expectedLine1 string
+	}{
+		{destination: redact.Internal, expectedLine1: `"line1":"1 Main Street"`},
+		{destination: redact.Export, expectedLine1: `"line1":"`},
+		{destination: redact.Log, expectedLine1: `"line1":"[redacted]"`},
+	}
+
+	for _, tt := range tests {
+		body, err := redact.JSON(request, tt.destination)
+		require.NoError(t, err)
+
+		assert.Contains(t, string(body), `"card_number":"************8877"`)
+		assert.NotContains(t, string(body), "cvv")
+		assert.NotContains(t, string(body), "2222405343248877")
+		assert.Contains(t, string(body), `"currency":"GBP"`)
+		assert.Contains(t, string(body), `"country":"GB"`)
+		assert.Contains(t, string(body), tt.expectedLine1)
+		if tt.destination != redact.Internal {
+			assert.NotContains(t, string(body), "1 Main Street")
+		}
+	}
+}
+
+func TestValue_OmitsEmptyAndUntaggedFields(t *testing.T) {
+	card := models.StoredCard{Token: "token", CardNumberLastFour: 8877, CardNumber: 2222405343248877}
+
+	fields, ok := redact.Value(&card, redact.Internal).(map[string]any)
+	require.True(t, ok)
+
+	assert.Equal(t, "token", fields["token"])
+	assert.Equal(t, 8877, fields["card_number_last_four"])
+	assert.NotContains(t, fields, "CardNumber", "json:\"-\" fields are never written")
+}
+
+// sensitiveFields are JSON names that always hold cardholder data, every model field with one of them must say how it is redacted.
+var sensitiveFields = map[string]bool{
+	"card_number": true,
+	"cvv":         true,
+	"line1":       true,
+	"line2":       true,
+	"city":        true,
+	"postal_code": true,
+}
+
+func TestModels_SensitiveFieldsAreTagged(t *testing.T) {
+	files, err := filepath.Glob("../models/*.go")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		parsed, err := parser.ParseFile(fset, file, nil, 0)
+		require.NoError(t, err)
+
+		ast.Inspect(parsed, func(node ast.Node) bool {
+			field, ok := node.(*ast.Field)
+			if !ok || field.Tag == nil {
+				return true
+			}
+			tagValue, err := strconv.Unquote(field.Tag.Value)
+			require.NoError(t, err)
+			tag := reflect.StructTag(tagValue)
+
+			name, _, _ := strings.Cut(tag.Get("json"), ",")
+			if !sensitiveFields[name] {
+				return true
+			}
+			assert.NotEmpty(t, tag.Get("pii"), "%s: field %s has no pii tag", fset.Position(field.Pos()), name)
+			return true
+		})
+	}
+}
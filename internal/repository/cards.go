@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+// CardsRepository keeps the cards customers have stored for later payments, keyed by token.  It is safe for concurrent use.
+type CardsRepository struct {
+	mu    sync.RWMutex
+	cards map[string]models.StoredCard
+}
+
+func NewCardsRepository() *CardsRepository {
+	return &CardsRepository{
+		cards: map[string]models.StoredCard{},
+	}
+}
+
+// GetCard returns the card stored under the given token, or nil if there is none.
+func (cs *CardsRepository) GetCard(ctx context.Context, token string) (*models.StoredCard, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	card, ok := cs.cards[token]
+	if !ok {
+		return nil, nil
+	}
+	return &card, nil
+}
+
+func (cs *CardsRepository) AddCard(ctx context.Context, card models.StoredCard) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.cards[card.Token] = card
+	return nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+// DisputesRepository keeps disputes by id with an index by the payment they were raised against.  It is safe for concurrent use.
+type DisputesRepository struct {
+	mu        sync.RWMutex
+	disputes  map[string]models.Dispute
+	byPayment map[string][]string
+}
+
+func NewDisputesRepository() *DisputesRepository {
+	return &DisputesRepository{
+		disputes:  map[string]models.Dispute{},
+		byPayment: map[string][]string{},
+	}
+}
+
+// GetDispute returns the dispute with the given id, or nil if there is none.
+func (ds *DisputesRepository) GetDispute(ctx context.Context, id string) (*models.Dispute, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	dispute, ok := ds.disputes[id]
+	if !ok {
+		return nil, nil
+	}
+	return &dispute, nil
+}
+
+// ListDisputes returns the disputes raised against a payment, oldest first.
+func (ds *DisputesRepository) ListDisputes(ctx context.Context, paymentID string) ([]models.Dispute, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	disputes := []models.Dispute{}
+	for _, id := range ds.byPayment[paymentID] {
+		disputes = append(disputes, ds.disputes[id])
+	}
+	return disputes, nil
+}
+
+func (ds *DisputesRepository) AddDispute(ctx context.Context, dispute models.Dispute) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	ds.disputes[dispute.Id] = dispute
+	ds.byPayment[dispute.PaymentId] = append(ds.byPayment[dispute.PaymentId], dispute.Id)
+	return nil
+}
+
+// UpdateDispute replaces the stored dispute with the same id, it fails with gatewayerrors.ErrDisputeNotFound if there is none.
+func (ds *DisputesRepository) UpdateDispute(ctx context.Context, dispute models.Dispute) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	if _, ok := ds.disputes[dispute.Id]; !ok {
+		return gatewayerrors.ErrDisputeNotFound
+	}
+	ds.disputes[dispute.Id] = dispute
+	return nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+// IntentsRepository keeps payment intents by id.  It is safe for concurrent use.
+type IntentsRepository struct {
+	mu      sync.RWMutex
+	intents map[string]models.PaymentIntent
+}
+
+func NewIntentsRepository() *IntentsRepository {
+	return &IntentsRepository{
+		intents: map[string]models.PaymentIntent{},
+	}
+}
+
+// GetIntent returns the payment intent with the given id, or nil if there is none.
+func (is *IntentsRepository) GetIntent(ctx context.Context, id string) (*models.PaymentIntent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	is.mu.RLock()
+	defer is.mu.RUnlock()
+
+	intent, ok := is.intents[id]
+	if !ok {
+		return nil, nil
+	}
+	return &intent, nil
+}
+
+func (is *IntentsRepository) AddIntent(ctx context.Context, intent models.PaymentIntent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	is.intents[intent.Id] = intent
+	return nil
+}
+
+// UpdateIntent replaces the stored payment intent with the same id, it fails with gatewayerrors.ErrPaymentIntentNotFound if there is none.
+func (is *IntentsRepository) UpdateIntent(ctx context.Context, intent models.PaymentIntent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	if _, ok := is.intents[intent.Id]; !ok {
+		return gatewayerrors.ErrPaymentIntentNotFound
+	}
+	is.intents[intent.Id] = intent
+	return nil
+}
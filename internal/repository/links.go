@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+// LinksRepository keeps payment links by id.  It is safe for concurrent use.
+type LinksRepository struct {
+	mu    sync.RWMutex
+	links map[string]models.PaymentLink
+}
+
+func NewLinksRepository() *LinksRepository {
+	return &LinksRepository{
+		links: map[string]models.PaymentLink{},
+	}
+}
+
+// GetLink returns the payment link with the given id, or nil if there is none.
+func (ls *LinksRepository) GetLink(ctx context.Context, id string) (*models.PaymentLink, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	link, ok := ls.links[id]
+	if !ok {
+		return nil, nil
+	}
+	return &link, nil
+}
+
+func (ls *LinksRepository) AddLink(ctx context.Context, link models.PaymentLink) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.links[link.Id] = link
+	return nil
+}
+
+// UpdateLink replaces the stored payment link with the same id, it fails with gatewayerrors.ErrPaymentLinkNotFound if there is none.
+func (ls *LinksRepository) UpdateLink(ctx context.Context, link models.PaymentLink) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if _, ok := ls.links[link.Id]; !ok {
+		return gatewayerrors.ErrPaymentLinkNotFound
+	}
+	ls.links[link.Id] = link
+	return nil
+}
@@ -1,28 +1,184 @@
 package repository
 
 import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
 )
 
+// PaymentsRepository is safe for concurrent use, payments are kept in insertion order with an index by id for lookups
+// and one by card last four for searches.  Every version of a payment is also kept, in the order it was stored, so its
+// state at an earlier time can be looked up.
 type PaymentsRepository struct {
-	payments []models.PostPaymentResponse
+	mu         sync.RWMutex
+	payments   []models.PostPaymentResponse
+	byID       map[string]int
+	byLastFour map[int][]int
+	history    map[string][]version
+}
+
+type version struct {
+	storedAt time.Time
+	payment  models.PostPaymentResponse
 }
 
 func NewPaymentsRepository() *PaymentsRepository {
 	return &PaymentsRepository{
-		payments: []models.PostPaymentResponse{},
+		payments:   []models.PostPaymentResponse{},
+		byID:       map[string]int{},
+		byLastFour: map[int][]int{},
+		history:    map[string][]version{},
 	}
 }
 
-func (ps *PaymentsRepository) GetPayment(id string) *models.PostPaymentResponse {
-	for _, element := range ps.payments {
-		if element.Id == id {
-			return &element
+// GetPayment returns the payment with the given id, or nil if there is none.
+func (ps *PaymentsRepository) GetPayment(ctx context.Context, id string) (*models.PostPaymentResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	i, ok := ps.byID[id]
+	if !ok {
+		return nil, nil
+	}
+	payment := clone(ps.payments[i])
+	return &payment, nil
+}
+
+// AddPayment stores a new payment, it fails with gatewayerrors.ErrPaymentExists if one with the same id is already stored.
+func (ps *PaymentsRepository) AddPayment(ctx context.Context, payment models.PostPaymentResponse) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if _, ok := ps.byID[payment.Id]; ok {
+		return gatewayerrors.ErrPaymentExists
+	}
+	ps.byID[payment.Id] = len(ps.payments)
+	ps.byLastFour[payment.CardNumberLastFour] = append(ps.byLastFour[payment.CardNumberLastFour], len(ps.payments))
+	ps.payments = append(ps.payments, clone(payment))
+	ps.record(payment)
+	return nil
+}
+
+// ListPayments returns a copy of every stored payment that matches filter, in the order they were added.  A payment was
+// created when it was first stored.
+func (ps *PaymentsRepository) ListPayments(ctx context.Context, filter models.PaymentFilter) ([]models.PostPaymentResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	payments := []models.PostPaymentResponse{}
+	add := func(payment models.PostPaymentResponse) {
+		if matches(filter, payment, ps.history[payment.Id][0].storedAt) {
+			payments = append(payments, clone(payment))
 		}
 	}
+
+	// the index holds positions in insertion order, so the order is the same either way
+	if filter.LastFour != nil {
+		for _, i := range ps.byLastFour[*filter.LastFour] {
+			add(ps.payments[i])
+		}
+		return payments, nil
+	}
+	for _, payment := range ps.payments {
+		add(payment)
+	}
+	return payments, nil
+}
+
+func matches(filter models.PaymentFilter, payment models.PostPaymentResponse, createdAt time.Time) bool {
+	switch {
+	case filter.Status != "" && payment.PaymentStatus != filter.Status:
+		return false
+	case filter.Currency != "" && payment.Currency != filter.Currency:
+		return false
+	case filter.LastFour != nil && payment.CardNumberLastFour != *filter.LastFour:
+		return false
+	case filter.MinAmount != nil && payment.Amount < *filter.MinAmount:
+		return false
+	case filter.MaxAmount != nil && payment.Amount > *filter.MaxAmount:
+		return false
+	case filter.CreatedAfter != nil && createdAt.Before(*filter.CreatedAfter):
+		return false
+	case filter.CreatedBefore != nil && createdAt.After(*filter.CreatedBefore):
+		return false
+	}
+	return true
+}
+
+// UpdatePayment replaces the stored payment with the same id, it fails with gatewayerrors.ErrPaymentNotFound if there is none.
+func (ps *PaymentsRepository) UpdatePayment(ctx context.Context, payment models.PostPaymentResponse) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	i, ok := ps.byID[payment.Id]
+	if !ok {
+		return gatewayerrors.ErrPaymentNotFound
+	}
+	if previous := ps.payments[i].CardNumberLastFour; previous != payment.CardNumberLastFour {
+		ps.byLastFour[previous] = slices.DeleteFunc(ps.byLastFour[previous], func(j int) bool { return j == i })
+		positions := append(ps.byLastFour[payment.CardNumberLastFour], i)
+		slices.Sort(positions)
+		ps.byLastFour[payment.CardNumberLastFour] = positions
+	}
+	ps.payments[i] = clone(payment)
+	ps.record(payment)
 	return nil
 }
 
-func (ps *PaymentsRepository) AddPayment(payment models.PostPaymentResponse) {
-	ps.payments = append(ps.payments, payment)
+// GetPaymentAsOf returns the payment with the given id as it was stored at the given time, or nil if it did not exist yet.
+func (ps *PaymentsRepository) GetPaymentAsOf(ctx context.Context, id string, at time.Time) (*models.PostPaymentResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	versions := ps.history[id]
+	// versions are stored in time order so the one in effect is the last stored no later than at
+	i := sort.Search(len(versions), func(i int) bool {
+		return versions[i].storedAt.After(at)
+	})
+	if i == 0 {
+		return nil, nil
+	}
+	payment := clone(versions[i-1].payment)
+	return &payment, nil
+}
+
+// record keeps a version of the payment, the caller must hold the write lock.
+func (ps *PaymentsRepository) record(payment models.PostPaymentResponse) {
+	ps.history[payment.Id] = append(ps.history[payment.Id], version{
+		storedAt: time.Now(),
+		payment:  clone(payment),
+	})
+}
+
+// clone copies the slices in a payment so callers can change what they were given without touching the stored record.
+func clone(payment models.PostPaymentResponse) models.PostPaymentResponse {
+	payment.Captures = slices.Clone(payment.Captures)
+	payment.Refunds = slices.Clone(payment.Refunds)
+	payment.Retries = slices.Clone(payment.Retries)
+	return payment
 }
@@ -0,0 +1,139 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stressWorkers is the number of goroutines hammering the repository in the stress test, run it with -race to catch unsynchronised access.
+const stressWorkers = 2000
+
+func TestPaymentsRepository_ConcurrentAddAndGet(t *testing.T) {
+	repo := repository.NewPaymentsRepository()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < stressWorkers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			payment := testPayment(i)
+			assert.NoError(t, repo.AddPayment(ctx, payment))
+
+			got, err := repo.GetPayment(ctx, payment.Id)
+			assert.NoError(t, err)
+			assert.Equal(t, &payment, got)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < stressWorkers; i++ {
+		payment := testPayment(i)
+		got, err := repo.GetPayment(ctx, payment.Id)
+		require.NoError(t, err)
+		require.Equal(t, &payment, got)
+	}
+}
+
+func BenchmarkPaymentsRepository_AddPayment(b *testing.B) {
+	repo := repository.NewPaymentsRepository()
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := repo.AddPayment(ctx, testPayment(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPaymentsRepository_GetPayment(b *testing.B) {
+	for _, size := range []int{1_000, 100_000} {
+		b.Run(fmt.Sprintf("payments=%d", size), func(b *testing.B) {
+			repo := repository.NewPaymentsRepository()
+			ctx := context.Background()
+			for i := 0; i < size; i++ {
+				if err := repo.AddPayment(ctx, testPayment(i)); err != nil {
+					b.Fatal(err)
+				}
+			}
+			id := testPayment(size - 1).Id
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.GetPayment(ctx, id); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPaymentsRepository_ListPaymentsByLastFour(b *testing.B) {
+	for _, size := range []int{1_000, 100_000} {
+		b.Run(fmt.Sprintf("payments=%d", size), func(b *testing.B) {
+			repo := repository.NewPaymentsRepository()
+			ctx := context.Background()
+			for i := 0; i < size; i++ {
+				payment := testPayment(i)
+				payment.CardNumberLastFour = i % 10_000
+				if err := repo.AddPayment(ctx, payment); err != nil {
+					b.Fatal(err)
+				}
+			}
+			lastFour := 8877
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.ListPayments(ctx, models.PaymentFilter{LastFour: &lastFour}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPaymentsRepository_Parallel(b *testing.B) {
+	repo := repository.NewPaymentsRepository()
+	ctx := context.Background()
+	if err := repo.AddPayment(ctx, testPayment(0)); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			// one write for every nine reads, roughly the mix of POST to GET traffic we expect
+			if i%10 == 0 {
+				if err := repo.AddPayment(ctx, testPayment(i)); err != nil {
+					b.Fatal(err)
+				}
+			} else if _, err := repo.GetPayment(ctx, testPayment(0).Id); err != nil {
+				b.Fatal(err)
+			}
+			i++
+		}
+	})
+}
+
+func testPayment(i int) models.PostPaymentResponse {
+	return models.PostPaymentResponse{
+		Id:                 fmt.Sprintf("payment-%d", i),
+		PaymentStatus:      "authorized",
+		CardNumberLastFour: 1234,
+		ExpiryMonth:        10,
+		ExpiryYear:         2035,
+		Currency:           "GBP",
+		Amount:             100,
+	}
+}
@@ -1,11 +1,15 @@
 package repository_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetPayment(t *testing.T) {
@@ -22,10 +26,12 @@ func TestGetPayment(t *testing.T) {
 	}
 
 	repository := repository.NewPaymentsRepository()
-	repository.AddPayment(expectedPayment)
+	err := repository.AddPayment(context.Background(), expectedPayment)
+	require.NoError(t, err)
 
 	// act
-	payment := repository.GetPayment(expectedPayment.Id)
+	payment, err := repository.GetPayment(context.Background(), expectedPayment.Id)
+	require.NoError(t, err)
 
 	// assert
 	assert.Equal(t, expectedPayment, *payment)
@@ -47,8 +53,182 @@ func TestAddPayment(t *testing.T) {
 	repository := repository.NewPaymentsRepository()
 
 	// act
-	repository.AddPayment(expectedPayment)
+	err := repository.AddPayment(context.Background(), expectedPayment)
+	require.NoError(t, err)
 
 	// assert
-	assert.Equal(t, &expectedPayment, repository.GetPayment(expectedPayment.Id))
+	payment, err := repository.GetPayment(context.Background(), expectedPayment.Id)
+	require.NoError(t, err)
+	assert.Equal(t, &expectedPayment, payment)
+}
+
+func TestAddPayment_Exists(t *testing.T) {
+	payment := models.PostPaymentResponse{Id: "test-id", PaymentStatus: "authorized", CardNumberLastFour: 1234}
+
+	repository := repository.NewPaymentsRepository()
+	require.NoError(t, repository.AddPayment(context.Background(), payment))
+
+	err := repository.AddPayment(context.Background(), payment)
+	assert.ErrorIs(t, err, gatewayerrors.ErrPaymentExists)
+
+	payments, err := repository.ListPayments(context.Background(), models.PaymentFilter{})
+	require.NoError(t, err)
+	assert.Len(t, payments, 1)
+
+	lastFour := 1234
+	payments, err = repository.ListPayments(context.Background(), models.PaymentFilter{LastFour: &lastFour})
+	require.NoError(t, err)
+	assert.Len(t, payments, 1)
+}
+
+func TestGetPayment_DeadlineExceeded(t *testing.T) {
+
+	// arrange
+	repository := repository.NewPaymentsRepository()
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	// act
+	payment, err := repository.GetPayment(ctx, "test-id")
+
+	// assert
+	assert.Nil(t, payment)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestUpdatePayment(t *testing.T) {
+
+	// arrange
+	payment := models.PostPaymentResponse{
+		Id:            "test-id",
+		PaymentStatus: "authorized",
+		Amount:        100,
+	}
+
+	repository := repository.NewPaymentsRepository()
+	require.NoError(t, repository.AddPayment(context.Background(), payment))
+
+	// act
+	payment.PaymentStatus = "captured"
+	err := repository.UpdatePayment(context.Background(), payment)
+	require.NoError(t, err)
+
+	// assert
+	stored, err := repository.GetPayment(context.Background(), payment.Id)
+	require.NoError(t, err)
+	assert.Equal(t, &payment, stored)
+}
+
+func TestUpdatePayment_NotFound(t *testing.T) {
+	repository := repository.NewPaymentsRepository()
+
+	err := repository.UpdatePayment(context.Background(), models.PostPaymentResponse{Id: "test-id"})
+
+	assert.ErrorIs(t, err, gatewayerrors.ErrPaymentNotFound)
+}
+
+func TestGetPaymentAsOf(t *testing.T) {
+
+	// arrange
+	payment := models.PostPaymentResponse{
+		Id:            "test-id",
+		PaymentStatus: "authorized",
+		Amount:        100,
+	}
+
+	repository := repository.NewPaymentsRepository()
+	beforeAdd := time.Now()
+	time.Sleep(time.Millisecond)
+	require.NoError(t, repository.AddPayment(context.Background(), payment))
+	authorizedAt := time.Now()
+	time.Sleep(time.Millisecond)
+
+	captured := payment
+	captured.PaymentStatus = "captured"
+	require.NoError(t, repository.UpdatePayment(context.Background(), captured))
+
+	// act
+	before, err := repository.GetPaymentAsOf(context.Background(), "test-id", beforeAdd)
+	require.NoError(t, err)
+	then, err := repository.GetPaymentAsOf(context.Background(), "test-id", authorizedAt)
+	require.NoError(t, err)
+	now, err := repository.GetPaymentAsOf(context.Background(), "test-id", time.Now())
+	require.NoError(t, err)
+
+	// assert
+	assert.Nil(t, before)
+	assert.Equal(t, &payment, then)
+	assert.Equal(t, &captured, now)
+}
+
+func TestListPayments_Filter(t *testing.T) {
+
+	// arrange
+	repository := repository.NewPaymentsRepository()
+	require.NoError(t, repository.AddPayment(context.Background(), models.PostPaymentResponse{Id: "old", PaymentStatus: "captured", Currency: "GBP", Amount: 100}))
+	time.Sleep(time.Millisecond)
+	between := time.Now()
+	time.Sleep(time.Millisecond)
+	require.NoError(t, repository.AddPayment(context.Background(), models.PostPaymentResponse{Id: "small", PaymentStatus: "authorized", Currency: "GBP", Amount: 50}))
+	require.NoError(t, repository.AddPayment(context.Background(), models.PostPaymentResponse{Id: "euro", PaymentStatus: "authorized", Currency: "EUR", Amount: 500}))
+
+	minAmount, maxAmount := 100, 500
+	tests := []struct {
+		name     string
+		filter   models.PaymentFilter
+		expected []string
+	}{
+		{name: "Everything", filter: models.PaymentFilter{}, expected: []string{"old", "small", "euro"}},
+		{name: "Status", filter: models.PaymentFilter{Status: "authorized"}, expected: []string{"small", "euro"}},
+		{name: "Currency", filter: models.PaymentFilter{Currency: "GBP"}, expected: []string{"old", "small"}},
+		{name: "AmountBoundsInclusive", filter: models.PaymentFilter{MinAmount: &minAmount, MaxAmount: &maxAmount}, expected: []string{"old", "euro"}},
+		{name: "CreatedAfter", filter: models.PaymentFilter{CreatedAfter: &between}, expected: []string{"small", "euro"}},
+		{name: "CreatedBefore", filter: models.PaymentFilter{CreatedBefore: &between}, expected: []string{"old"}},
+		{name: "Combined", filter: models.PaymentFilter{Status: "authorized", Currency: "GBP"}, expected: []string{"small"}},
+		{name: "NoMatch", filter: models.PaymentFilter{Status: "refunded"}, expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// act
+			payments, err := repository.ListPayments(context.Background(), tt.filter)
+			require.NoError(t, err)
+
+			// assert
+			ids := []string{}
+			for _, payment := range payments {
+				ids = append(ids, payment.Id)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestListPayments_LastFour(t *testing.T) {
+
+	// arrange
+	repository := repository.NewPaymentsRepository()
+	require.NoError(t, repository.AddPayment(context.Background(), models.PostPaymentResponse{Id: "first", CardNumberLastFour: 8877, Currency: "GBP"}))
+	require.NoError(t, repository.AddPayment(context.Background(), models.PostPaymentResponse{Id: "other", CardNumberLastFour: 1234, Currency: "GBP"}))
+	require.NoError(t, repository.AddPayment(context.Background(), models.PostPaymentResponse{Id: "second", CardNumberLastFour: 8877, Currency: "EUR"}))
+	require.NoError(t, repository.AddPayment(context.Background(), models.PostPaymentResponse{Id: "zeros", CardNumberLastFour: 0, Currency: "GBP"}))
+
+	// an update that changes the last four moves the payment in the index
+	require.NoError(t, repository.UpdatePayment(context.Background(), models.PostPaymentResponse{Id: "other", CardNumberLastFour: 8877, Currency: "GBP"}))
+
+	search := func(lastFour int, currency string) []string {
+		payments, err := repository.ListPayments(context.Background(), models.PaymentFilter{LastFour: &lastFour, Currency: currency})
+		require.NoError(t, err)
+		ids := []string{}
+		for _, payment := range payments {
+			ids = append(ids, payment.Id)
+		}
+		return ids
+	}
+
+	// act and assert
+	assert.Equal(t, []string{"first", "other", "second"}, search(8877, ""))
+	assert.Equal(t, []string{"first", "other"}, search(8877, "GBP"))
+	assert.Equal(t, []string{}, search(1234, ""))
+	assert.Equal(t, []string{"zeros"}, search(0, ""))
 }
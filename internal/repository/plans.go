@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/gatewayerrors"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+)
+
+// PlansRepository keeps installment plans by id.  It is safe for concurrent use.
+type PlansRepository struct {
+	mu    sync.RWMutex
+	plans map[string]models.InstallmentPlan
+}
+
+func NewPlansRepository() *PlansRepository {
+	return &PlansRepository{
+		plans: map[string]models.InstallmentPlan{},
+	}
+}
+
+// GetPlan returns the installment plan with the given id, or nil if there is none.
+func (ps *PlansRepository) GetPlan(ctx context.Context, id string) (*models.InstallmentPlan, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	plan, ok := ps.plans[id]
+	if !ok {
+		return nil, nil
+	}
+	return &plan, nil
+}
+
+func (ps *PlansRepository) AddPlan(ctx context.Context, plan models.InstallmentPlan) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.plans[plan.Id] = plan
+	return nil
+}
+
+// UpdatePlan replaces the stored installment plan with the same id, it fails with gatewayerrors.ErrInstallmentPlanNotFound if there is none.
+func (ps *PlansRepository) UpdatePlan(ctx context.Context, plan models.InstallmentPlan) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if _, ok := ps.plans[plan.Id]; !ok {
+		return gatewayerrors.ErrInstallmentPlanNotFound
+	}
+	ps.plans[plan.Id] = plan
+	return nil
+}
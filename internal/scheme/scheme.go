@@ -0,0 +1,48 @@
+package scheme
+
+import "strconv"
+
+/*
+Detect works out the card scheme from the leading digits of the card number (the BIN ranges each scheme publishes).  Only the schemes the gateway treats differently are recognised, anything else is Unknown.
+*/
+
+type Scheme string
+
+const (
+	Visa       Scheme = "visa"
+	Mastercard Scheme = "mastercard"
+	Amex       Scheme = "amex"
+	Discover   Scheme = "discover"
+	Unknown    Scheme = "unknown"
+)
+
+func Detect(cardNumber string) Scheme {
+	switch {
+	case hasPrefix(cardNumber, 1, 4):
+		return Visa
+	case inRange(cardNumber, 2, 51, 55), inRange(cardNumber, 4, 2221, 2720):
+		return Mastercard
+	case hasPrefix(cardNumber, 2, 34), hasPrefix(cardNumber, 2, 37):
+		return Amex
+	case hasPrefix(cardNumber, 4, 6011), hasPrefix(cardNumber, 2, 65), inRange(cardNumber, 3, 644, 649):
+		return Discover
+	default:
+		return Unknown
+	}
+}
+
+func hasPrefix(cardNumber string, digits, prefix int) bool {
+	return inRange(cardNumber, digits, prefix, prefix)
+}
+
+// inRange reports whether the first digits of cardNumber, read as a number, fall between low and high inclusive.
+func inRange(cardNumber string, digits, low, high int) bool {
+	if len(cardNumber) < digits {
+		return false
+	}
+	leading, err := strconv.Atoi(cardNumber[:digits])
+	if err != nil {
+		return false
+	}
+	return leading >= low && leading <= high
+}
@@ -0,0 +1,31 @@
+package scheme_test
+
+import (
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/scheme"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		cardNumber string
+		want       scheme.Scheme
+	}{
+		{cardNumber: "4111111111111111", want: scheme.Visa},
+		{cardNumber: "5555555555554444", want: scheme.Mastercard},
+		{cardNumber: "2222405343248877", want: scheme.Mastercard},
+		{cardNumber: "378282246310005", want: scheme.Amex},
+		{cardNumber: "6011111111111117", want: scheme.Discover},
+		{cardNumber: "6445644564456445", want: scheme.Discover},
+		{cardNumber: "3530111333300000", want: scheme.Unknown},
+		{cardNumber: "4", want: scheme.Visa},
+		{cardNumber: "", want: scheme.Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cardNumber, func(t *testing.T) {
+			assert.Equal(t, tt.want, scheme.Detect(tt.cardNumber))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/models"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+)
+
+/*
+Seed data lets demos and UI work start from a populated gateway instead of a sequence of curl commands.  The file is plain JSON so it can be edited by hand, see seed/demo.json for an example.
+
+Only payments can be seeded for now as they are the only thing the gateway stores.
+*/
+
+type Data struct {
+	Payments []models.PostPaymentResponse `json:"payments"`
+}
+
+func LoadFile(path string) (*Data, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open seed file: %w", err)
+	}
+	defer f.Close()
+
+	var data Data
+	if err := json.NewDecoder(f).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode seed file: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for i, payment := range data.Payments {
+		if payment.Id == "" {
+			return nil, fmt.Errorf("seed payment %d has no id", i)
+		}
+		if seen[payment.Id] {
+			return nil, fmt.Errorf("seed payment %d has the same id as an earlier one: %s", i, payment.Id)
+		}
+		seen[payment.Id] = true
+	}
+
+	return &data, nil
+}
+
+func (d *Data) Apply(ctx context.Context, repo *repository.PaymentsRepository) error {
+	for _, payment := range d.Payments {
+		if err := repo.AddPayment(ctx, payment); err != nil {
+			return fmt.Errorf("failed to seed payment %s: %w", payment.Id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package seed_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/repository"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/seed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFile_Demo(t *testing.T) {
+	data, err := seed.LoadFile("../../seed/demo.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, data.Payments)
+
+	repo := repository.NewPaymentsRepository()
+	require.NoError(t, data.Apply(context.Background(), repo))
+
+	for _, payment := range data.Payments {
+		stored, err := repo.GetPayment(context.Background(), payment.Id)
+		require.NoError(t, err)
+		assert.Equal(t, &payment, stored)
+	}
+}
+
+func TestLoadFile_MissingID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seed.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"payments": [{"payment_status": "authorized"}]}`), 0o600))
+
+	data, err := seed.LoadFile(path)
+	require.Error(t, err)
+	require.Nil(t, data)
+}
+
+func TestLoadFile_DuplicateID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seed.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"payments": [{"id": "a"}, {"id": "b"}, {"id": "a"}]}`), 0o600))
+
+	data, err := seed.LoadFile(path)
+	require.Error(t, err)
+	require.Nil(t, data)
+	assert.Contains(t, err.Error(), "same id")
+}
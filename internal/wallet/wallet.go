@@ -0,0 +1,61 @@
+package wallet
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+/*
+Apple Pay and Google Pay hand the merchant an encrypted payment token rather than card details.  A Decrypter unwraps it, with the keys the gateway holds for the wallet, into the device or network token, its expiry and the cryptogram the wallet generated, which the gateway then pays with like any other network token.
+
+Decrypting a real wallet token needs the merchant's wallet keys and certificates, so the gateway ships with no real Decrypter.  FakeDecrypter stands in for one in dev mode.
+*/
+
+const (
+	ApplePay  = "apple_pay"
+	GooglePay = "google_pay"
+)
+
+// ErrInvalidPayload is returned when a wallet payload cannot be decrypted.
+var ErrInvalidPayload = errors.New("invalid wallet payload")
+
+// Decrypted is the card data a wallet payload carries.
+type Decrypted struct {
+	Token       string `json:"token"`
+	ExpiryMonth int    `json:"expiry_month"`
+	ExpiryYear  int    `json:"expiry_year"`
+	Cryptogram  string `json:"cryptogram"`
+	ECI         string `json:"eci,omitempty"`
+}
+
+type Decrypter interface {
+	Decrypt(ctx context.Context, payload string) (*Decrypted, error)
+}
+
+// FakeDecrypter takes a payload that is the base64 encoding of a Decrypted as JSON, so wallet payments can be tried
+// without wallet keys.
+type FakeDecrypter struct{}
+
+func NewFakeDecrypter() *FakeDecrypter {
+	return &FakeDecrypter{}
+}
+
+func (d *FakeDecrypter) Decrypt(ctx context.Context, payload string) (*Decrypted, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	var decrypted Decrypted
+	if err := json.Unmarshal(data, &decrypted); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return &decrypted, nil
+}
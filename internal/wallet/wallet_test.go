@@ -0,0 +1,32 @@
+package wallet_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeDecrypter_Decrypt(t *testing.T) {
+	decrypter := wallet.NewFakeDecrypter()
+
+	payload := base64.StdEncoding.EncodeToString([]byte(`{"token": "4895370012003477", "expiry_month": 4, "expiry_year": 2035, "cryptogram": "AgAAAAAABk4DWZ4C28yUQAAAAAA=", "eci": "07"}`))
+	decrypted, err := decrypter.Decrypt(context.Background(), payload)
+	require.NoError(t, err)
+	assert.Equal(t, &wallet.Decrypted{
+		Token:       "4895370012003477",
+		ExpiryMonth: 4,
+		ExpiryYear:  2035,
+		Cryptogram:  "AgAAAAAABk4DWZ4C28yUQAAAAAA=",
+		ECI:         "07",
+	}, decrypted)
+
+	_, err = decrypter.Decrypt(context.Background(), "not base64!")
+	assert.ErrorIs(t, err, wallet.ErrInvalidPayload)
+
+	_, err = decrypter.Decrypt(context.Background(), base64.StdEncoding.EncodeToString([]byte("not json")))
+	assert.ErrorIs(t, err, wallet.ErrInvalidPayload)
+}
@@ -2,13 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/cko-recruitment/payment-gateway-challenge-go/docs"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/abuse"
 	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/api"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/client"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/forwarded"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/listen"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/mountebank"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/seed"
+	"github.com/cko-recruitment/payment-gateway-challenge-go/internal/wallet"
 )
 
 var (
@@ -17,6 +29,43 @@ var (
 	date    = "unknown"
 )
 
+const (
+	bankURL = "http://localhost:8080"
+
+	// concurrency limits for calls to the acquiring bank, AIMD moves the limit
+	// between min and max and treats calls slower than bankLatencyThreshold
+	// as the bank struggling
+	bankTimeout            = 5 * time.Second
+	bankInitialConcurrency = 20
+	bankMinConcurrency     = 1
+	bankMaxConcurrency     = 200
+	bankLatencyThreshold   = time.Second
+
+	// devSeedFile is loaded in dev mode when no -seed-file is given
+	devSeedFile = "seed/demo.json"
+)
+
+var (
+	dev           = flag.Bool("dev", false, "run with an in-process fake bank and verbose logging, no docker compose needed")
+	mountebankURL = flag.String("mountebank-url", "http://localhost:2525", "Mountebank admin API used to provision imposters")
+	impostersDir  = flag.String("imposters-dir", "", "provision Mountebank imposters from the fixtures in this directory at startup")
+	seedFile      = flag.String("seed-file", "", "load demo data from this JSON file at startup, defaults to "+devSeedFile+" in dev mode")
+
+	expiryInterval        = flag.Duration("expiry-interval", time.Minute, "how often to look for authorizations that were never captured, 0 disables expiry")
+	authorizationValidity = flag.Duration("authorization-validity", 0, "how long an authorization may go uncaptured before it is voided, 0 uses each card scheme's own window")
+	avsDecline            = flag.String("avs-decline", "", "comma separated AVS result codes to decline even when the bank authorizes, e.g. N,A,Z")
+	scheduleInterval      = flag.Duration("schedule-interval", 10*time.Second, "how often to send scheduled payments that have fallen due to the bank, 0 disables the scheduler")
+	asyncWorkers          = flag.Int("async-workers", 4, "workers sending payments made with Prefer: respond-async to the bank, 0 processes them synchronously")
+
+	listenAddress     = flag.String("listen", ":8090", "address to serve on: host:port, unix:/path/to/socket, or systemd for socket activation")
+	maxConnections    = flag.Int("max-connections", api.DefaultServerConfig.MaxConnections, "maximum concurrently open connections, 0 for no limit")
+	maxHeaderBytes    = flag.Int("max-header-bytes", api.DefaultServerConfig.MaxHeaderBytes, "maximum size of request headers in bytes")
+	readHeaderTimeout = flag.Duration("read-header-timeout", api.DefaultServerConfig.ReadHeaderTimeout, "time allowed for a client to send request headers")
+	idleTimeout       = flag.Duration("idle-timeout", api.DefaultServerConfig.IdleTimeout, "how long an idle keep-alive connection is kept open")
+	trustedProxies    = flag.String("trusted-proxies", "", "comma separated IPs or CIDR ranges of reverse proxies trusted to set X-Forwarded-For, unix trusts peers on a Unix socket")
+	h2c               = flag.Bool("h2c", api.DefaultServerConfig.H2C, "serve HTTP/2 over cleartext alongside HTTP/1.1")
+)
+
 //	@title			Payment Gateway Challenge Go
 //	@description	Interview challenge for building a Payment Gateway - Go version
 
@@ -25,6 +74,7 @@ var (
 
 // @securityDefinitions.basic	BasicAuth
 func main() {
+	flag.Parse()
 	fmt.Printf("version %s, commit %s, built at %s\n", version, commit, date)
 	docs.SwaggerInfo.Version = version
 
@@ -54,10 +104,105 @@ func run() error {
 		}
 	}()
 
-	api := api.New()
-	if err := api.Run(ctx, ":8090"); err != nil {
+	if *dev && *impostersDir != "" {
+		return errors.New("-dev uses an in-process fake bank, it cannot be combined with -imposters-dir")
+	}
+
+	var bankClient client.Client
+	if *dev {
+		fmt.Printf("dev mode: using in-process fake bank\n")
+		log.SetFlags(log.LstdFlags | log.Lmicroseconds | log.Lshortfile)
+		bankClient = client.NewFakeClient()
+	} else {
+		if *impostersDir != "" {
+			provisioner := mountebank.NewProvisioner(*mountebankURL, 5*time.Second)
+			if err := provisioner.ProvisionDir(*impostersDir); err != nil {
+				return err
+			}
+		}
+		// shed load early rather than queueing behind a slow acquirer
+		bankClient = client.NewAdaptiveLimitClient(
+			client.NewClient(bankURL, bankTimeout),
+			bankInitialConcurrency,
+			bankMinConcurrency,
+			bankMaxConcurrency,
+			bankLatencyThreshold,
+		)
+	}
+
+	api := api.New(bankClient)
+	if err := seedPayments(ctx, api); err != nil {
+		return err
+	}
+	if *dev {
+		// real wallet payloads need the merchant's wallet keys, dev mode takes the fake's unencrypted ones
+		api.SetWalletDecrypter(wallet.ApplePay, wallet.NewFakeDecrypter())
+		api.SetWalletDecrypter(wallet.GooglePay, wallet.NewFakeDecrypter())
+		// there is no CAPTCHA provider to ask, dev mode takes "pass" as the answer
+		api.SetChallenger(abuse.NewFakeChallenger())
+	}
+	api.SetAuthorizationValidity(*authorizationValidity)
+	if *avsDecline != "" {
+		api.SetAVSDeclineResults(strings.Split(*avsDecline, ","))
+	}
+	if *trustedProxies != "" {
+		proxies, err := forwarded.ParseProxies(strings.Split(*trustedProxies, ","))
+		if err != nil {
+			return err
+		}
+		api.SetTrustedProxies(proxies)
+	} else if strings.HasPrefix(*listenAddress, "unix:") {
+		fmt.Printf("clients on a Unix socket cannot be told apart, rate limits and abuse blocks are off unless -trusted-proxies unix is passed\n")
+	}
+	api.StartPaymentWorkers(ctx, *asyncWorkers)
+	if *expiryInterval > 0 {
+		go api.RunExpiry(ctx, *expiryInterval)
+	}
+	if *scheduleInterval > 0 {
+		go api.RunScheduler(ctx, *scheduleInterval)
+	}
+	listener, err := listen.Listen(*listenAddress)
+	if err != nil {
+		return err
+	}
+	if err := api.Serve(ctx, listener, serverConfig()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func serverConfig() api.ServerConfig {
+	return api.ServerConfig{
+		MaxHeaderBytes:    *maxHeaderBytes,
+		ReadHeaderTimeout: *readHeaderTimeout,
+		IdleTimeout:       *idleTimeout,
+		MaxConnections:    *maxConnections,
+		H2C:               *h2c,
+	}
+}
+
+func seedPayments(ctx context.Context, api *api.Api) error {
+	path := *seedFile
+	if path == "" && *dev {
+		path = devSeedFile
+		if _, err := os.Stat(path); err != nil {
+			fmt.Printf("dev mode: not seeding, %s not found (run from the repository root or pass -seed-file)\n", path)
+			return nil
+		}
+	}
+	if path == "" {
+		return nil
+	}
+
+	data, err := seed.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := api.Seed(ctx, data); err != nil {
 		return err
 	}
+	fmt.Printf("seeded %d payments from %s\n", len(data.Payments), path)
 
 	return nil
 }